@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+)
+
+// AuditBucket is the name of the bolt bucket in which audit records are
+// stored.
+const AuditBucket = "audit"
+
+// BoltStore stores audit records in a bolt database.
+//
+// Records are keyed by their time followed by a sequence number, so that
+// time ranges can be queried without reading the entire history.
+type BoltStore struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+// NewBoltStore returns a store that keeps records in db.
+func NewBoltStore(db *bolt.DB) *BoltStore {
+	return &BoltStore{
+		db:     db,
+		bucket: []byte(AuditBucket),
+	}
+}
+
+// Add appends the given records to the database.
+func (s *BoltStore) Add(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(btx *bolt.Tx) error {
+		bucket, err := btx.CreateBucketIfNotExists(s.bucket)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range records {
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+
+			key := make([]byte, 16)
+			binary.BigEndian.PutUint64(key[0:8], uint64(r.Time.UnixNano()))
+			binary.BigEndian.PutUint64(key[8:16], seq)
+			if err := bucket.Put(key, data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Query returns the records selected by filter.
+func (s *BoltStore) Query(filter Filter) (records []Record, err error) {
+	err = s.db.View(func(btx *bolt.Tx) error {
+		bucket := btx.Bucket(s.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		k, v := c.First()
+		if !filter.Start.IsZero() {
+			start := make([]byte, 8)
+			binary.BigEndian.PutUint64(start, uint64(filter.Start.UnixNano()))
+			k, v = c.Seek(start)
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			var r Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if !filter.End.IsZero() && !r.Time.Before(filter.End) {
+				break
+			}
+			if filter.Match(r) {
+				records = append(records, r)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.limit(records), nil
+}
+
+// Close closes the database.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,3 @@
+// Package audit records the history of leases so that their use of
+// resources can be reviewed after the fact.
+package audit
@@ -0,0 +1,35 @@
+package audit
+
+// Event is a type of event in the lifecycle of a lease.
+type Event string
+
+const (
+	// Create indicates that a lease was issued.
+	Create Event = "create"
+
+	// Promote indicates that a queued lease became active.
+	Promote Event = "promote"
+
+	// Demote indicates that an active lease was returned to the queue,
+	// such as when it was preempted by a higher priority lease.
+	Demote Event = "demote"
+
+	// Borrow indicates that an active lease was borrowed for use while
+	// disconnected from the guardian.
+	Borrow Event = "borrow"
+
+	// Renew summarizes the renewals of a lease that took place before it
+	// was released, expired or deleted.
+	Renew Event = "renew"
+
+	// Release indicates that a lease was released by its holder or an
+	// administrator.
+	Release Event = "release"
+
+	// Expire indicates that a lease was released because it was not renewed
+	// in time or it reached the maximum session length.
+	Expire Event = "expire"
+
+	// Delete indicates that a lease was removed.
+	Delete Event = "delete"
+)
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// FileStore stores audit records in an append-only file. Each record is
+// written as a line of JSON.
+type FileStore struct {
+	path string
+
+	mutex sync.Mutex
+	file  *os.File
+}
+
+// NewFileStore returns a store that appends records to the file at path.
+// The file is created if it does not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStore{
+		path: path,
+		file: file,
+	}, nil
+}
+
+// Add appends the given records to the file.
+func (s *FileStore) Add(records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var data []byte
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+
+	_, err := s.file.Write(data)
+	return err
+}
+
+// Query reads the file and returns the records selected by filter.
+//
+// Lines that cannot be decoded, such as a final line that was only partially
+// written, are skipped.
+func (s *FileStore) Query(filter Filter) (records []Record, err error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if filter.Match(r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return filter.limit(records), nil
+}
+
+// Close closes the file.
+func (s *FileStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
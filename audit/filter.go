@@ -0,0 +1,42 @@
+package audit
+
+import "time"
+
+// Filter selects records from a store. Empty fields match all records.
+type Filter struct {
+	Resource string
+	Host     string
+	User     string
+	Start    time.Time // Records before this time are excluded
+	End      time.Time // Records at or after this time are excluded
+	Limit    int       // Maximum number of records, keeping the most recent, or zero for no limit
+}
+
+// Match returns true if r is selected by the filter.
+func (f Filter) Match(r Record) bool {
+	if f.Resource != "" && r.Resource != f.Resource {
+		return false
+	}
+	if f.Host != "" && r.Instance.Host != f.Host {
+		return false
+	}
+	if f.User != "" && r.Instance.User != f.User {
+		return false
+	}
+	if !f.Start.IsZero() && r.Time.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && !r.Time.Before(f.End) {
+		return false
+	}
+	return true
+}
+
+// limit returns the most recent records permitted by the filter, which must
+// be in chronological order.
+func (f Filter) limit(records []Record) []Record {
+	if f.Limit > 0 && len(records) > f.Limit {
+		return records[len(records)-f.Limit:]
+	}
+	return records
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// Record describes an event in the lifecycle of a lease.
+type Record struct {
+	Time  time.Time `json:"time"`
+	Event Event     `json:"event"`
+	lease.Subject
+	Status     lease.Status     `json:"status"`
+	Properties lease.Properties `json:"properties,omitempty"`
+	Units      uint             `json:"units"`
+	Started    time.Time        `json:"started,omitempty"`
+	Renewed    time.Time        `json:"renewed,omitempty"`
+	Released   time.Time        `json:"released,omitempty"`
+	Renewals   uint             `json:"renewals,omitempty"`    // Number of renewals summarized by a renew event
+	PolicyHash string           `json:"policy_hash,omitempty"` // Hash of the policies that issued the lease
+	Operator   string           `json:"operator,omitempty"`    // Administrator that caused the event, if any
+}
+
+// NewRecord returns a record of an event affecting ls at the given time.
+func NewRecord(at time.Time, event Event, ls lease.Lease) Record {
+	r := Record{
+		Time:       at,
+		Event:      event,
+		Subject:    ls.Subject,
+		Status:     ls.Status,
+		Properties: ls.Properties,
+		Units:      ls.Weight(),
+		Started:    ls.Started,
+		Renewed:    ls.Renewed,
+		PolicyHash: ls.PolicyHash,
+	}
+	if ls.Status == lease.Released {
+		r.Released = ls.Released
+	}
+	return r
+}
@@ -0,0 +1,13 @@
+package audit
+
+// Store is an audit record storage interface.
+type Store interface {
+	// Add appends the given records to the store.
+	Add(records ...Record) error
+
+	// Query returns the records selected by filter in chronological order.
+	Query(filter Filter) ([]Record, error)
+
+	// Close releases any resources consumed by the store.
+	Close() error
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian/transport"
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// AdminCmd performs administrative actions on a guardian.
+type AdminCmd struct {
+	Revoke     AdminRevokeCmd     `kong:"cmd,help='Forcibly releases or deletes a lease.'"`
+	Purge      AdminPurgeCmd      `kong:"cmd,help='Forcibly releases or deletes every lease held by a host or user.'"`
+	Hold       AdminHoldCmd       `kong:"cmd,help='Holds units of a resource so that they cannot be leased.'"`
+	Unhold     AdminUnholdCmd     `kong:"cmd,help='Removes a hold.'"`
+	Checkpoint AdminCheckpointCmd `kong:"cmd,help='Records a checkpoint in the transaction log.'"`
+}
+
+// AdminRevokeCmd forcibly releases or deletes a lease.
+type AdminRevokeCmd struct {
+	Server   string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token    string `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+	Delete   bool   `kong:"optional,name='delete',help='Delete the lease instead of releasing it.'"`
+	Reason   string `kong:"optional,name='reason',short='r',help='Explanation given to the holder of the lease.'"`
+	Resource string `kong:"arg,required,name='resource',help='Resource of the lease.'"`
+	Host     string `kong:"arg,required,name='host',help='Host of the lease.'"`
+	User     string `kong:"arg,required,name='user',help='User of the lease.'"`
+	Instance string `kong:"arg,required,name='instance',help='Instance identifier of the lease.'"`
+}
+
+// Run executes the admin revoke command.
+func (cmd *AdminRevokeCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	client := newClient(cmd.Server)
+
+	subject := lease.Subject{
+		Resource: cmd.Resource,
+		Instance: lease.Instance{Host: cmd.Host, User: cmd.User, ID: cmd.Instance},
+	}
+
+	response, err := client.Revoke(ctx, cmd.Token, subject, cmd.Delete, cmd.Reason)
+	if err != nil {
+		return fmt.Errorf("revocation failed: %v", err)
+	}
+
+	return printAdminResponse(response)
+}
+
+// AdminPurgeCmd forcibly releases or deletes every lease held by a host or
+// user.
+type AdminPurgeCmd struct {
+	Server   string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token    string `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+	Delete   bool   `kong:"optional,name='delete',help='Delete the leases instead of releasing them.'"`
+	Reason   string `kong:"optional,name='reason',short='r',help='Explanation given to the holders of the leases.'"`
+	Resource string `kong:"optional,name='resource',help='Only purge leases for this resource.'"`
+	Host     string `kong:"optional,name='host',help='Host whose leases are purged.'"`
+	User     string `kong:"optional,name='user',short='u',help='User whose leases are purged.'"`
+}
+
+// Run executes the admin purge command.
+func (cmd *AdminPurgeCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	if cmd.Host == "" && cmd.User == "" {
+		return fmt.Errorf("a host or user must be specified")
+	}
+
+	client := newClient(cmd.Server)
+
+	response, err := client.Purge(ctx, cmd.Token, cmd.Resource, cmd.Host, cmd.User, cmd.Delete, cmd.Reason)
+	if err != nil {
+		return fmt.Errorf("purge failed: %v", err)
+	}
+
+	return printAdminResponse(response)
+}
+
+// AdminHoldCmd holds units of a resource so that they cannot be leased.
+type AdminHoldCmd struct {
+	Server   string        `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token    string        `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+	Units    uint          `kong:"optional,name='units',short='n',default='1',help='Number of units to hold.'"`
+	Duration time.Duration `kong:"optional,name='duration',short='d',help='Length of the hold. The hold lasts until it is removed if not specified.'"`
+	ID       string        `kong:"optional,name='id',help='Hold identifier. Generated by the guardian if not specified.'"`
+	Note     string        `kong:"optional,name='note',help='Description of the hold.'"`
+	Resource string        `kong:"arg,required,name='resource',help='Resource to hold.'"`
+}
+
+// Run executes the admin hold command.
+func (cmd *AdminHoldCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	client := newClient(cmd.Server)
+
+	response, err := client.Hold(ctx, cmd.Token, cmd.Resource, cmd.Units, cmd.Duration, cmd.ID, cmd.Note)
+	if err != nil {
+		return fmt.Errorf("hold failed: %v", err)
+	}
+
+	return printAdminResponse(response)
+}
+
+// AdminUnholdCmd removes a hold.
+type AdminUnholdCmd struct {
+	Server   string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token    string `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+	Resource string `kong:"arg,required,name='resource',help='Resource that was held.'"`
+	ID       string `kong:"arg,required,name='id',help='Identifier of the hold.'"`
+}
+
+// Run executes the admin unhold command.
+func (cmd *AdminUnholdCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	client := newClient(cmd.Server)
+
+	response, err := client.Unhold(ctx, cmd.Token, cmd.Resource, cmd.ID)
+	if err != nil {
+		return fmt.Errorf("removal failed: %v", err)
+	}
+
+	return printAdminResponse(response)
+}
+
+// AdminCheckpointCmd records a checkpoint in the transaction log.
+type AdminCheckpointCmd struct {
+	Server string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token  string `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+}
+
+// Run executes the admin checkpoint command.
+func (cmd *AdminCheckpointCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	client := newClient(cmd.Server)
+
+	response, err := client.Checkpoint(ctx, cmd.Token)
+	if err != nil {
+		return fmt.Errorf("checkpoint failed: %v", err)
+	}
+
+	return printAdminResponse(response)
+}
+
+// printAdminResponse prints the leases affected by an administrative action.
+func printAdminResponse(response transport.AdminResponse) error {
+	if response.Message != "" {
+		fmt.Println(response.Message)
+	}
+	if len(response.Leases) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RESOURCE\tHOST\tUSER\tINSTANCE\tSTATUS\tUNITS\n")
+	for _, ls := range response.Leases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\n", ls.Resource, ls.Instance.Host, ls.Instance.User, ls.Instance.ID, ls.Status, ls.Weight())
+	}
+	return w.Flush()
+}
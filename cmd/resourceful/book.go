@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// bookingTimeLayouts are the time formats accepted for the start and end of
+// a booking. Times without a zone are interpreted in local time.
+var bookingTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+}
+
+// BookCmd manages bookings of resource units for future windows of time.
+type BookCmd struct {
+	List   BookListCmd   `kong:"cmd,help='Lists current and upcoming bookings.'"`
+	Add    BookAddCmd    `kong:"cmd,help='Books units of a resource for a window of time.'"`
+	Cancel BookCancelCmd `kong:"cmd,help='Cancels a booking.'"`
+}
+
+// BookListCmd lists current and upcoming bookings.
+type BookListCmd struct {
+	Server   string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Resource string `kong:"arg,optional,name='resource',help='Resource to list bookings for.'"`
+}
+
+// Run executes the book list command.
+func (cmd *BookListCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	client := newClient(cmd.Server)
+
+	response, err := client.Bookings(ctx, cmd.Resource)
+	if err != nil {
+		return fmt.Errorf("failed to collect bookings: %v", err)
+	}
+
+	if len(response.Bookings) == 0 {
+		fmt.Printf("No bookings.\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tRESOURCE\tUNITS\tSTART\tEND\tUSERS\tHOSTS\tNOTE\n")
+	for _, ls := range response.Bookings {
+		b := ls.Booking
+		if b == nil {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", b.ID, ls.Resource, ls.Weight(), formatBookingTime(b.Start), formatBookingTime(b.End), strings.Join(b.Users, ","), strings.Join(b.Hosts, ","), b.Note)
+	}
+	return w.Flush()
+}
+
+// BookAddCmd books units of a resource for a window of time.
+type BookAddCmd struct {
+	Server   string        `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token    string        `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+	Resource string        `kong:"arg,required,name='resource',help='Resource to book.'"`
+	Units    uint          `kong:"optional,name='units',short='n',default='1',help='Number of units to book.'"`
+	Start    string        `kong:"optional,name='start',help='Start of the booking, such as \"2006-01-02 15:04\". Defaults to now.'"`
+	End      string        `kong:"optional,name='end',help='End of the booking, such as \"2006-01-02 17:00\".'"`
+	Duration time.Duration `kong:"optional,name='duration',short='d',help='Length of the booking, when an end is not specified.'"`
+	Users    []string      `kong:"optional,name='user',short='u',help='User permitted to use the booked units. May be repeated.'"`
+	Hosts    []string      `kong:"optional,name='host',help='Host permitted to use the booked units. May be repeated.'"`
+	ID       string        `kong:"optional,name='id',help='Booking identifier. Generated by the guardian if not specified.'"`
+	Note     string        `kong:"optional,name='note',help='Description of the booking.'"`
+}
+
+// Run executes the book add command.
+func (cmd *BookAddCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	booking := lease.Booking{
+		ID:    cmd.ID,
+		Users: cmd.Users,
+		Hosts: cmd.Hosts,
+		Note:  cmd.Note,
+	}
+
+	var err error
+	booking.Start = time.Now()
+	if cmd.Start != "" {
+		if booking.Start, err = parseBookingTime(cmd.Start); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case cmd.End != "":
+		if booking.End, err = parseBookingTime(cmd.End); err != nil {
+			return err
+		}
+	case cmd.Duration > 0:
+		booking.End = booking.Start.Add(cmd.Duration)
+	default:
+		return errors.New("an end time or duration must be specified")
+	}
+
+	if len(booking.Users) == 0 && len(booking.Hosts) == 0 {
+		return errors.New("at least one user or host must be specified")
+	}
+
+	client := newClient(cmd.Server)
+
+	response, err := client.Book(ctx, cmd.Token, cmd.Resource, cmd.Units, booking)
+	if err != nil {
+		return fmt.Errorf("booking failed: %v", err)
+	}
+
+	b := response.Booking.Booking
+	if b == nil {
+		return errors.New("booking failed: the guardian did not return the booking")
+	}
+	fmt.Printf("Booked %d unit(s) of %s from %s to %s (id: %s).\n", response.Booking.Weight(), response.Booking.Resource, formatBookingTime(b.Start), formatBookingTime(b.End), b.ID)
+	return nil
+}
+
+// BookCancelCmd cancels a booking.
+type BookCancelCmd struct {
+	Server   string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Token    string `kong:"required,name='token',env='RESOURCEFUL_ADMIN_TOKEN',help='Administrative access token.'"`
+	Resource string `kong:"arg,required,name='resource',help='Resource that was booked.'"`
+	ID       string `kong:"arg,required,name='id',help='Identifier of the booking.'"`
+}
+
+// Run executes the book cancel command.
+func (cmd *BookCancelCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	client := newClient(cmd.Server)
+
+	if _, err := client.Unbook(ctx, cmd.Token, cmd.Resource, cmd.ID); err != nil {
+		return fmt.Errorf("cancellation failed: %v", err)
+	}
+
+	fmt.Printf("Cancelled booking %s of %s.\n", cmd.ID, cmd.Resource)
+	return nil
+}
+
+// parseBookingTime parses a booking time in any of the accepted layouts.
+func parseBookingTime(value string) (time.Time, error) {
+	for _, layout := range bookingTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid booking time \"%s\"", value)
+}
+
+// formatBookingTime formats a booking time in local time.
+func formatBookingTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
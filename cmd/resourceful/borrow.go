@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scjalliance/resourceful/runner"
+)
+
+// BorrowCmd borrows a lease for a program so that it can be run while the
+// guardian cannot be reached.
+type BorrowCmd struct {
+	Server    string        `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	Duration  time.Duration `kong:"optional,name='duration',short='d',help='Length of time to borrow the lease for. Defaults to the maximum permitted by policy.'"`
+	BorrowKey string        `kong:"optional,name='borrowkey',env='RESOURCEFUL_BORROW_KEY',help='Hex-encoded public key of the guardian that signs borrowed leases.'"`
+	Program   string        `kong:"arg,required,name='program',help='Program to borrow a lease for.'"`
+}
+
+// Run executes the borrow command.
+func (cmd *BorrowCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	key, err := parseBorrowKey(cmd.BorrowKey)
+	if err != nil {
+		return err
+	}
+
+	client := newClient(cmd.Server)
+
+	ls, err := runner.Borrow(ctx, client, runner.Config{Program: cmd.Program, BorrowKey: key}, cmd.Duration)
+	if err != nil {
+		return fmt.Errorf("borrowing failed: %v", err)
+	}
+
+	fmt.Printf("Borrowed a lease for %s until %s.\n", ls.Resource, ls.ExpirationTime().Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+// ReturnCmd returns a borrowed lease for a program.
+type ReturnCmd struct {
+	Server    string `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	BorrowKey string `kong:"optional,name='borrowkey',env='RESOURCEFUL_BORROW_KEY',help='Hex-encoded public key of the guardian that signs borrowed leases.'"`
+	Program   string `kong:"arg,required,name='program',help='Program to return the borrowed lease for.'"`
+}
+
+// Run executes the return command.
+func (cmd *ReturnCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	key, err := parseBorrowKey(cmd.BorrowKey)
+	if err != nil {
+		return err
+	}
+
+	client := newClient(cmd.Server)
+
+	if err := runner.Return(ctx, client, runner.Config{Program: cmd.Program, BorrowKey: key}); err != nil {
+		return fmt.Errorf("return failed: %v", err)
+	}
+
+	fmt.Printf("Returned the borrowed lease for %s.\n", cmd.Program)
+	return nil
+}
+
+// parseBorrowKey parses the hex-encoded public key of the guardian that signs
+// borrowed leases. It returns a nil key if value is empty.
+func parseBorrowKey(value string) (ed25519.PublicKey, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(value)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid borrowing public key \"%s\"", value)
+	}
+	return ed25519.PublicKey(key), nil
+}
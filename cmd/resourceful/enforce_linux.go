@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/scjalliance/resourceful/enforcer"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/fsprov"
+)
+
+// Run executes the enforce command.
+func (cmd *EnforceCmd) Run(ctx context.Context) error {
+	client := newClient(cmd.Server)
+
+	logger := cliLogger{
+		Debug: cmd.Debug,
+	}
+
+	environment, err := buildEnvironment()
+	if err != nil {
+		fmt.Printf("Failed to collect environment: %v\n", err)
+		os.Exit(1)
+	}
+
+	var cache policy.Cache
+	if polDir, err := cacheDir(); err != nil {
+		fmt.Printf("Failed to locate cache directory: %v\n", err)
+	} else if err := os.MkdirAll(polDir, 0755); err != nil {
+		fmt.Printf("Failed to create cache directory: %v\n", err)
+	} else {
+		prov := fsprov.New(polDir)
+		defer prov.Close()
+		cache = prov
+	}
+
+	service := enforcer.New(client, time.Second, time.Minute, environment, cache, cmd.Passive, logger)
+
+	service.Start()
+	<-ctx.Done()
+	service.Stop()
+
+	return nil
+}
+
+func runServiceHandler() {
+	fmt.Printf("The resourceful policy enforcer does not run as a service on linux.\n")
+	os.Exit(1)
+}
+
+func cacheDir() (dir string, err error) {
+	dir, err = os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "resourceful", "policycache"), nil
+}
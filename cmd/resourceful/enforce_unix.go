@@ -1,5 +1,5 @@
-//go:build !windows
-// +build !windows
+//go:build !windows && !linux
+// +build !windows,!linux
 
 package main
 
@@ -12,10 +12,10 @@ import (
 
 // Run executes the enforce command.
 func (cmd *EnforceCmd) Run(ctx context.Context) error {
-	return errors.New("the resourceful policy enforcer can only be run on windows")
+	return errors.New("the resourceful policy enforcer can only be run on windows or linux")
 }
 
 func runServiceHandler() {
-	fmt.Printf("The resourceful policy enforcer can only be run on windows.\n")
+	fmt.Printf("The resourceful policy enforcer can only be run on windows or linux.\n")
 	os.Exit(1)
 }
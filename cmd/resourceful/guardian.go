@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"io/fs"
 	"log"
@@ -13,24 +17,46 @@ import (
 	"time"
 
 	"github.com/boltdb/bolt"
+	"github.com/scjalliance/resourceful/audit"
 	"github.com/scjalliance/resourceful/guardian"
 	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/auditprov"
 	"github.com/scjalliance/resourceful/provider/boltprov"
 	"github.com/scjalliance/resourceful/provider/cacheprov"
 	"github.com/scjalliance/resourceful/provider/fsprov"
 	"github.com/scjalliance/resourceful/provider/logprov"
 	"github.com/scjalliance/resourceful/provider/memprov"
+	"github.com/scjalliance/resourceful/provider/sqlprov"
+	"github.com/scjalliance/resourceful/txlog"
+
+	// Database drivers for the SQL lease provider
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 // GuardianCmd runs a guardian policy server.
 type GuardianCmd struct {
 	LeaseStorage  string        `kong:"optional,name='leasestore',env='LEASE_STORE',default='memory',help='Lease storage type.'"`
 	BoltPath      string        `kong:"optional,name='boltpath',env='BOLT_PATH',default='resourceful.boltdb',help='Bolt database file path.'"`
+	SQLDialect    string        `kong:"optional,name='sqldialect',env='SQL_DIALECT',enum='sqlite,postgres',default='sqlite',help='SQL database dialect (sqlite or postgres).'"`
+	SQLSource     string        `kong:"optional,name='sqldsn',env='SQL_DSN',default='resourceful.sqlite',help='SQL data source name.'"`
 	PolicyPath    string        `kong:"optional,name='policypath',env='POLICY_PATH',help='Policy directory path.'"`
+	PolicyPoll    time.Duration `kong:"optional,name='policypoll',env='POLICY_POLL',default='5s',help='Interval at which the policy directory is checked for changes. Zero disables polling.'"`
 	TxPath        string        `kong:"optional,name='txlog',env='TRANSACTION_LOG',default='resourceful.tx.log',help='Transaction log file path.'"`
+	TxReplay      bool          `kong:"optional,name='txreplay',env='TRANSACTION_REPLAY',default='true',negatable,help='Restore in-memory lease state from the transaction log at startup.'"`
+	TxFormat      string        `kong:"optional,name='txformat',env='TRANSACTION_LOG_FORMAT',enum='text,json',default='text',help='Transaction log format (text or json).'"`
+	TxMaxSize     int64         `kong:"optional,name='txmaxsize',env='TRANSACTION_LOG_MAX_SIZE',help='Size in megabytes at which the transaction log is rotated. Zero disables size-based rotation.'"`
+	TxMaxAge      time.Duration `kong:"optional,name='txmaxage',env='TRANSACTION_LOG_MAX_AGE',help='Age at which the transaction log is rotated. Zero disables age-based rotation.'"`
+	TxCompress    bool          `kong:"optional,name='txcompress',env='TRANSACTION_LOG_COMPRESS',help='Compress rotated transaction log segments with gzip.'"`
+	TxKeep        int           `kong:"optional,name='txkeep',env='TRANSACTION_LOG_KEEP',help='Number of rotated transaction log segments to keep. Zero keeps them all.'"`
 	Schedule      string        `kong:"optional,name='cpschedule',env='CHECKPOINT_SCHEDULE',help='Transaction checkpoint schedule.'"`
 	StatHatKey    string        `kong:"optional,name='stathatkey',env='STATHAT_KEY',help='Optional StatHat key for recording statistics.'"`
 	StatsInterval time.Duration `kong:"optional,name='stats',env='STATS_INTERVAL',default='1m',help='Optional interval for recording statistics.'"`
+	BorrowKey     string        `kong:"optional,name='borrowkey',env='BORROW_KEY',help='Path of the key used to sign borrowed leases. Created if it does not exist. Borrowing is disabled when no path is given.'"`
+	AdminTokens   string        `kong:"optional,name='admintokens',env='ADMIN_TOKENS',help='Path of a file that lists the access tokens of administrators, one \"operator token\" pair per line.'"`
+	AuditStorage  string        `kong:"optional,name='auditstore',env='AUDIT_STORE',default='none',help='Lease history storage type (file, bolt or none).'"`
+	AuditPath     string        `kong:"optional,name='auditpath',env='AUDIT_PATH',default='resourceful.audit',help='Lease history file or bolt database path.'"`
 }
 
 // Run executes the guardian command.
@@ -80,7 +106,12 @@ func (cmd *GuardianCmd) Run(ctx context.Context) (err error) {
 	logger.Println("Starting resourceful guardian daemon")
 	defer logger.Printf("Stopped resourceful guardian daemon")
 
-	txFile, err := createTransactionLog(cmd.TxPath)
+	txFile, err := createTransactionLog(cmd.TxPath, txlog.Rotation{
+		MaxSize:  cmd.TxMaxSize * 1024 * 1024,
+		MaxAge:   cmd.TxMaxAge,
+		Compress: cmd.TxCompress,
+		Keep:     cmd.TxKeep,
+	})
 	if err != nil {
 		logger.Printf("Unable to open transaction log: %v", err)
 		return
@@ -89,23 +120,62 @@ func (cmd *GuardianCmd) Run(ctx context.Context) (err error) {
 		defer txFile.Close()
 	}
 
-	leaseProvider, err := createLeaseProvider(cmd.LeaseStorage, cmd.BoltPath)
+	borrowKey, err := loadBorrowKey(cmd.BorrowKey)
 	if err != nil {
-		logger.Printf("Unable to create lease provider: %v", err)
+		logger.Printf("Unable to load borrowing key: %v", err)
 		return
 	}
-
-	if txFile != nil {
-		txLogger := log.New(txFile, "", log.LstdFlags)
-		leaseProvider = logprov.New(leaseProvider, txLogger, checkpointSchedule...)
+	if borrowKey != nil {
+		logger.Printf("Borrowed leases are verified with public key %s", hex.EncodeToString(borrowKey.Public().(ed25519.PublicKey)))
 	}
 
-	defer closeProvider(leaseProvider, "lease", logger)
+	operators, err := loadOperators(cmd.AdminTokens)
+	if err != nil {
+		logger.Printf("Unable to load administrative access tokens: %v", err)
+		return
+	}
 
 	policyProvider := cacheprov.New(fsprov.New(cmd.PolicyPath))
 
 	defer closeProvider(policyProvider, "policy", logger)
 
+	leaseProvider, err := createLeaseProvider(cmd.LeaseStorage, cmd.BoltPath, cmd.SQLDialect, cmd.SQLSource)
+	if err != nil {
+		logger.Printf("Unable to create lease provider: %v", err)
+		return
+	}
+
+	// Leases held in memory are lost when the guardian stops, so they are
+	// rebuilt from the transaction log before it is wrapped around them
+	if _, inMemory := leaseProvider.(*memprov.Provider); inMemory && txFile != nil && cmd.TxReplay {
+		if err := restoreTransactionLog(leaseProvider, policyProvider, cmd.TxPath, logger); err != nil {
+			logger.Printf("Unable to restore leases from the transaction log: %v", err)
+		}
+	}
+
+	history, err := createAuditStore(cmd.AuditStorage, cmd.AuditPath)
+	if err != nil {
+		logger.Printf("Unable to open lease history store: %v", err)
+		closeProvider(leaseProvider, "lease", logger)
+		return
+	}
+
+	if history != nil {
+		leaseProvider = auditprov.New(leaseProvider, history, logger)
+	}
+
+	if txFile != nil {
+		switch cmd.TxFormat {
+		case "json":
+			leaseProvider = logprov.NewJSON(leaseProvider, txFile, checkpointSchedule...)
+		default:
+			txLogger := log.New(txFile, "", log.LstdFlags)
+			leaseProvider = logprov.New(leaseProvider, txLogger, checkpointSchedule...)
+		}
+	}
+
+	defer closeProvider(leaseProvider, "lease", logger)
+
 	// The embeded file system contains all files in a www directory, which
 	// is an unnecessary detail we would like to hide form the world.
 	fsys, err := fs.Sub(webfiles, "www")
@@ -121,6 +191,9 @@ func (cmd *GuardianCmd) Run(ctx context.Context) (err error) {
 		ShutdownTimeout: 5 * time.Second,
 		Logger:          logger,
 		Handler:         http.FileServer(http.FS(fsys)),
+		BorrowKey:       borrowKey,
+		Operators:       operators,
+		History:         history,
 	}
 
 	logger.Printf("Created providers (policy: %s, lease: %s)", policyProvider.ProviderName(), leaseProvider.ProviderName())
@@ -173,12 +246,12 @@ func (cmd *GuardianCmd) Run(ctx context.Context) (err error) {
 			}
 		}()
 
-		err = guardian.Run(ctx, cfg)
+		err = runGuardian(ctx, cfg, policyProvider, cmd.PolicyPoll)
 
 		statsCancel()
 		wg.Wait()
 	} else {
-		err = guardian.Run(ctx, cfg)
+		err = runGuardian(ctx, cfg, policyProvider, cmd.PolicyPoll)
 	}
 
 	if err != http.ErrServerClosed {
@@ -187,6 +260,27 @@ func (cmd *GuardianCmd) Run(ctx context.Context) (err error) {
 	return
 }
 
+// runGuardian runs a guardian server with the given configuration. While
+// the server is running its policies are reloaded whenever they change.
+func runGuardian(ctx context.Context, cfg guardian.ServerConfig, policyProvider *cacheprov.Provider, pollInterval time.Duration) error {
+	server := guardian.NewServer(cfg)
+
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		watchPolicies(watchCtx, policyProvider, server, pollInterval, cfg.Logger)
+	}()
+
+	err := server.Run(ctx)
+
+	watchCancel()
+	wg.Wait()
+
+	return err
+}
+
 func createStatRecipient(statHatKey string) StatRecipient {
 	if statHatKey != "" {
 		return NewStatHatRecipient("resourceful", statHatKey)
@@ -194,14 +288,99 @@ func createStatRecipient(statHatKey string) StatRecipient {
 	return nil
 }
 
-func createTransactionLog(path string) (file *os.File, err error) {
+func createTransactionLog(path string, rotation txlog.Rotation) (file *txlog.File, err error) {
+	if path == "" {
+		return nil, nil
+	}
+	return txlog.OpenFile(path, rotation)
+}
+
+// loadBorrowKey loads the private key used to sign borrowed leases from the
+// hex-encoded seed stored at path. If the file does not exist a new key is
+// generated and written to it.
+func loadBorrowKey(path string) (ed25519.PrivateKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		seed, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid key file \"%s\"", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	case os.IsNotExist(err):
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(key.Seed())+"\n"), 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	default:
+		return nil, err
+	}
+}
+
+// loadOperators loads the administrative access tokens listed in the file at
+// path. Each line of the file holds the name of an operator followed by
+// their access token. Blank lines and lines starting with # are ignored.
+func loadOperators(path string) (map[string]string, error) {
 	if path == "" {
 		return nil, nil
 	}
-	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	operators := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected an operator name and an access token", path, i+1)
+		}
+		operators[fields[1]] = fields[0]
+	}
+	return operators, nil
+}
+
+// restoreTransactionLog restores the leases recorded in the transaction log
+// at path within provider.
+func restoreTransactionLog(provider lease.Provider, policyProvider policy.Provider, path string, logger *log.Logger) error {
+	policies, err := policyProvider.Policies()
+	if err != nil {
+		return err
+	}
+
+	restored, plain, err := restoreLeases(provider, path, policies, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if plain {
+		logger.Printf("Part of the transaction log was written without detail lines, so queued leases recorded there were not restored and units were taken from the current policies")
+	}
+
+	switch restored {
+	case 0:
+	case 1:
+		logger.Printf("Restored 1 lease from the transaction log")
+	default:
+		logger.Printf("Restored %d leases from the transaction log", restored)
+	}
+	return nil
 }
 
-func createLeaseProvider(storage string, boltPath string) (lease.Provider, error) {
+func createLeaseProvider(storage string, boltPath string, sqlDialect string, sqlSource string) (lease.Provider, error) {
 	switch strings.ToLower(storage) {
 	case "mem", "memory":
 		return memprov.New(), nil
@@ -211,11 +390,66 @@ func createLeaseProvider(storage string, boltPath string) (lease.Provider, error
 			return nil, fmt.Errorf("unable to open or create bolt database \"%s\": %v", boltPath, err)
 		}
 		return boltprov.New(boltdb), nil
+	case "sql":
+		return createSQLProvider(sqlDialect, sqlSource)
 	default:
 		return nil, fmt.Errorf("unknown lease storage type: %s", storage)
 	}
 }
 
+func createSQLProvider(dialectName string, source string) (lease.Provider, error) {
+	dialect, ok := sqlprov.DialectByName(dialectName)
+	if !ok {
+		return nil, fmt.Errorf("unknown SQL dialect: %s", dialectName)
+	}
+
+	var driver string
+	switch dialect.Name {
+	case sqlprov.Postgres.Name:
+		driver = "postgres"
+	default:
+		driver = "sqlite"
+	}
+
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s database: %v", dialect.Name, err)
+	}
+	if driver == "sqlite" {
+		// SQLite allows a single writer at a time, so sharing one connection
+		// avoids busy errors between concurrent transactions
+		db.SetMaxOpenConns(1)
+	}
+
+	provider, err := sqlprov.New(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to prepare %s database: %v", dialect.Name, err)
+	}
+	return provider, nil
+}
+
+func createAuditStore(storage string, path string) (audit.Store, error) {
+	switch strings.ToLower(storage) {
+	case "", "none":
+		return nil, nil
+	case "file":
+		store, err := audit.NewFileStore(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open or create lease history file \"%s\": %v", path, err)
+		}
+		return store, nil
+	case "bolt", "boltdb":
+		boltdb, err := bolt.Open(path, 0666, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open or create bolt database \"%s\": %v", path, err)
+		}
+		return audit.NewBoltStore(boltdb), nil
+	default:
+		return nil, fmt.Errorf("unknown lease history storage type: %s", storage)
+	}
+}
+
 type closer interface {
 	Close() error
 }
@@ -0,0 +1,25 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/scjalliance/resourceful/enforcer"
+)
+
+type cliLogger struct {
+	Debug bool
+}
+
+func (l cliLogger) Log(e enforcer.Event) {
+	if e.IsDebug() && !l.Debug {
+		return
+	}
+	s := e.String()
+	if len(s) == 0 || s[len(s)-1] != '\n' {
+		s = s + "\n"
+	}
+	fmt.Print(s)
+}
@@ -26,11 +26,18 @@ func main() {
 	defer stop()
 
 	var cli struct {
+		Run       RunCmd       `kong:"cmd,help='Runs a program if a lease can be procured for it.'"`
 		List      ListCmd      `kong:"cmd,help='Lists running processes that match current policies.'"`
 		Install   InstallCmd   `kong:"cmd,help='Installs the resourceful enforcer service on the local machine.'"`
 		Uninstall UninstallCmd `kong:"cmd,help='Uninstalls the resourceful enforcer service from the local machine.'"`
 		Enforce   EnforceCmd   `kong:"cmd,help='Enforces resourceful policies on the local machine.'"`
 		Guardian  GuardianCmd  `kong:"cmd,help='Runs a guardian policy server.'"`
+		Book      BookCmd      `kong:"cmd,help='Manages bookings of resource units for future windows of time.'"`
+		Borrow    BorrowCmd    `kong:"cmd,help='Borrows a lease so that a program can be run while disconnected.'"`
+		Return    ReturnCmd    `kong:"cmd,help='Returns a borrowed lease.'"`
+		Admin     AdminCmd     `kong:"cmd,help='Performs administrative actions on a guardian.'"`
+		Report    ReportCmd    `kong:"cmd,help='Summarizes resource usage recorded in a transaction log.'"`
+		Txlog     TxlogCmd     `kong:"cmd,help='Examines transaction logs.'"`
 		UI        UICmd        `kong:"cmd,help='Starts a user interface agent.'"`
 	}
 
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian"
+	"github.com/scjalliance/resourceful/provider/cacheprov"
+)
+
+// watchPolicies reloads the policies cached by prov whenever its source
+// changes or a SIGHUP signal is received, until ctx is cancelled. Each
+// reload is published to the stream listeners of server.
+//
+// The source is polled for changes at the given interval. If interval is
+// zero, policies are only reloaded when a signal is received. Failures to
+// poll the source are logged when they first occur and when they change.
+func watchPolicies(ctx context.Context, prov *cacheprov.Provider, server *guardian.Server, interval time.Duration, logger *log.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		poll = t.C
+	}
+
+	var failure string // The most recent failure to poll the source
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Printf("POL: Received reload signal")
+		case <-poll:
+			changed, err := prov.Changed()
+			if err != nil {
+				if err.Error() != failure {
+					failure = err.Error()
+					logger.Printf("POL: Unable to check policy source for changes: %v", err)
+				}
+				continue
+			}
+			if failure != "" {
+				failure = ""
+				logger.Printf("POL: Policy source is available again")
+			}
+			if !changed {
+				continue
+			}
+			logger.Printf("POL: Change detected in policy source")
+		}
+
+		reloadPolicies(prov, server, logger)
+	}
+}
+
+// reloadPolicies reloads the policies cached by prov, logs the changes and
+// publishes the new policy set to the stream listeners of server.
+func reloadPolicies(prov *cacheprov.Provider, server *guardian.Server, logger *log.Logger) {
+	policies, additions, deletions, err := prov.Reload()
+	if err != nil {
+		logger.Printf("POL: Failed to reload policies, keeping previous policy set: %v", err)
+		return
+	}
+
+	switch d := len(policies); d {
+	case 1:
+		logger.Printf("POL: Reloaded 1 policy")
+	default:
+		logger.Printf("POL: Reloaded %d policies", d)
+	}
+
+	for _, pol := range additions {
+		logger.Printf("POL: ADD %s: %s", pol.Hash().String(), pol.String())
+	}
+	for _, pol := range deletions {
+		logger.Printf("POL: REM %s: %s", pol.Hash().String(), pol.String())
+	}
+
+	server.PublishPolicies(policies)
+}
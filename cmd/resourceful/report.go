@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/fsprov"
+	"github.com/scjalliance/resourceful/provider/logprov"
+	"github.com/scjalliance/resourceful/report"
+	"github.com/scjalliance/resourceful/txlog"
+)
+
+// reportTimeLayouts are the time formats accepted for the period of a
+// report. Times without a zone are interpreted in local time.
+var reportTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ReportCmd summarizes resource usage recorded in a transaction log.
+type ReportCmd struct {
+	TxPath     string `kong:"optional,name='txlog',env='TRANSACTION_LOG',default='resourceful.tx.log',help='Transaction log file path.'"`
+	PolicyPath string `kong:"optional,name='policypath',env='POLICY_PATH',help='Policy directory path. Used to determine resource limits.'"`
+	From       string `kong:"optional,name='from',help='Start of the reporting period, such as \"2006-01-02\". Defaults to the start of the log.'"`
+	To         string `kong:"optional,name='to',help='End of the reporting period (exclusive), such as \"2006-02-01\". Defaults to now.'"`
+	Format     string `kong:"optional,name='format',short='f',enum='table,csv,json',default='table',help='Output format (table, csv or json).'"`
+	Users      bool   `kong:"optional,name='users',short='u',help='Include per-user hours in table and csv output.'"`
+}
+
+// Run executes the report command.
+func (cmd *ReportCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	var from, to time.Time
+	var err error
+	if cmd.From != "" {
+		if from, err = parseReportTime(cmd.From); err != nil {
+			return err
+		}
+	}
+	if cmd.To != "" {
+		if to, err = parseReportTime(cmd.To); err != nil {
+			return err
+		}
+	} else {
+		to = time.Now()
+	}
+	if !from.IsZero() && !to.After(from) {
+		return fmt.Errorf("the end of the reporting period must be after its start")
+	}
+
+	var limits report.LimitFunc
+	if cmd.PolicyPath != "" {
+		policies, err := fsprov.New(cmd.PolicyPath).Policies()
+		if err != nil {
+			return fmt.Errorf("failed to load policies: %v", err)
+		}
+		limits = policyLimits(policies)
+	}
+
+	file, err := txlog.OpenSegments(cmd.TxPath)
+	if err != nil {
+		return fmt.Errorf("unable to open transaction log: %v", err)
+	}
+	defer file.Close()
+
+	builder := report.NewBuilder(from, to, limits)
+	scanner := logprov.NewScanner(file, time.Local)
+	for scanner.Scan() {
+		builder.Add(scanner.Entry())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read transaction log: %v", err)
+	}
+	if scanner.Plain() {
+		fmt.Fprintf(os.Stderr, "Warning: part of the transaction log was written without detail lines, so queued and denied leases are not counted there and each of its leases counts as one unit.\n")
+	}
+
+	r := builder.Report()
+
+	switch cmd.Format {
+	case "csv":
+		return writeReportCSV(r, cmd.Users)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	default:
+		return writeReportTable(r, cmd.Users)
+	}
+}
+
+// policyLimits returns a limit function that determines the limit of each
+// resource from its policies. Schedules are not taken into account.
+func policyLimits(policies policy.Set) report.LimitFunc {
+	return func(resource string) uint {
+		matches := policies.MatchResource(resource)
+		if len(matches) == 0 {
+			return 0
+		}
+		return matches.Limit()
+	}
+}
+
+func writeReportTable(r report.Report, users bool) error {
+	fmt.Printf("Resource usage from %s to %s\n\n", r.From.Local().Format("2006-01-02 15:04"), r.To.Local().Format("2006-01-02 15:04"))
+
+	if len(r.Resources) == 0 {
+		fmt.Printf("No usage recorded.\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RESOURCE\tLIMIT\tPEAK\tHOURS AT LIMIT\tSEAT HOURS\tSESSIONS\tQUEUED\tDENIED\n")
+	for _, res := range r.Resources {
+		limit, atLimit := "-", "-"
+		if res.Limit > 0 {
+			limit = strconv.FormatUint(uint64(res.Limit), 10)
+			atLimit = fmt.Sprintf("%.2f", res.AtLimit)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%.2f\t%d\t%d\t%d\n", res.Resource, limit, res.Peak, atLimit, res.SeatHours, res.Sessions, res.Queued, res.Denials)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !users {
+		return nil
+	}
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RESOURCE\tUSER\tHOURS\tSESSIONS\n")
+	for _, res := range r.Resources {
+		for _, usr := range res.Users {
+			fmt.Fprintf(w, "%s\t%s\t%.2f\t%d\n", res.Resource, usr.User, usr.Hours, usr.Sessions)
+		}
+	}
+	return w.Flush()
+}
+
+func writeReportCSV(r report.Report, users bool) error {
+	w := csv.NewWriter(os.Stdout)
+	if users {
+		w.Write([]string{"resource", "user", "hours", "sessions"})
+		for _, res := range r.Resources {
+			for _, usr := range res.Users {
+				w.Write([]string{res.Resource, usr.User, formatHours(usr.Hours), strconv.Itoa(usr.Sessions)})
+			}
+		}
+	} else {
+		w.Write([]string{"resource", "limit", "peak", "at_limit_hours", "seat_hours", "sessions", "queued", "denials"})
+		for _, res := range r.Resources {
+			w.Write([]string{
+				res.Resource,
+				strconv.FormatUint(uint64(res.Limit), 10),
+				strconv.FormatUint(uint64(res.Peak), 10),
+				formatHours(res.AtLimit),
+				formatHours(res.SeatHours),
+				strconv.Itoa(res.Sessions),
+				strconv.Itoa(res.Queued),
+				strconv.Itoa(res.Denials),
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', 4, 64)
+}
+
+func parseReportTime(value string) (time.Time, error) {
+	for _, layout := range reportTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid report time \"%s\"", value)
+}
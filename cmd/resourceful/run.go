@@ -1,59 +1,57 @@
 package main
 
-/*
-// RunCommand returns a run command and configuration for app.
-func RunCommand(app *kingpin.Application) (*kingpin.CmdClause, *RunConfig) {
-	cmd := app.Command("run", "Runs a program if a lease can be procured for it.")
-	conf := &RunConfig{}
-	conf.Bind(cmd)
-	return cmd, conf
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"github.com/scjalliance/resourceful/lease/leaseui"
+	"github.com/scjalliance/resourceful/runner"
+)
+
+// RunCmd runs a program if a lease can be procured for it.
+type RunCmd struct {
+	Server    string   `kong:"optional,name='server',short='s',help='Guardian policy server host and port.'"`
+	BorrowKey string   `kong:"optional,name='borrowkey',env='RESOURCEFUL_BORROW_KEY',help='Hex-encoded public key of the guardian that signs borrowed leases.'"`
+	Program   string   `kong:"arg,passthrough,name='program',help='Program to run.'"`
+	Args      []string `kong:"arg,optional,name='arguments',help='Program arguments.'"`
 }
 
-// RunConfig holds configuration for the run command.
-type RunConfig struct {
-	Server  string
-	Program string
-	Args    []string
-}
-
-// Bind binds the guardian configuration to the command.
-func (conf *RunConfig) Bind(cmd *kingpin.CmdClause) {
-	cmd.Flag("server", "Guardian policy server host and port.").Short('s').StringVar(&conf.Server)
-	cmd.Arg("program", "program to run").Required().StringVar(&conf.Program)
-	cmd.Arg("arguments", "program arguments").StringsVar(&conf.Args)
-}
+// Run executes the run command.
+func (cmd *RunCmd) Run(ctx context.Context) error {
+	if cmd.Program == "" {
+		runError(errors.New("no executable path provided to run"))
+	}
 
-func runError(err error) {
-	leaseui.Notify("resourceful run error", err.Error())
-	os.Exit(2)
-}
+	// The lease user interface redraws its status in place when attached to
+	// a terminal, and log output would garble it.
+	if leaseui.IsTerminal(os.Stderr) {
+		log.SetOutput(io.Discard)
+	}
 
-func run(ctx context.Context, conf RunConfig) {
-	if conf.Program == "" {
-		runError(errors.New("no executable path provided to run"))
+	key, err := parseBorrowKey(cmd.BorrowKey)
+	if err != nil {
+		runError(err)
 	}
 
-	client := newClient(conf.Server)
+	client := newClient(cmd.Server)
 
-	err := runner.Run(ctx, client, runner.Config{
-		Icon:    programIcon(),
-		Program: conf.Program,
-		Args:    conf.Args,
+	err = runner.Run(ctx, client, runner.Config{
+		Icon:      programIcon(),
+		Program:   cmd.Program,
+		Args:      cmd.Args,
+		BorrowKey: key,
 	})
 	if err != nil {
 		runError(err)
 	}
+
+	return nil
 }
 
-// splitEndpointArgs extracts a single -s argument from the start of the arg
-// list if present and interpets it as a guardian endpoint. Any remaining
-// arguments are returned and will be passed to the executable being run.
-func splitEndpointArgs(combined []string) (endpoints []guardian.Endpoint, args []string) {
-	args = combined
-	for len(args) > 2 && args[0] == "-s" && args[1] != "" {
-		endpoints = append(endpoints, guardian.Endpoint(args[1]))
-		args = args[2:]
-	}
-	return
+func runError(err error) {
+	leaseui.Notify("resourceful run error", err.Error())
+	os.Exit(2)
 }
-*/
@@ -32,6 +32,12 @@ func (r StatHatRecipient) SendResource(resource string, stats ResourceStats) err
 	if err := r.send(resource, "active", stats.Active, stats.Time); err != nil {
 		return err
 	}
+	if err := r.send(resource, "borrowed", stats.Borrowed, stats.Time); err != nil {
+		return err
+	}
+	if err := r.send(resource, "held", stats.Held, stats.Time); err != nil {
+		return err
+	}
 	if err := r.send(resource, "released", stats.Released, stats.Time); err != nil {
 		return err
 	}
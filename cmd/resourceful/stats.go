@@ -24,6 +24,8 @@ type ResourceStats struct {
 	Consumed uint
 	Limit    uint
 	Active   uint
+	Borrowed uint
+	Held     uint
 	Released uint
 	Queued   uint
 	Users    UserStatsMap
@@ -179,6 +181,8 @@ func (m *StatManager) collect(polProv policy.Provider, leaseProv lease.Provider,
 			Consumed: data.Consumed(strat),
 			Limit:    limit,
 			Active:   data.Active(strat),
+			Borrowed: data.Borrowed(strat),
+			Held:     data.Held(strat),
 			Released: data.Released(strat),
 			Queued:   data.Queued(strat),
 			Users:    users,
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/logprov"
+	"github.com/scjalliance/resourceful/txlog"
+)
+
+// TxlogCmd examines transaction logs.
+type TxlogCmd struct {
+	Replay TxlogReplayCmd `kong:"cmd,help='Prints the lease state recorded in a transaction log at a point in time.'"`
+}
+
+// TxlogReplayCmd prints the lease state recorded in a transaction log at a
+// point in time.
+type TxlogReplayCmd struct {
+	TxPath string `kong:"optional,name='txlog',env='TRANSACTION_LOG',default='resourceful.tx.log',help='Transaction log file path.'"`
+	At     string `kong:"optional,name='at',help='Point in time to reconstruct, such as \"2006-01-02 15:04\". Defaults to the end of the log.'"`
+}
+
+// Run executes the txlog replay command.
+func (cmd *TxlogReplayCmd) Run(ctx context.Context) error {
+	prepareConsole(false)
+
+	var at time.Time
+	if cmd.At != "" {
+		var err error
+		if at, err = parseReportTime(cmd.At); err != nil {
+			return err
+		}
+	}
+
+	file, err := txlog.OpenSegments(cmd.TxPath)
+	if err != nil {
+		return fmt.Errorf("unable to open transaction log: %v", err)
+	}
+	defer file.Close()
+
+	leases, plain, err := logprov.Replay(file, time.Local, at)
+	if err != nil {
+		return fmt.Errorf("unable to replay transaction log: %v", err)
+	}
+	if plain {
+		fmt.Fprintf(os.Stderr, "Warning: part of the transaction log was written without detail lines, so queued leases are missing from it and each of its leases counts as one unit.\n")
+	}
+
+	if len(leases) == 0 {
+		fmt.Printf("No leases.\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RESOURCE\tHOST\tUSER\tINSTANCE\tSTATUS\tUNITS\tSTARTED\n")
+	for _, ls := range leases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", ls.Resource, ls.Instance.Host, ls.Instance.User, ls.Instance.ID, ls.Status, ls.Weight(), ls.Started.Local().Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+// restoreLeases rebuilds the leases recorded in the transaction log at path
+// within provider, which is expected to be empty. It returns the number of
+// leases that were restored, and plain as true if part of the log was written
+// without the detail lines that record the queue and the units held by each
+// lease.
+//
+// Lines of text in the log do not record the terms of each lease, so they
+// are taken from the current policies. Leases for resources that are no
+// longer governed by a policy are discarded. Active and queued leases are
+// treated as though they were renewed at the given time, which gives their
+// holders a chance to renew them before they expire.
+func restoreLeases(provider lease.Provider, path string, policies policy.Set, now time.Time) (restored int, plain bool, err error) {
+	file, err := txlog.OpenSegments(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	defer file.Close()
+
+	leases, plain, err := logprov.Replay(file, time.Local, time.Time{})
+	if err != nil {
+		return 0, false, err
+	}
+
+	byResource := make(map[string]lease.Set)
+	for _, ls := range leases {
+		if ls.Status != lease.Held {
+			matches := policies.MatchResource(ls.Resource)
+			if len(matches) == 0 {
+				continue
+			}
+			ls = restoreTerms(ls, matches, now)
+		}
+		byResource[ls.Resource] = append(byResource[ls.Resource], ls)
+	}
+
+	for resource, leases := range byResource {
+		revision, existing, err := provider.LeaseView(resource)
+		if err != nil {
+			return restored, plain, err
+		}
+		tx := lease.NewTx(resource, revision, existing)
+		for _, ls := range leases {
+			tx.Create(ls)
+		}
+		if err := provider.LeaseCommit(tx); err != nil {
+			return restored, plain, err
+		}
+		restored += len(leases)
+	}
+
+	return restored, plain, nil
+}
+
+// restoreTerms applies the terms of policies to a lease reconstructed from
+// the transaction log, unless the log recorded the terms of the lease.
+func restoreTerms(ls lease.Lease, policies policy.Set, now time.Time) lease.Lease {
+	if ls.Duration == 0 {
+		ls.Strategy = policies.Strategy()
+		ls.Limit = policies.Limit()
+		ls.MaxSession = policies.MaxSession()
+		ls.MaxPerUser = policies.MaxPerUser()
+		ls.MaxPerHost = policies.MaxPerHost()
+		ls.Grace = policies.Grace()
+		ls.Priority = policies.Priority()
+		ls.Preemption = policies.Preemption()
+		ls.Duration = policies.Duration()
+		ls.Decay = policies.Decay()
+		ls.Refresh = policies.Refresh()
+		ls.Consumer = policies.Consumer()
+		ls.PolicyHash = policies.Hash().String()
+		if ls.Units == 0 {
+			// Plain lines of text don't record units
+			ls.Units = policies.Units()
+		}
+		if reservations := policies.Reservations(); len(reservations) > 0 {
+			ls.Reservations = reservations.Leased()
+		}
+		if ls.Status == lease.Borrowed {
+			// The length of the borrowing period isn't recorded, so the
+			// lease is assumed to have been borrowed for as long as is
+			// permitted
+			ls.Duration = policies.MaxBorrow()
+		}
+	}
+
+	if ls.Status == lease.Active || ls.Status == lease.Queued {
+		ls.Renewed = now
+	}
+
+	return ls
+}
@@ -284,7 +284,27 @@
 			}
 		}
 
+		const parseBooking = function(lease) {
+			const booking = lease.booking;
+			return {
+				"id": lease.instance.id,
+				"pid": "",
+				"resource": lease.resource,
+				"program": booking.note ? lease.resource + " (" + booking.note + ")" : lease.resource,
+				"user": (booking.users || []).join(", "),
+				"computer": (booking.hosts || []).join(", "),
+				"status": lease.status,
+				"units": String(lease.units || 1),
+				"started": booking.start,
+				"released": "",
+				"death": booking.end,
+			};
+		};
+
 		const parseLease = function(lease) {
+			if (lease.status == "booked") {
+				return parseBooking(lease);
+			}
 			return {
 				"id": lease.instance.id,
 				"pid": lease.properties["process.id"],
@@ -293,6 +313,7 @@
 				"user": lease.properties["user.account"] || lease.properties["user.id"] || lease.instance.user,
 				"computer": lease.properties["host.name"] || lease.instance.host,
 				"status": lease.status,
+				"units": String(lease.units || 1),
 				"started": lease.properties["process.creation"] || lease.started,
 				"released": lease.released,
 				"death": timeOfDeath(lease.status, lease.duration, lease.decay, lease.renewed, lease.released),
@@ -307,6 +328,7 @@
 				makeCell("computer", lease.computer),
 				makeCell("pid", lease.pid),
 				makeCell("status", lease.status),
+				makeCell("units", lease.units),
 				makeTimeSinceCell("time", lease.started),
 				makeTimeUntilCell("remaining", lease.death)
 			);
@@ -319,7 +341,7 @@
 		const updateLease = function(row, lease) {
 			row.className = "status-" + lease.status;
 			row.dataset.death = lease.death;
-			updateRow(row, lease.program, lease.user, lease.computer, lease.pid, lease.status, lease.started, lease.death);
+			updateRow(row, lease.program, lease.user, lease.computer, lease.pid, lease.status, lease.units, lease.started, lease.death);
 		};
 
 		const collectChildrenForResource = function(parent, resource) {
@@ -0,0 +1,41 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import "os"
+
+var blocklist = map[string]bool{
+	"agetty":         true,
+	"bash":           true,
+	"dbus-daemon":    true,
+	"gnome-shell":    true,
+	"init":           true,
+	"login":          true,
+	"plasmashell":    true,
+	"resourceful":    true,
+	"sh":             true,
+	"sshd":           true,
+	"su":             true,
+	"sudo":           true,
+	"systemd":        true,
+	"systemd-logind": true,
+	"Xorg":           true,
+	"Xwayland":       true,
+	"zsh":            true,
+}
+
+// Blocklisted returns true if p is a blocklisted process that must not
+// be managed.
+func Blocklisted(p ProcessData) bool {
+	if p.KernelThread() {
+		return true
+	}
+
+	// Never manage init or ourselves
+	if p.ID == 1 || int(p.ID) == os.Getpid() {
+		return true
+	}
+
+	return blocklist[p.Name]
+}
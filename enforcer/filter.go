@@ -0,0 +1,140 @@
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+)
+
+// makeCriteriaFilter returns a property filter that matches lease properties
+// that satisfy all of the given criteria.
+//
+// If no valid criteria are present a nil filter will be returned.
+func makeCriteriaFilter(criteria policy.Criteria) (filter propertyFilter, err error) {
+	var filters []propertyFilter
+	for _, c := range criteria {
+		f, err := makeCriterionFilter(c)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	return func(props lease.Properties) bool {
+		for _, filter := range filters {
+			if !filter(props) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// makeAnyFilter returns a property filter that matches lease properties
+// that satisfy any of the given criteria.
+//
+// If no valid criteria are present a nil filter will be returned.
+func makeAnyFilter(criteria policy.Criteria) (filter propertyFilter, err error) {
+	var filters []propertyFilter
+	for _, c := range criteria {
+		f, err := makeCriterionFilter(c)
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			filters = append(filters, f)
+		}
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	return func(props lease.Properties) bool {
+		for _, filter := range filters {
+			if filter(props) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func makeCriterionFilter(c policy.Criterion) (filter propertyFilter, err error) {
+	if group := c.Group(); group != "" {
+		return makeGroupFilter(group, c.Children())
+	}
+
+	matcher, err := makeMatcher(c.Comparison, c.Value, c.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	return makePropertyFilter(c.Key, matcher), nil
+}
+
+func makeGroupFilter(group string, children policy.Criteria) (filter propertyFilter, err error) {
+	switch group {
+	case policy.GroupAll:
+		filter, err = makeCriteriaFilter(children)
+	case policy.GroupAny:
+		filter, err = makeAnyFilter(children)
+	case policy.GroupNot:
+		filter, err = makeCriteriaFilter(children)
+		if filter != nil {
+			inner := filter
+			filter = func(props lease.Properties) bool {
+				return !inner(props)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("policy criteria contains unrecognized group type: %s", group)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("policy criteria contains an empty \"%s\" group", group)
+	}
+	return filter, nil
+}
+
+type propertyFilter func(lease.Properties) bool
+
+func makePropertyFilter(key string, matcher policy.Matcher) propertyFilter {
+	return func(p lease.Properties) bool {
+		value, present := p[key]
+		return matcher(value, present)
+	}
+}
+
+// makeMatcher returns a matcher for the given comparison.
+//
+// Regular expressions are always matched without regard to case. All other
+// comparisons are matched by the policy package.
+func makeMatcher(comparison, value string, values []string) (m policy.Matcher, err error) {
+	if comparison == policy.ComparisonRegex {
+		re, err := compileRegex(value)
+		if err != nil {
+			return nil, err
+		}
+		if re == nil {
+			return nil, errors.New("empty regular expression")
+		}
+		return func(fieldValue string, present bool) bool {
+			return re.MatchString(fieldValue)
+		}, nil
+	}
+
+	m, err = policy.NewMatcher(comparison, value, values)
+	if err != nil {
+		return nil, fmt.Errorf("policy criteria contains an invalid comparison: %v", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,27 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+)
+
+// ProcessFilter reports whether a process should be included in a scan.
+type ProcessFilter func(ProcessData) bool
+
+// Filter returns a process filter that matches processes that match
+// the given criteria.
+//
+// If no valid criteria are present a nil filter will be returned.
+func Filter(criteria policy.Criteria, environment lease.Properties) (filter ProcessFilter, err error) {
+	match, err := makeCriteriaFilter(criteria)
+	if err != nil || match == nil {
+		return nil, err
+	}
+
+	return func(p ProcessData) bool {
+		return match(Properties(p, environment))
+	}, nil
+}
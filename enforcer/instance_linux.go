@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"bufio"
+	"encoding/base64"
+
+	"github.com/scjalliance/resourceful/lease"
+	"golang.org/x/crypto/sha3"
+)
+
+// Instance returns the lease instance for p.
+func Instance(host string, p ProcessData, id string) lease.Instance {
+	return lease.Instance{
+		Host: host,
+		User: p.User.String(),
+		ID:   id,
+	}
+}
+
+// NewInstanceID generates an instance identifier for p.
+//
+// The return value is not guaranteed to be deterministic.
+func NewInstanceID(p ProcessData) string {
+	var (
+		hash = sha3.New224()
+		w    = hashWriter{bufio.NewWriterSize(hash, hash.BlockSize())}
+		uid  = p.UniqueID()
+	)
+
+	w.WriteInt(int(uid.ID))
+	w.WriteInt(int(uid.Start))
+	w.WriteString(p.User.ID)
+	w.WriteString(p.Name)
+
+	if err := w.Flush(); err != nil {
+		panic(err)
+	}
+
+	var h [28]byte
+	hash.Sum(h[:0])
+
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
@@ -0,0 +1,296 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// terminationGrace is how long a process is given to exit after it has been
+// sent SIGTERM before it is killed.
+var terminationGrace = 10 * time.Second
+
+// Invocation represents the intent of a user to launch and operate a program
+// that requires a lease according to the current policy set.
+//
+// On linux an invocation manages a single process for its lifetime. Processes
+// that are terminated for lack of a lease are not respawned. Processes that
+// ignore SIGTERM are sent SIGKILL once the termination grace period ends.
+type Invocation struct {
+	instance lease.Instance
+	name     string
+	logger   Logger
+
+	mutex   sync.Mutex
+	stop    context.CancelFunc
+	stopped <-chan struct{}
+	pols    policy.Set
+
+	stateMutex sync.Mutex
+	state      lease.State
+	props      lease.Properties          // Most recent lease properties of the process
+	maintainer *guardian.LeaseMaintainer // Set once the lease maintainer has been created
+}
+
+// NewInvocation returns a new invocation for the given process data.
+func NewInvocation(client *guardian.Client, environment lease.Properties, instance lease.Instance, process *Process, logger Logger) *Invocation {
+	data := process.Data()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+
+	inv := &Invocation{
+		instance: instance,
+		name:     data.Name,
+		logger:   logger,
+		stop:     cancel,
+		stopped:  stopped,
+	}
+
+	go inv.manage(ctx, client, environment, process, stopped)
+
+	return inv
+}
+
+// Stop causes inv to stop managing its process without killing it.
+func (inv *Invocation) Stop() {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	if inv.stop != nil {
+		// Tell the management goroutine to stop and then wait for it
+		inv.stop()
+		<-inv.stopped
+		inv.stop = nil
+		inv.stopped = nil
+	}
+}
+
+// Done returns true if the invocation has ceased management.
+func (inv *Invocation) Done() bool {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+
+	return inv.done()
+}
+
+func (inv *Invocation) done() bool {
+	if inv.stopped == nil {
+		return true
+	}
+
+	select {
+	case <-inv.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// UpdatePolicies updates the set of policies used by the invocation.
+func (inv *Invocation) UpdatePolicies(pols policy.Set) {
+	inv.mutex.Lock()
+	defer inv.mutex.Unlock()
+	inv.pols = pols
+}
+
+// UpdateProperties updates the lease properties of the invocation's process.
+// If they have changed since the last update the invocation's lease is
+// updated to match them, which might cause it to be transmuted into a lease
+// for a different resource.
+func (inv *Invocation) UpdateProperties(props lease.Properties) {
+	inv.stateMutex.Lock()
+	if inv.props.Equal(props) {
+		inv.stateMutex.Unlock()
+		return
+	}
+	inv.props = props
+	maintainer := inv.maintainer
+	inv.stateMutex.Unlock()
+
+	// The maintainer might be busy broadcasting a state to us, so we don't
+	// hold the state lock while we call it
+	if maintainer != nil {
+		inv.log("Updating lease properties")
+		maintainer.Update(props)
+	}
+}
+
+func (inv *Invocation) manage(ctx context.Context, client *guardian.Client, environment lease.Properties, process *Process, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	// Create a lease maintainer and ask it to acquire a lease
+	retry := time.Second * 5
+	inv.stateMutex.Lock()
+	inv.props = Properties(process.Data(), environment)
+	maintainer := guardian.NewLeaseMaintainer(client, inv.instance, inv.props, retry)
+	inv.maintainer = maintainer
+	inv.stateMutex.Unlock()
+	maintainer.Acquire()
+	states := maintainer.Listen(1)
+
+	// Release the lease when we exit, which could be almost immediate if we
+	// fail to acquire a lease.
+	defer func() {
+		go func() {
+			defer maintainer.Close()
+			maintainer.Release()
+		}()
+
+		for range states {
+			// Drain the states
+		}
+	}()
+
+	inv.log("Managing process %d", process.Data().ID)
+
+	inv.maintain(ctx, states, process)
+}
+
+func (inv *Invocation) maintain(ctx context.Context, states <-chan lease.State, process *Process) {
+	var (
+		termSent time.Time        // When SIGTERM was sent, if it has been
+		kill     <-chan time.Time // Fires when the termination grace period ends
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			// The process manager is telling us to stop managing the process.
+			// This could be because the process exited or because the
+			// process manager is shutting down.
+			if process.Running() {
+				inv.log("Ceasing management")
+			} else {
+				inv.log("Exited")
+			}
+			return
+		case <-kill:
+			kill = nil
+			if !process.Running() {
+				break
+			}
+			id := process.Data().ID
+			inv.log("Process %d did not exit within %s of termination, killing it", id, time.Since(termSent).Round(time.Second))
+			if err := process.Kill(); err != nil {
+				inv.log("Killing process %d failed: %v", id, err)
+			} else {
+				inv.log("Killed process %d", id)
+			}
+		case state, ok := <-states:
+			if !ok {
+				inv.log("Lease maintainer closed")
+				return
+			}
+
+			now := time.Now()
+			inv.recordState(state, now)
+
+			var terminate bool
+
+			switch {
+			case state.Acquired:
+				if (state.Lease.Status != lease.Active && state.Lease.Status != lease.Borrowed) || state.Lease.Expired(now) {
+					terminate = true
+				}
+			case state.LeaseNotRequired:
+				return
+			case !state.Online:
+				terminate = true
+			}
+
+			if terminate {
+				if !termSent.IsZero() {
+					break // Already sent a termination signal
+				}
+				id := process.Data().ID
+				inv.log("Terminating process %d", id)
+				if err := process.Terminate(); err == errPassive {
+					// Note the termination so that it's only reported once
+					termSent = now
+					inv.log("Process %d would be terminated but the enforcer is passive", id)
+				} else if err != nil {
+					inv.log("Termination of process %d failed: %v", id, err)
+				} else {
+					termSent = now
+					kill = time.After(terminationGrace)
+					inv.log("Terminated process %d", id)
+				}
+			}
+		}
+	}
+}
+
+func (inv *Invocation) recordState(state lease.State, now time.Time) {
+	inv.stateMutex.Lock()
+	old := inv.state
+	inv.state = state
+	inv.stateMutex.Unlock()
+
+	if state.Online != old.Online {
+		if state.Online {
+			inv.log("Online")
+		} else {
+			inv.log("Offline")
+		}
+	}
+
+	switch {
+	case state.Acquired:
+		if state.Lease.Expired(now) {
+			inv.log("Lease Expired")
+			return
+		}
+	case state.LeaseNotRequired:
+		inv.log("Lease Not Required")
+		return
+	case !state.Online:
+		inv.log("Lease Acquisition Failed")
+		return
+	}
+
+	tcase := cases.Title(language.AmericanEnglish)
+
+	remaining := state.Lease.ExpirationTime().Sub(now)
+	diff := state.Lease.Duration - remaining
+	if diff < time.Second {
+		inv.log("%s (%s, %s)", tcase.String(string(state.Lease.Status)), state.Lease.Resource, state.Lease.Duration)
+	} else {
+		inv.log("%s (%s, %s / %s)", tcase.String(string(state.Lease.Status)), state.Lease.Resource, remaining.Round(time.Second), state.Lease.Duration)
+	}
+
+	if state.Message != "" {
+		inv.log("%s", state.Message)
+	}
+}
+
+func (inv *Invocation) log(format string, v ...interface{}) {
+	if inv.logger == nil {
+		return
+	}
+	inv.logger.Log(InvocationEvent{
+		Instance:    inv.instance,
+		ProcessName: inv.name,
+		Msg:         fmt.Sprintf(format, v...),
+	})
+}
+
+func (inv *Invocation) debug(format string, v ...interface{}) {
+	if inv.logger == nil {
+		return
+	}
+	inv.logger.Log(InvocationEvent{
+		Instance:    inv.instance,
+		ProcessName: inv.name,
+		Msg:         fmt.Sprintf(format, v...),
+		Debug:       true,
+	})
+}
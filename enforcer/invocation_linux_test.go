@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// TestIgnoreTermHelper is not a real test. It is run as a child process by
+// TestMaintainKillsProcessIgnoringTerm and waits to be killed.
+func TestIgnoreTermHelper(t *testing.T) {
+	if os.Getenv("RESOURCEFUL_IGNORE_TERM_HELPER") != "1" {
+		t.Skip("helper process")
+	}
+	signal.Ignore(syscall.SIGTERM)
+	os.Stdout.WriteString("ready\n")
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func TestMaintainKillsProcessIgnoringTerm(t *testing.T) {
+	grace := terminationGrace
+	terminationGrace = 100 * time.Millisecond
+	defer func() { terminationGrace = grace }()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestIgnoreTermHelper$")
+	cmd.Env = append(os.Environ(), "RESOURCEFUL_IGNORE_TERM_HELPER=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	// Wait for the child to ignore SIGTERM before it is sent
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("helper process did not start: %v", err)
+	}
+
+	data, err := readProcess(PID(cmd.Process.Pid), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	process, err := NewProcess(data, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	states := make(chan lease.State, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		inv := &Invocation{}
+		inv.maintain(ctx, states, process)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Losing contact with the guardian causes the process to be terminated
+	states <- lease.State{Online: false}
+
+	select {
+	case err := <-exited:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("helper process exited without being signaled: %v", err)
+		}
+		status := exitErr.Sys().(syscall.WaitStatus)
+		if !status.Signaled() || status.Signal() != syscall.SIGKILL {
+			t.Fatalf("helper process exited with %v, want it to be killed by %v", err, syscall.SIGKILL)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process that ignores SIGTERM was not killed")
+	}
+}
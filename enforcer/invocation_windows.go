@@ -45,6 +45,8 @@ type Invocation struct {
 
 	stateMutex sync.Mutex
 	state      lease.State
+	props      lease.Properties          // Most recent lease properties of the process
+	maintainer *guardian.LeaseMaintainer // Set once the lease maintainer has been created
 }
 
 // NewInvocation returns a new invocation for the given process data.
@@ -146,6 +148,28 @@ func (inv *Invocation) UpdatePolicies(pols policy.Set) {
 	inv.pols = pols
 }
 
+// UpdateProperties updates the lease properties of the invocation's process.
+// If they have changed since the last update the invocation's lease is
+// updated to match them, which might cause it to be transmuted into a lease
+// for a different resource.
+func (inv *Invocation) UpdateProperties(props lease.Properties) {
+	inv.stateMutex.Lock()
+	if inv.props.Equal(props) {
+		inv.stateMutex.Unlock()
+		return
+	}
+	inv.props = props
+	maintainer := inv.maintainer
+	inv.stateMutex.Unlock()
+
+	// The maintainer might be busy broadcasting a state to us, so we don't
+	// hold the state lock while we call it
+	if maintainer != nil {
+		inv.log("Updating lease properties")
+		maintainer.Update(props)
+	}
+}
+
 func (inv *Invocation) manage(ctx context.Context, client *guardian.Client, environment lease.Properties, process *Process, absorption <-chan absorptionRequest, stopped chan<- struct{}) {
 	// Things we need to handle here:
 	// * We're instructed by the service to stop managing the process
@@ -160,7 +184,11 @@ func (inv *Invocation) manage(ctx context.Context, client *guardian.Client, envi
 
 	// Create a lease maintainer and ask it to acquire a lease
 	retry := time.Second * 5
-	maintainer := guardian.NewLeaseMaintainer(client, inv.instance, Properties(process.Data(), environment), retry)
+	inv.stateMutex.Lock()
+	inv.props = Properties(process.Data(), environment)
+	maintainer := guardian.NewLeaseMaintainer(client, inv.instance, inv.props, retry)
+	inv.maintainer = maintainer
+	inv.stateMutex.Unlock()
 	maintainer.Acquire()
 	states := maintainer.Listen(1)
 
@@ -255,7 +283,7 @@ func (inv *Invocation) maintain(ctx context.Context, absorption <-chan absorptio
 
 			switch {
 			case state.Acquired:
-				if state.Lease.Status != lease.Active || state.Lease.Expired(now) {
+				if (state.Lease.Status != lease.Active && state.Lease.Status != lease.Borrowed) || state.Lease.Expired(now) {
 					terminate = true
 				}
 			case state.LeaseNotRequired:
@@ -308,7 +336,7 @@ func (inv *Invocation) waitForAquisition(ctx context.Context, absorption <-chan
 			var start bool
 			switch {
 			case state.Acquired:
-				if !state.Lease.Expired(now) && state.Lease.Status == lease.Active {
+				if !state.Lease.Expired(now) && (state.Lease.Status == lease.Active || state.Lease.Status == lease.Borrowed) {
 					start = true
 				}
 			case state.LeaseNotRequired:
@@ -359,6 +387,10 @@ func (inv *Invocation) recordState(state lease.State, now time.Time) {
 		inv.log("%s (%s, %s / %s)", tcase.String(string(state.Lease.Status)), state.Lease.Resource, remaining.Round(time.Second), state.Lease.Duration)
 	}
 
+	if state.Message != "" {
+		inv.log("%s", state.Message)
+	}
+
 	/*
 		if !state.Online {
 			const warningInterval = 30 * time.Second
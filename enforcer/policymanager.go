@@ -1,6 +1,3 @@
-//go:build windows
-// +build windows
-
 package enforcer
 
 import (
@@ -19,7 +16,8 @@ type PolicyManager struct {
 	logger Logger
 
 	polMutex sync.RWMutex
-	policies policy.Set
+	received policy.Set // Policies as they were received
+	policies policy.Set // Policies that were compiled successfully
 
 	cacheMutex sync.Mutex
 	cache      policy.Cache
@@ -62,8 +60,9 @@ func (m *PolicyManager) Load() {
 	}
 
 	m.polMutex.Lock()
-	previous := m.policies
-	m.policies = updated
+	previous := m.received
+	m.received = updated
+	m.policies = m.compile(updated)
 	m.polMutex.Unlock()
 
 	switch d := len(updated); d {
@@ -100,8 +99,14 @@ func (m *PolicyManager) Update(ctx context.Context) (changed, ok bool) {
 	updated := response.Policies
 
 	m.polMutex.Lock()
-	previous := m.policies
-	m.policies = updated
+	previous := m.received
+	additions, deletions := previous.Diff(updated)
+	if len(additions) > 0 || len(deletions) > 0 {
+		// Only compile policies when they change, so that policies that
+		// can't be compiled are reported once
+		m.received = updated
+		m.policies = m.compile(updated)
+	}
 	m.polMutex.Unlock()
 
 	if m.cache != nil {
@@ -113,7 +118,6 @@ func (m *PolicyManager) Update(ctx context.Context) (changed, ok bool) {
 		}
 	}
 
-	additions, deletions := previous.Diff(updated)
 	if len(additions) == 0 && len(deletions) == 0 {
 		return false, true
 	}
@@ -135,6 +139,21 @@ func (m *PolicyManager) Update(ctx context.Context) (changed, ok bool) {
 	return true, true
 }
 
+// compile returns a copy of policies with each policy compiled in advance.
+// Policies that can't be compiled are logged and left out, as they can't be
+// matched reliably.
+func (m *PolicyManager) compile(policies policy.Set) (compiled policy.Set) {
+	for i := range policies {
+		pol, err := policies[i].Compile()
+		if err != nil {
+			m.log("POL: IGNORING %s: %v", policies[i].Hash().String(), err)
+			continue
+		}
+		compiled = append(compiled, pol)
+	}
+	return compiled
+}
+
 func (m *PolicyManager) log(format string, v ...interface{}) {
 	if m.logger == nil {
 		return
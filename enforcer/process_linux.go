@@ -0,0 +1,194 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// PID is a process ID.
+type PID uint32
+
+// String returns a string representation of the process ID.
+func (id PID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// UniqueID is a unique process ID. It combines the process ID with the
+// process start time so that recycled process IDs can be distinguished.
+type UniqueID struct {
+	ID    PID
+	Start uint64 // Clock ticks since boot
+}
+
+// String returns a string representation of the unique process ID.
+func (uid UniqueID) String() string {
+	return fmt.Sprintf("%d.%d", uid.ID, uid.Start)
+}
+
+// User identifies the owner of a process.
+//
+// Accounts provided by a directory service through NSS, such as those of
+// winbind, have names of the form DOMAIN\account. The domain of local
+// accounts is empty.
+type User struct {
+	ID      string // Numeric user ID
+	Domain  string // Domain of the account, if it has one
+	Account string // Account name, if it could be determined
+}
+
+// String returns the domain-qualified account name of the user, or the user
+// ID if the account name is unknown.
+func (u User) String() string {
+	switch {
+	case u.Account == "":
+		return u.ID
+	case u.Domain != "":
+		return u.Domain + `\` + u.Account
+	default:
+		return u.Account
+	}
+}
+
+// Times holds timing information for a process.
+type Times struct {
+	Creation time.Time
+}
+
+// ProcessData holds information about a linux process.
+type ProcessData struct {
+	ID          PID
+	ParentID    PID
+	Name        string
+	Path        string
+	CommandLine []string
+	User        User
+	Times       Times
+	kernel      bool   // Is this a kernel thread?
+	start       uint64 // Clock ticks since boot
+}
+
+// UniqueID returns a unique identifier for the process.
+func (data ProcessData) UniqueID() UniqueID {
+	return UniqueID{ID: data.ID, Start: data.start}
+}
+
+// KernelThread returns true if the process is a kernel thread.
+func (data ProcessData) KernelThread() bool {
+	return data.kernel
+}
+
+// String returns a string representation of the process.
+func (data ProcessData) String() string {
+	return fmt.Sprintf("%s: %s", data.ID, data.Name)
+}
+
+// errPassive is returned when termination is attempted in passive mode.
+var errPassive = errors.New("processes are not terminated in passive mode")
+
+// Process manages enforcement a process for which policies are being enforced.
+type Process struct {
+	data    ProcessData
+	passive bool
+	logger  Logger
+}
+
+// NewProcess returns a new process.
+func NewProcess(data ProcessData, passive bool, logger Logger) (*Process, error) {
+	p := &Process{
+		data:    data,
+		passive: passive,
+		logger:  logger,
+	}
+
+	if err := p.verify(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Data returns information about the process.
+func (p *Process) Data() ProcessData {
+	return p.data
+}
+
+// Running attempts to determine when the process is still running.
+//
+// It returns true if the processs was still running at the time the function
+// was called. If the process has exited, or if it is unable to make a
+// determination, it returns false.
+func (p *Process) Running() bool {
+	return p.verify() == nil
+}
+
+// Terminate asks the process to exit by sending it SIGTERM.
+func (p *Process) Terminate() error {
+	if p.passive {
+		return errPassive
+	}
+
+	if err := p.verify(); err != nil {
+		return nil
+	}
+
+	return syscall.Kill(int(p.data.ID), syscall.SIGTERM)
+}
+
+// Kill causes the process to exit immediately. It is used when a process
+// ignores a request to terminate.
+func (p *Process) Kill() error {
+	if p.passive {
+		return errPassive
+	}
+
+	if err := p.verify(); err != nil {
+		return nil
+	}
+
+	return syscall.Kill(int(p.data.ID), syscall.SIGKILL)
+}
+
+// verify confirms that the process is still running and that its process ID
+// has not been recycled.
+func (p *Process) verify() error {
+	stat, err := readStat(procDir(p.data.ID))
+	if err != nil {
+		return fmt.Errorf("unable to retrieve unique ID for process: %v", err)
+	}
+
+	if stat.start != p.data.start || stat.state == 'Z' {
+		// The process ID was recycled into a new process. Abort.
+		return fmt.Errorf("the process to be managed has terminated")
+	}
+
+	return nil
+}
+
+func (p *Process) log(format string, v ...interface{}) {
+	if p.logger == nil {
+		return
+	}
+	p.logger.Log(ProcessEvent{
+		ProcessID:   p.data.ID,
+		ProcessName: p.data.Name,
+		Msg:         fmt.Sprintf(format, v...),
+	})
+}
+
+func (p *Process) debug(format string, v ...interface{}) {
+	if p.logger == nil {
+		return
+	}
+	p.logger.Log(ProcessEvent{
+		ProcessID:   p.data.ID,
+		ProcessName: p.data.Name,
+		Msg:         fmt.Sprintf(format, v...),
+		Debug:       true,
+	})
+}
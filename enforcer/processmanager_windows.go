@@ -85,8 +85,13 @@ func (m *ProcessManager) Enforce(policies policy.Set) error {
 			delete(m.skipped, id)
 		}
 
-		// Don't re-manage processes that are already managed
-		if _, exists := m.managed[id]; exists {
+		// Don't re-manage processes that are already managed, but keep
+		// their leases in step with properties that have changed since
+		// management began
+		if instance, exists := m.managed[id]; exists {
+			if inv := m.invocations[instance]; inv != nil {
+				inv.UpdateProperties(Properties(proc, m.environment))
+			}
 			continue
 		}
 
@@ -0,0 +1,271 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// procRoot is the mount point of the proc filesystem.
+const procRoot = "/proc"
+
+// clockTicks is the number of clock ticks per second used by the kernel to
+// report process start times. It is fixed at 100 on all architectures that
+// are supported by Go.
+const clockTicks = 100
+
+var (
+	bootOnce sync.Once
+	bootTime time.Time
+	bootErr  error
+)
+
+// listPIDs returns the IDs of all processes present in the proc filesystem.
+func listPIDs() ([]PID, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %v", procRoot, err)
+	}
+
+	pids := make([]PID, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		id, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue // Not a process directory
+		}
+		pids = append(pids, PID(id))
+	}
+	return pids, nil
+}
+
+// readProcess collects information about the process with the given ID from
+// the proc filesystem.
+//
+// Users are looked up through the given user cache, which may be nil.
+func readProcess(id PID, users userCache) (data ProcessData, err error) {
+	dir := procDir(id)
+
+	stat, err := readStat(dir)
+	if err != nil {
+		return ProcessData{}, err
+	}
+
+	if stat.state == 'Z' {
+		return ProcessData{}, errors.New("the process has exited")
+	}
+
+	uid, err := readUID(dir)
+	if err != nil {
+		return ProcessData{}, err
+	}
+
+	creation, err := startTime(stat.start)
+	if err != nil {
+		return ProcessData{}, err
+	}
+
+	data = ProcessData{
+		ID:       id,
+		ParentID: stat.parent,
+		User:     users.Lookup(uid),
+		Times:    Times{Creation: creation},
+		kernel:   stat.flags&kernelThreadFlag != 0,
+		start:    stat.start,
+	}
+
+	// The command line is empty for kernel threads and zombies
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		cmdline = bytes.TrimRight(cmdline, "\x00")
+		if len(cmdline) > 0 {
+			data.CommandLine = strings.Split(string(cmdline), "\x00")
+		}
+	}
+
+	// The executable link can only be read for processes that we have
+	// permission to trace
+	if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		data.Path = strings.TrimSuffix(exe, " (deleted)")
+	} else if len(data.CommandLine) > 0 && filepath.IsAbs(data.CommandLine[0]) {
+		data.Path = data.CommandLine[0]
+	}
+
+	if data.Path != "" {
+		data.Name = filepath.Base(data.Path)
+	} else {
+		data.Name = stat.comm
+	}
+
+	return data, nil
+}
+
+// procDir returns the proc filesystem directory for the process with the
+// given ID.
+func procDir(id PID) string {
+	return filepath.Join(procRoot, id.String())
+}
+
+// kernelThreadFlag is the process flag that identifies kernel threads.
+const kernelThreadFlag = 0x00200000
+
+// procStat holds values parsed from a process stat file.
+type procStat struct {
+	comm   string // Command name, truncated by the kernel
+	state  byte   // Process state, such as 'R' or 'Z'
+	parent PID    // Parent process ID
+	flags  uint64 // Kernel flags
+	start  uint64 // Clock ticks since boot
+}
+
+// readStat parses the stat file in dir.
+func readStat(dir string) (stat procStat, err error) {
+	contents, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return procStat{}, err
+	}
+
+	// The command name is enclosed in parentheses and may itself contain
+	// spaces and parentheses, so we look for the last closing parenthesis.
+	data := string(contents)
+	open := strings.IndexByte(data, '(')
+	closing := strings.LastIndexByte(data, ')')
+	if open < 0 || closing < open {
+		return procStat{}, errors.New("malformed process stat data")
+	}
+	stat.comm = data[open+1 : closing]
+
+	// Fields following the command name, starting with the process state
+	fields := strings.Fields(data[closing+1:])
+	if len(fields) < 20 || len(fields[0]) != 1 {
+		return procStat{}, errors.New("truncated process stat data")
+	}
+	stat.state = fields[0][0]
+
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return procStat{}, fmt.Errorf("invalid parent process ID: %v", err)
+	}
+	stat.parent = PID(ppid)
+
+	stat.flags, err = strconv.ParseUint(fields[6], 10, 64)
+	if err != nil {
+		return procStat{}, fmt.Errorf("invalid process flags: %v", err)
+	}
+
+	stat.start, err = strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return procStat{}, fmt.Errorf("invalid process start time: %v", err)
+	}
+
+	return stat, nil
+}
+
+// readUID returns the real user ID of the process from the status file in
+// dir.
+func readUID(dir string) (uid string, err error) {
+	f, err := os.Open(filepath.Join(dir, "status"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Uid:"))
+		if len(fields) == 0 {
+			break
+		}
+		return fields[0], nil
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("process status does not include a user ID")
+}
+
+// startTime converts a process start time in clock ticks since boot into
+// wall clock time.
+func startTime(ticks uint64) (time.Time, error) {
+	bootOnce.Do(func() {
+		bootTime, bootErr = readBootTime()
+	})
+	if bootErr != nil {
+		return time.Time{}, bootErr
+	}
+	offset := time.Duration(ticks) * time.Second / clockTicks
+	return bootTime.Add(offset), nil
+}
+
+// readBootTime returns the time at which the system booted.
+func readBootTime() (time.Time, error) {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "btime ") {
+			continue
+		}
+		secs, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "btime ")), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid boot time: %v", err)
+		}
+		return time.Unix(secs, 0), nil
+	}
+	if err := s.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, errors.New("unable to determine boot time")
+}
+
+// userCache caches user account lookups for the duration of a scan.
+type userCache map[string]User
+
+// Lookup returns the user with the given user ID.
+func (c userCache) Lookup(uid string) User {
+	if u, ok := c[uid]; ok {
+		return u
+	}
+
+	u := User{ID: uid}
+	if account, err := user.LookupId(uid); err == nil {
+		u.Domain, u.Account = splitAccount(account.Username)
+	}
+
+	if c != nil {
+		c[uid] = u
+	}
+
+	return u
+}
+
+// splitAccount splits a user name of the form DOMAIN\account into its domain
+// and account name. Names without a domain are returned with an empty
+// domain.
+func splitAccount(name string) (domain, account string) {
+	if parts := strings.SplitN(name, `\`, 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return "", name
+}
@@ -0,0 +1,26 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// Properties returns the lease properties for p.
+//
+// The user.domain property is empty for processes owned by local accounts.
+func Properties(p ProcessData, environment lease.Properties) lease.Properties {
+	props := make(lease.Properties, len(environment)+7)
+	for k, v := range environment {
+		props[k] = v
+	}
+	props["program.name"] = p.Name
+	props["program.path"] = p.Path
+	props["process.id"] = p.ID.String()
+	props["process.creation"] = p.Times.Creation.String()
+	props["user.id"] = p.User.ID
+	props["user.account"] = p.User.Account
+	props["user.domain"] = p.User.Domain
+	return props
+}
@@ -0,0 +1,38 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import "testing"
+
+func TestPropertiesUserDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		domain   string
+		account  string
+		instance string
+	}{
+		{name: `CONTRACTORS\jdoe`, domain: "CONTRACTORS", account: "jdoe", instance: `CONTRACTORS\jdoe`},
+		{name: "jdoe", domain: "", account: "jdoe", instance: "jdoe"},
+	}
+
+	for _, test := range tests {
+		domain, account := splitAccount(test.name)
+		data := ProcessData{
+			ID:   1,
+			Name: "app",
+			User: User{ID: "1000", Domain: domain, Account: account},
+		}
+
+		props := Properties(data, nil)
+		if got := props["user.domain"]; got != test.domain {
+			t.Errorf("%s: user.domain: got %q, want %q", test.name, got, test.domain)
+		}
+		if got := props["user.account"]; got != test.account {
+			t.Errorf("%s: user.account: got %q, want %q", test.name, got, test.account)
+		}
+		if got := Instance("host", data, "id").User; got != test.instance {
+			t.Errorf("%s: instance user: got %q, want %q", test.name, got, test.instance)
+		}
+	}
+}
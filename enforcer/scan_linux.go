@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package enforcer
+
+import (
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+)
+
+// Scan returns the set of running processes for which one or more policies
+// might be applicable.
+func Scan(policies policy.Set, environment lease.Properties) ([]ProcessData, error) {
+	// Use resource criteria from the policies to build up a set of
+	// process filters
+	var filters []ProcessFilter
+	for _, pol := range policies {
+		filter, err := Filter(pol.Criteria, environment)
+		if err != nil {
+			// Skip policiies with criteria that we couldn't understand
+			continue
+		}
+		if filter != nil {
+			filters = append(filters, filter)
+		}
+	}
+
+	// Exit early if no policies with resource criteria are in effect
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	pids, err := listPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(userCache)
+
+	var procs []ProcessData
+	for _, pid := range pids {
+		data, err := readProcess(pid, users)
+		if err != nil {
+			// The process most likely exited while we were scanning
+			continue
+		}
+		for _, filter := range filters {
+			if filter(data) {
+				procs = append(procs, data)
+				break
+			}
+		}
+	}
+
+	return procs, nil
+}
@@ -0,0 +1,534 @@
+package guardian
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian/transport"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/lease/leaseutil"
+)
+
+// Revocation modes
+const (
+	revokeRelease = "release" // Revoked leases are released and left to decay
+	revokeDelete  = "delete"  // Revoked leases are deleted immediately
+)
+
+var (
+	// errLeaseNotFound is returned when a lease cannot be revoked or updated
+	// because it does not exist.
+	errLeaseNotFound = errors.New("lease not found")
+
+	// errHoldNotFound is returned when a hold cannot be removed because it
+	// does not exist.
+	errHoldNotFound = errors.New("hold not found")
+
+	// errHoldExists is returned when a hold cannot be created because its
+	// identifier is already in use.
+	errHoldExists = errors.New("a hold with the same id already exists")
+)
+
+// authorize returns the identity of the operator whose access token was
+// provided as a bearer token with r. If the request is not authorized an
+// error is written to w and ok is false.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) (operator string, ok bool) {
+	if len(s.Operators) == 0 {
+		http.Error(w, "Administration is not enabled on this guardian", http.StatusForbidden)
+		return "", false
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Administrative requests must be posted", http.StatusMethodNotAllowed)
+		return "", false
+	}
+
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found && token != "" {
+		for t, name := range s.Operators {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+				return name, true
+			}
+		}
+	}
+
+	printf(s.Logger, "Unauthorized administrative request from %s\n", r.RemoteAddr)
+	w.Header().Set("WWW-Authenticate", `Bearer realm="resourceful"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return "", false
+}
+
+// revokeHandler will forcibly release or delete a particular lease on behalf
+// of an administrator.
+func (s *Server) revokeHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	req, err := parseRequest(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Resource == "" || req.HostUser() == "" {
+		http.Error(w, "resource and consumer must be specified", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := parseRevocationMode(r.Form.Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rev := revocation{
+		Operator: operator,
+		Reason:   r.Form.Get("reason"),
+		At:       time.Now(),
+	}
+
+	revoked, err := s.revoke(req.Resource, mode, rev, func(ls lease.Lease) bool {
+		return ls.Instance == req.Instance
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(revoked) == 0 {
+		http.Error(w, errLeaseNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	s.writeAdminResponse(w, transport.AdminResponse{
+		Operator: operator,
+		Leases:   revoked,
+		Success:  true,
+	})
+}
+
+// purgeHandler will forcibly release or delete every lease held by a host
+// or user on behalf of an administrator. If a resource is specified only
+// the leases for that resource are purged.
+func (s *Server) purgeHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	host, user := r.Form.Get("host"), r.Form.Get("user")
+	if host == "" && user == "" {
+		http.Error(w, "host or user must be specified", http.StatusBadRequest)
+		return
+	}
+
+	mode, err := parseRevocationMode(r.Form.Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resources []string
+	if resource := r.Form.Get("resource"); resource != "" {
+		resources = []string{resource}
+	} else {
+		resources, err = s.collectResources()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	rev := revocation{
+		Operator: operator,
+		Reason:   r.Form.Get("reason"),
+		At:       time.Now(),
+	}
+
+	match := func(ls lease.Lease) bool {
+		return (host == "" || ls.Instance.Host == host) && (user == "" || ls.Instance.User == user)
+	}
+
+	response := transport.AdminResponse{
+		Operator: operator,
+		Leases:   lease.Set{},
+		Success:  true,
+	}
+	for _, resource := range resources {
+		revoked, err := s.revoke(resource, mode, rev, match)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Leases = append(response.Leases, revoked...)
+	}
+	if len(response.Leases) == 0 {
+		response.Message = "No leases matched."
+	}
+
+	s.writeAdminResponse(w, response)
+}
+
+// holdHandler will create a hold on units of a resource on behalf of an
+// administrator.
+func (s *Server) holdHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	resource := r.Form.Get("resource")
+	if resource == "" {
+		http.Error(w, "resource must be specified", http.StatusBadRequest)
+		return
+	}
+
+	var units uint = 1
+	if value := r.Form.Get("units"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || n == 0 {
+			http.Error(w, fmt.Sprintf("invalid units \"%s\"", value), http.StatusBadRequest)
+			return
+		}
+		units = uint(n)
+	}
+
+	var duration time.Duration
+	if value := r.Form.Get("duration"); value != "" {
+		var err error
+		if duration, err = time.ParseDuration(value); err != nil || duration < 0 {
+			http.Error(w, fmt.Sprintf("invalid duration \"%s\"", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hold := lease.Hold{
+		ID:       r.Form.Get("id"),
+		Operator: operator,
+		Created:  time.Now(),
+		Note:     r.Form.Get("note"),
+	}
+	if hold.ID == "" {
+		var err error
+		if hold.ID, err = newIdentifier(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ls, err := s.hold(resource, units, duration, hold)
+	switch err {
+	case nil:
+	case errHoldExists:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeAdminResponse(w, transport.AdminResponse{
+		Operator: operator,
+		Leases:   lease.Set{ls},
+		Success:  true,
+	})
+}
+
+// unholdHandler will remove a hold on behalf of an administrator.
+func (s *Server) unholdHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	resource, id := r.Form.Get("resource"), r.Form.Get("id")
+	if resource == "" || id == "" {
+		http.Error(w, "resource and id must be specified", http.StatusBadRequest)
+		return
+	}
+
+	ls, err := s.unhold(operator, resource, id)
+	switch err {
+	case nil:
+	case errHoldNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeAdminResponse(w, transport.AdminResponse{
+		Operator: operator,
+		Leases:   lease.Set{ls},
+		Success:  true,
+	})
+}
+
+// revoke forcibly releases or deletes the leases for resource that match,
+// on behalf of the operator responsible for rev. The holders of the revoked
+// leases are told of the revocation when they next attempt to renew them.
+//
+// Holds and booking placeholders are never revoked.
+func (s *Server) revoke(resource, mode string, rev revocation, match func(lease.Lease) bool) (revoked lease.Set, err error) {
+	var snapshot lease.Snapshot
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Revocation failed: %v\n", resource, err)
+			continue
+		}
+
+		now := time.Now()
+		tx := lease.NewTx(resource, revision, leases)
+		leaseutil.Refresh(tx, now) // Update stale values
+
+		// Only the revocations are attributed to the operator
+		tx.SetOperator(rev.Operator)
+		revoked = nil
+		tx.Process(func(iter *lease.Iter) {
+			if iter.Status == lease.Held || iter.Status == lease.Booked || !match(iter.Lease) {
+				return
+			}
+			revoked = append(revoked, iter.Lease)
+			switch {
+			case mode == revokeDelete, iter.Status == lease.Queued:
+				iter.Delete()
+			case iter.Status == lease.Active, iter.Status == lease.Borrowed:
+				iter.Status = lease.Released
+				iter.Released = now
+				iter.Update()
+			}
+		})
+
+		tx.SetOperator("")
+
+		if len(revoked) == 0 {
+			return revoked, nil
+		}
+
+		leaseutil.Refresh(tx, now) // Units may now be available to queued leases
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(resource, snapshot.Leases, now)
+
+		// Attempt to commit the transaction
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			s.sessions.Record(tx, now)
+			break
+		}
+
+		printf(s.Logger, "%s: Revocation failed: %v\n", resource, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	action := "Release"
+	if mode == revokeDelete {
+		action = "Deletion"
+	}
+
+	reason := rev.Reason
+	if reason == "" {
+		reason = "no reason given"
+	}
+
+	summary := statsSummary(revoked[0].Limit, snapshot.Stats, revoked[0].Strategy)
+	instances := make([]lease.Instance, 0, len(revoked))
+	for _, ls := range revoked {
+		s.revocations.Add(ls.Instance, rev)
+		instances = append(instances, ls.Instance)
+		printf(s.Logger, "%s: %s of %s lease forced by %s (%s) (%s)\n", ls.Subject, action, ls.Status, rev.Operator, reason, summary)
+	}
+
+	s.publishLeaseUpdate(snapshot, summary)
+	s.publishRevocation(transport.RevocationEvent{
+		Operator:  rev.Operator,
+		Instances: instances,
+		Message:   rev.Message(),
+	})
+
+	return revoked, nil
+}
+
+// hold creates a held lease that holds units of resource until it is
+// removed or the given duration has passed. If duration is zero the hold
+// lasts until it is removed.
+//
+// Holds are created even if the units of the resource are in use. Leases
+// that are already active are unaffected, but no further leases are
+// activated while the resource is over-allocated.
+func (s *Server) hold(resource string, units uint, duration time.Duration, hold lease.Hold) (ls lease.Lease, err error) {
+	prefix := fmt.Sprintf("%s: hold %s", resource, hold.ID)
+
+	policies, err := s.PolicyProvider.Policies()
+	if err != nil {
+		return ls, fmt.Errorf("unable to retrieve policies: %v", err)
+	}
+	policies = policies.MatchResource(resource)
+	if len(policies) == 0 {
+		return ls, fmt.Errorf("no policies apply to resource \"%s\"", resource)
+	}
+
+	ls = lease.NewHold(resource, units, duration, hold)
+	ls.Strategy = policies.Strategy()
+	ls.Limit = policies.Limit()
+
+	var snapshot lease.Snapshot
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Hold failed: %v\n", prefix, err)
+			continue
+		}
+
+		now := time.Now()
+		tx := lease.NewTx(resource, revision, leases)
+		leaseutil.Refresh(tx, now)
+
+		if _, exists := tx.Instance(ls.Instance); exists {
+			return ls, errHoldExists
+		}
+
+		tx.SetOperator(hold.Operator)
+		tx.Create(ls)
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(resource, snapshot.Leases, now)
+
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Hold failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return ls, err
+	}
+
+	summary := statsSummary(ls.Limit, snapshot.Stats, ls.Strategy)
+	printf(s.Logger, "%s: Hold of %d units by %s succeeded (%s)\n", prefix, units, hold.Operator, summary)
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return ls, nil
+}
+
+// unhold removes the hold on resource with the given identifier on behalf
+// of operator.
+func (s *Server) unhold(operator, resource, id string) (ls lease.Lease, err error) {
+	prefix := fmt.Sprintf("%s: hold %s", resource, id)
+	instance := lease.Instance{ID: lease.HoldPrefix + id}
+
+	var snapshot lease.Snapshot
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Removal failed: %v\n", prefix, err)
+			continue
+		}
+
+		now := time.Now()
+		tx := lease.NewTx(resource, revision, leases)
+		leaseutil.Refresh(tx, now)
+
+		var found bool
+		ls, found = tx.Instance(instance)
+		if !found || ls.Status != lease.Held {
+			return ls, errHoldNotFound
+		}
+
+		tx.SetOperator(operator)
+		tx.Delete(instance)
+		tx.SetOperator("")
+		leaseutil.Refresh(tx, now) // Units held may now be available
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(resource, snapshot.Leases, now)
+
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Removal failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return ls, err
+	}
+
+	summary := statsSummary(ls.Limit, snapshot.Stats, ls.Strategy)
+	printf(s.Logger, "%s: Removal by %s succeeded (%s)\n", prefix, operator, summary)
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return ls, nil
+}
+
+// writeAdminResponse writes an administrative response to w as JSON.
+func (s *Server) writeAdminResponse(w http.ResponseWriter, response transport.AdminResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		printf(s.Logger, "Failed to marshal administrative response: %v\n", err)
+		http.Error(w, "Failed to marshal response", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(data)
+}
+
+// parseRevocationMode returns the revocation mode specified by value, which
+// defaults to release.
+func parseRevocationMode(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", revokeRelease:
+		return revokeRelease, nil
+	case revokeDelete:
+		return revokeDelete, nil
+	default:
+		return "", fmt.Errorf("invalid revocation mode \"%s\"", value)
+	}
+}
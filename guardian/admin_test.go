@@ -0,0 +1,127 @@
+package guardian
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AndrewBurian/eventsource/v2"
+	"github.com/scjalliance/resourceful/guardian/transport"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/memprov"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestRevocationIsAnnounced(t *testing.T) {
+	s := NewServer(ServerConfig{LeaseProvider: memprov.New()})
+	policies := policy.Set{policy.New("cad", strategy.Instance, 1, time.Minute, nil)}
+	props := lease.Properties{"program.name": "cad.exe"}
+	subject := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+
+	if _, _, err := s.acquire(subject, props, policies); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	connected := make(chan struct{})
+	s.revoked.ClientConnectHook(func(*http.Request, *eventsource.Client) { close(connected) })
+	srv := httptest.NewServer(s.revoked)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	announced := make(chan transport.RevocationEvent, 1)
+	go Endpoint(srv.URL).Revocations(ctx, func(revocation transport.RevocationEvent) {
+		announced <- revocation
+	})
+
+	select {
+	case <-connected:
+	case <-ctx.Done():
+		t.Fatal("revocation listener did not connect")
+	}
+
+	rev := revocation{Operator: "alice", Reason: "Stuck session", At: time.Now()}
+	if _, err := s.revoke("cad", revokeRelease, rev, func(ls lease.Lease) bool { return ls.Instance == subject.Instance }); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	select {
+	case got := <-announced:
+		if got.Operator != rev.Operator {
+			t.Errorf("got operator %q, want %q", got.Operator, rev.Operator)
+		}
+		if len(got.Instances) != 1 || got.Instances[0] != subject.Instance {
+			t.Errorf("got instances %v, want %v", got.Instances, subject.Instance)
+		}
+		if got.Message != rev.Message() {
+			t.Errorf("got message %q, want %q", got.Message, rev.Message())
+		}
+	case <-ctx.Done():
+		t.Fatal("revocation was not announced")
+	}
+}
+
+func TestRevocationsShareOneStream(t *testing.T) {
+	s := NewServer(ServerConfig{LeaseProvider: memprov.New()})
+	policies := policy.Set{policy.New("cad", strategy.Instance, 2, time.Minute, nil)}
+	props := lease.Properties{"program.name": "cad.exe"}
+	revoked := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+	kept := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws2", User: "asmith", ID: "2"}}
+
+	for _, subject := range []lease.Subject{revoked, kept} {
+		if _, _, err := s.acquire(subject, props, policies); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+
+	connected := make(chan struct{}, 2)
+	s.revoked.ClientConnectHook(func(*http.Request, *eventsource.Client) { connected <- struct{}{} })
+	srv := httptest.NewServer(s.revoked)
+	defer srv.Close()
+
+	c := NewClient(nil)
+	c.endpoint = Endpoint(srv.URL)
+
+	revokedCh := make(chan struct{}, 1)
+	keptCh := make(chan struct{}, 1)
+	defer c.WatchRevocations(revoked.Instance, revokedCh)()
+	defer c.WatchRevocations(kept.Instance, keptCh)()
+
+	timeout := time.After(5 * time.Second)
+	select {
+	case <-connected:
+	case <-timeout:
+		t.Fatal("revocation stream did not connect")
+	}
+
+	rev := revocation{Operator: "alice", Reason: "Stuck session", At: time.Now()}
+	if _, err := s.revoke("cad", revokeRelease, rev, func(ls lease.Lease) bool { return ls.Instance == revoked.Instance }); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	select {
+	case <-revokedCh:
+	case <-timeout:
+		t.Fatal("revocation was not passed to its watcher")
+	}
+
+	// Wait for the dispatch to finish
+	c.watchMutex.Lock()
+	c.watchMutex.Unlock()
+
+	select {
+	case <-keptCh:
+		t.Error("revocation was passed to the watcher of another instance")
+	default:
+	}
+
+	select {
+	case <-connected:
+		t.Error("watchers opened more than one revocation stream")
+	default:
+	}
+}
@@ -0,0 +1,352 @@
+package guardian
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian/transport"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/lease/leaseutil"
+)
+
+// errBookingNotFound is returned when a booking cannot be cancelled because
+// it does not exist.
+var errBookingNotFound = errors.New("booking not found")
+
+// bookingsHandler will return the set of booking placeholder leases for a
+// particular resource, or for all resources if none is specified.
+func (s *Server) bookingsHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		resources []string
+		err       error
+	)
+	if resource := r.Form.Get("resource"); resource != "" {
+		resources = []string{resource}
+	} else {
+		resources, err = s.collectResources()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	response := transport.BookingsResponse{
+		Bookings: lease.Set{},
+	}
+	for _, resource := range resources {
+		_, leases, err := s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response.Bookings = append(response.Bookings, leases.Status(lease.Booked)...)
+	}
+
+	s.writeBookingResponse(w, response)
+}
+
+// bookHandler will attempt to book units of a resource for a window of time
+// on behalf of an administrator.
+func (s *Server) bookHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	resource, units, booking, err := parseBooking(r, time.Now())
+	if err != nil {
+		printf(s.Logger, "Bad booking request: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	printf(s.Logger, "%s: Booking of %d unit(s) requested by %s\n", resource, units, operator)
+
+	ls, err := s.book(resource, units, booking)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeBookingResponse(w, transport.BookingResponse{
+		Booking: ls,
+		Success: true,
+	})
+}
+
+// unbookHandler will attempt to cancel a booking on behalf of an
+// administrator.
+func (s *Server) unbookHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	resource, id := r.Form.Get("resource"), r.Form.Get("id")
+	if resource == "" || id == "" {
+		printf(s.Logger, "Bad booking cancellation request: resource and id not specified\n")
+		http.Error(w, "resource and id must be specified", http.StatusBadRequest)
+		return
+	}
+
+	printf(s.Logger, "%s: Cancellation of booking %s requested by %s\n", resource, id, operator)
+
+	ls, err := s.unbook(resource, id)
+	switch err {
+	case nil:
+	case errBookingNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeBookingResponse(w, transport.BookingResponse{
+		Booking: ls,
+		Success: true,
+	})
+}
+
+// book creates a placeholder lease that holds units of resource for the
+// booking.
+//
+// The booking is refused if the units booked for resource at any time
+// during the booking would exceed the limit of its policies.
+func (s *Server) book(resource string, units uint, booking lease.Booking) (ls lease.Lease, err error) {
+	prefix := fmt.Sprintf("%s: booking %s", resource, booking.ID)
+
+	policies, err := s.PolicyProvider.Policies()
+	if err != nil {
+		return ls, fmt.Errorf("unable to retrieve policies: %v", err)
+	}
+	policies = policies.MatchResource(resource)
+	if len(policies) == 0 {
+		return ls, fmt.Errorf("no policies apply to resource \"%s\"", resource)
+	}
+	limit := policies.Limit()
+
+	ls = lease.NewBooking(resource, units, booking)
+
+	var snapshot lease.Snapshot
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Booking failed: %v\n", prefix, err)
+			continue
+		}
+
+		now := time.Now()
+		tx := lease.NewTx(resource, revision, leases)
+		leaseutil.Refresh(tx, now)
+
+		if _, exists := tx.Instance(ls.Instance); exists {
+			return ls, fmt.Errorf("booking %s already exists", booking.ID)
+		}
+
+		if booked := tx.Leases().Booked(booking.Start, booking.End); booked+units > limit {
+			var available uint
+			if booked < limit {
+				available = limit - booked
+			}
+			return ls, fmt.Errorf("only %d of the %d units of %s can be booked between %s and %s", available, limit, resource, booking.Start.Format(time.RFC3339), booking.End.Format(time.RFC3339))
+		}
+
+		tx.Create(ls)
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(resource, snapshot.Leases, now)
+
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Booking failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return ls, err
+	}
+
+	summary := statsSummary(limit, snapshot.Stats, policies.Strategy())
+	printf(s.Logger, "%s: Booking of %d units from %s to %s succeeded (%s)\n", prefix, units, booking.Start.Format(time.RFC3339), booking.End.Format(time.RFC3339), summary)
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return ls, nil
+}
+
+// unbook removes the placeholder lease for the booking of resource with the
+// given identifier.
+func (s *Server) unbook(resource, id string) (ls lease.Lease, err error) {
+	prefix := fmt.Sprintf("%s: booking %s", resource, id)
+	instance := lease.Instance{ID: lease.BookingPrefix + id}
+
+	var snapshot lease.Snapshot
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Cancellation failed: %v\n", prefix, err)
+			continue
+		}
+
+		now := time.Now()
+		tx := lease.NewTx(resource, revision, leases)
+		leaseutil.Refresh(tx, now)
+
+		var found bool
+		ls, found = tx.Instance(instance)
+		if !found || ls.Status != lease.Booked {
+			return ls, errBookingNotFound
+		}
+
+		tx.Delete(instance)
+		leaseutil.Refresh(tx, now) // Units withheld by the booking may now be available
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(resource, snapshot.Leases, now)
+
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Cancellation failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return ls, err
+	}
+
+	printf(s.Logger, "%s: Cancellation succeeded\n", prefix)
+	s.publishLeaseUpdate(snapshot, resource)
+
+	return ls, nil
+}
+
+// writeBookingResponse writes a booking response to w as JSON.
+func (s *Server) writeBookingResponse(w http.ResponseWriter, response interface{}) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		printf(s.Logger, "Failed to marshal booking response: %v\n", err)
+		http.Error(w, "Failed to marshal response", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(data)
+}
+
+// parseBooking parses a booking request. Times must be provided in RFC 3339
+// format. The booking starts at the given time if no start is specified, and
+// may be given a duration instead of an end time.
+func parseBooking(r *http.Request, now time.Time) (resource string, units uint, booking lease.Booking, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	resource = r.Form.Get("resource")
+	if resource == "" {
+		err = errors.New("resource not specified")
+		return
+	}
+
+	units = 1
+	if value := r.Form.Get("units"); value != "" {
+		var n uint64
+		if n, err = strconv.ParseUint(value, 10, 32); err != nil || n == 0 {
+			err = fmt.Errorf("invalid units \"%s\"", value)
+			return
+		}
+		units = uint(n)
+	}
+
+	booking.ID = r.Form.Get("id")
+	if booking.ID == "" {
+		if booking.ID, err = newIdentifier(); err != nil {
+			return
+		}
+	}
+
+	booking.Start = now
+	if value := r.Form.Get("start"); value != "" {
+		if booking.Start, err = time.Parse(time.RFC3339, value); err != nil {
+			err = fmt.Errorf("invalid start time: %v", err)
+			return
+		}
+	}
+
+	switch value := r.Form.Get("end"); {
+	case value != "":
+		if booking.End, err = time.Parse(time.RFC3339, value); err != nil {
+			err = fmt.Errorf("invalid end time: %v", err)
+			return
+		}
+	case r.Form.Get("duration") != "":
+		var duration time.Duration
+		if duration, err = time.ParseDuration(r.Form.Get("duration")); err != nil {
+			err = fmt.Errorf("invalid duration: %v", err)
+			return
+		}
+		booking.End = booking.Start.Add(duration)
+	default:
+		err = errors.New("end time or duration not specified")
+		return
+	}
+
+	if !booking.End.After(booking.Start) {
+		err = errors.New("the booking must end after it starts")
+		return
+	}
+	if !booking.End.After(now) {
+		err = errors.New("the booking has already ended")
+		return
+	}
+
+	booking.Users = r.Form["user"]
+	booking.Hosts = r.Form["host"]
+	if len(booking.Users) == 0 && len(booking.Hosts) == 0 {
+		err = errors.New("at least one user or host must be specified")
+		return
+	}
+
+	booking.Note = r.Form.Get("note")
+
+	return
+}
+
+// newIdentifier returns a random identifier for a booking or hold.
+func newIdentifier() (string, error) {
+	var id [6]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("unable to generate identifier: %v", err)
+	}
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,301 @@
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian/transport"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/lease/leasetoken"
+	"github.com/scjalliance/resourceful/lease/leaseutil"
+	"github.com/scjalliance/resourceful/policy"
+)
+
+var (
+	// errUnavailable is returned when a lease cannot be borrowed because
+	// there are no units of the resource available.
+	errUnavailable = errors.New("no units of the resource are available to borrow")
+
+	// errNotBorrowed is returned when a lease cannot be returned because it
+	// has not been borrowed.
+	errNotBorrowed = errors.New("the lease has not been borrowed")
+)
+
+// borrowHandler will attempt to borrow a lease for the specified resource
+// for use while disconnected from the guardian.
+//
+// The lease is borrowed for the duration requested by the client, up to the
+// maximum permitted by its policies. The response includes a signed lease
+// token that the client can verify while offline, using the guardian's
+// public key that it has been given out of band.
+func (s *Server) borrowHandler(w http.ResponseWriter, r *http.Request) {
+	if s.BorrowKey == nil {
+		http.Error(w, "Lease borrowing is not enabled on this guardian", http.StatusForbidden)
+		return
+	}
+
+	req, policies, err := s.initRequest(r)
+	if err != nil {
+		printf(s.Logger, "Bad borrow request: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The requested duration is not a lease property
+	delete(req.Properties, "duration")
+
+	prefix := req.Subject.String()
+
+	resources := policies.Resources()
+	switch {
+	case len(resources) == 0:
+		http.Error(w, "No policies apply to the lease", http.StatusBadRequest)
+		return
+	case len(resources) > 1:
+		http.Error(w, "Leases that consume more than one resource cannot be borrowed", http.StatusBadRequest)
+		return
+	}
+	req.Resource = resources[0]
+
+	max := policies.MaxBorrow()
+	if max == 0 {
+		printf(s.Logger, "%s: Borrowing refused because the policies do not permit it\n", prefix)
+		http.Error(w, fmt.Sprintf("Leases for %s cannot be borrowed", req.Resource), http.StatusForbidden)
+		return
+	}
+
+	duration := max
+	if value := r.Form.Get("duration"); value != "" {
+		requested, err := time.ParseDuration(value)
+		if err != nil || requested <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid duration \"%s\"", value), http.StatusBadRequest)
+			return
+		}
+		if requested < max {
+			duration = requested
+		}
+	}
+
+	props := lease.MergeProperties(req.Properties, policies.Properties())
+
+	printf(s.Logger, "%s: Lease borrowing requested for %s\n", prefix, duration)
+
+	ls, err := s.borrow(req.Subject, props, policies, duration)
+	if err != nil {
+		status := http.StatusBadRequest
+		if err == errUnavailable {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	token, err := leasetoken.Sign(ls, s.BorrowKey)
+	if err != nil {
+		printf(s.Logger, "%s: Failed to sign lease token: %v\n", prefix, err)
+		http.Error(w, "Failed to sign lease token", http.StatusInternalServerError)
+		return
+	}
+
+	response := transport.BorrowResponse{
+		Request: req,
+		Lease:   ls,
+		Token:   token,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		printf(s.Logger, "%s: Failed to marshal response: %v\n", prefix, err)
+		http.Error(w, "Failed to marshal response", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(data)
+}
+
+// returnHandler will attempt to return a borrowed lease before it expires.
+func (s *Server) returnHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		err = fmt.Errorf("unable to parse request: %v", err)
+		printf(s.Logger, "Bad return request: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Resource == "" || req.HostUser() == "" {
+		printf(s.Logger, "Bad return request: resource and consumer not specified\n")
+		http.Error(w, "resource and consumer must be specified", http.StatusBadRequest)
+		return
+	}
+
+	prefix := req.Subject.String()
+
+	printf(s.Logger, "%s: Return requested\n", prefix)
+
+	switch err := s.giveBack(req.Subject); err {
+	case nil:
+	case errNotBorrowed:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := transport.ReleaseResponse{
+		Request: req,
+		Success: true,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		printf(s.Logger, "%s: Failed to marshal response: %v\n", prefix, err)
+		http.Error(w, "Failed to marshal response", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(data)
+}
+
+// borrow will attempt to borrow a lease for subject for the given duration.
+//
+// An active lease held by subject is converted into a borrowed lease. A
+// borrowed lease held by subject is extended. Otherwise a new borrowed lease
+// is issued if a unit of the resource is available immediately. Borrowed
+// leases are never queued.
+func (s *Server) borrow(subject lease.Subject, props lease.Properties, policies policy.Set, duration time.Duration) (ls lease.Lease, err error) {
+	prefix := subject.String()
+
+	strat := policies.Strategy()
+	limit := policies.Limit()
+
+	var snapshot lease.Snapshot
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+
+		revision, leases, err = s.LeaseProvider.LeaseView(subject.Resource)
+		if err != nil {
+			printf(s.Logger, "%s: Lease retrieval failed: %v\n", prefix, err)
+			continue
+		}
+		now := time.Now()
+
+		ls = s.newLease(subject, props, policies, now)
+		ls.Status = lease.Borrowed
+		ls.Duration = duration
+
+		tx := lease.NewTx(subject.Resource, revision, leases)
+		acc := leaseutil.Refresh(tx, now)
+
+		existing, found := tx.Instance(subject.Instance)
+		if found && (existing.Status == lease.Active || existing.Status == lease.Borrowed) {
+			ls.Started = existing.Started
+			tx.Update(existing.Instance, ls)
+		} else {
+			if !leaseutil.CanActivate(strat, acc.Active(ls.ConsumerKey()), acc.Total(strat), ls.Weight(), leaseutil.Limit(acc, ls)) {
+				printf(s.Logger, "%s: Borrowing refused because no units are available\n", prefix)
+				return ls, errUnavailable
+			}
+			if reason := leaseutil.SubLimit(acc, ls); reason != "" {
+				printf(s.Logger, "%s: Borrowing refused because %s\n", prefix, reason)
+				return ls, fmt.Errorf("the lease cannot be borrowed because %s", reason)
+			}
+			if found {
+				tx.Update(existing.Instance, ls)
+			} else {
+				tx.Create(ls)
+			}
+		}
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(subject.Resource, snapshot.Leases, now)
+
+		// Attempt to commit the transaction
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Lease borrowing failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	summary := statsSummary(limit, snapshot.Stats, strat)
+	printf(s.Logger, "%s: Borrowing of lease until %s succeeded (%s)\n", prefix, ls.ExpirationTime().Format(time.RFC3339), summary)
+
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return
+}
+
+// giveBack will release the lease borrowed by subject.
+func (s *Server) giveBack(subject lease.Subject) (err error) {
+	prefix := subject.String()
+
+	var snapshot lease.Snapshot
+	var ls lease.Lease
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(subject.Resource)
+		if err != nil {
+			printf(s.Logger, "%s: Return failed: %v\n", prefix, err)
+			continue
+		}
+
+		now := time.Now()
+		tx := lease.NewTx(subject.Resource, revision, leases)
+		leaseutil.Refresh(tx, now) // Update stale values
+
+		var found bool
+		ls, found = tx.Instance(subject.Instance)
+		if !found || ls.Status != lease.Borrowed {
+			printf(s.Logger, "%s: Return ignored because the lease is not borrowed\n", prefix)
+			return errNotBorrowed
+		}
+
+		tx.Release(subject.Instance, now)
+		leaseutil.Refresh(tx, now) // Updates leases after release
+
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(subject.Resource, snapshot.Leases, now)
+
+		// Attempt to commit the transaction
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Return failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	summary := statsSummary(ls.Limit, snapshot.Stats, ls.Strategy)
+	printf(s.Logger, "%s: Return of borrowed lease succeeded (%s)\n", prefix, summary)
+
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/memprov"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestAcquireSharesBorrowedLease(t *testing.T) {
+	s := NewServer(ServerConfig{LeaseProvider: memprov.New()})
+	policies := policy.Set{policy.New("cad", strategy.Consumer, 1, time.Minute, nil)}
+	props := lease.Properties{"program.name": "cad.exe"}
+
+	subject := func(id string) lease.Subject {
+		return lease.Subject{
+			Resource: "cad",
+			Instance: lease.Instance{Host: "laptop", User: "jdoe", ID: id},
+		}
+	}
+
+	borrowed, err := s.borrow(subject("1"), props, policies, time.Hour)
+	if err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+
+	// A second instance of the consumer shares the borrowed lease without
+	// taking it over
+	ls, _, err := s.acquire(subject("2"), props, policies)
+	if err != nil {
+		t.Fatalf("acquire by second instance: %v", err)
+	}
+	if ls.Instance != subject("2").Instance {
+		t.Errorf("second instance was given the lease of instance %s", ls.Instance.ID)
+	}
+	if ls.Status != lease.Borrowed {
+		t.Errorf("second instance: got status %s, want %s", ls.Status, lease.Borrowed)
+	}
+	if ls.ExpirationTime().After(borrowed.ExpirationTime()) {
+		t.Errorf("second instance: lease expires at %s, after the borrowed lease expires at %s", ls.ExpirationTime(), borrowed.ExpirationTime())
+	}
+
+	// The borrowing instance still receives its own lease
+	ls, _, err = s.acquire(subject("1"), props, policies)
+	if err != nil {
+		t.Fatalf("acquire by borrowing instance: %v", err)
+	}
+	if ls.Instance != subject("1").Instance || ls.Status != lease.Borrowed || !ls.ExpirationTime().Equal(borrowed.ExpirationTime()) {
+		t.Errorf("borrowing instance: got %s lease for instance %s expiring at %s, want the borrowed lease", ls.Status, ls.Instance.ID, ls.ExpirationTime())
+	}
+
+	// Only the borrowed lease is recorded
+	_, leases, err := s.LeaseProvider.LeaseView("cad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases) != 1 || leases[0].Instance != subject("1").Instance || leases[0].Status != lease.Borrowed {
+		t.Fatalf("got leases %v, want only the borrowed lease of instance 1", leases)
+	}
+}
+
+func TestAcquireCountsInstancesOfBorrower(t *testing.T) {
+	s := NewServer(ServerConfig{LeaseProvider: memprov.New()})
+	policies := policy.Set{policy.New("cad", strategy.Instance, 1, time.Minute, nil)}
+	props := lease.Properties{"program.name": "cad.exe"}
+
+	subject := func(id string) lease.Subject {
+		return lease.Subject{
+			Resource: "cad",
+			Instance: lease.Instance{Host: "laptop", User: "jdoe", ID: id},
+		}
+	}
+
+	if _, err := s.borrow(subject("1"), props, policies, time.Hour); err != nil {
+		t.Fatalf("borrow: %v", err)
+	}
+
+	// When instances are counted the borrowed lease isn't shared, so a
+	// second instance must wait for a unit of its own
+	ls, _, err := s.acquire(subject("2"), props, policies)
+	if err != nil {
+		t.Fatalf("acquire by second instance: %v", err)
+	}
+	if ls.Status != lease.Queued {
+		t.Errorf("second instance: got status %s, want %s", ls.Status, lease.Queued)
+	}
+
+	_, leases, err := s.LeaseProvider.LeaseView("cad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases) != 2 {
+		t.Fatalf("got %d leases, want the borrowed lease and the queued lease", len(leases))
+	}
+}
@@ -0,0 +1,43 @@
+package guardian
+
+import (
+	"net/http"
+
+	"github.com/scjalliance/resourceful/guardian/transport"
+)
+
+// Checkpointer is implemented by lease providers that can record a
+// checkpoint of their lease data on demand, such as transaction logging
+// providers.
+type Checkpointer interface {
+	Checkpoint() error
+}
+
+// checkpointHandler will record a checkpoint of the lease data on behalf of
+// an administrator.
+func (s *Server) checkpointHandler(w http.ResponseWriter, r *http.Request) {
+	operator, ok := s.authorize(w, r)
+	if !ok {
+		return
+	}
+
+	cp, ok := s.LeaseProvider.(Checkpointer)
+	if !ok {
+		http.Error(w, "The lease provider does not record checkpoints", http.StatusNotImplemented)
+		return
+	}
+
+	printf(s.Logger, "Checkpoint requested by %s\n", operator)
+
+	if err := cp.Checkpoint(); err != nil {
+		printf(s.Logger, "Checkpoint failed: %v\n", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.writeAdminResponse(w, transport.AdminResponse{
+		Operator: operator,
+		Success:  true,
+		Message:  "Checkpoint recorded.",
+	})
+}
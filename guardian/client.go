@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/scjalliance/resourceful/audit"
 	"github.com/scjalliance/resourceful/guardian/transport"
 	"github.com/scjalliance/resourceful/lease"
 )
@@ -26,6 +27,16 @@ type Client struct {
 	selection sync.Mutex
 	resolved  time.Time
 	selected  time.Time
+
+	watchMutex sync.Mutex
+	watchers   map[*revocationWatcher]struct{}
+	unwatch    context.CancelFunc // Closes the revocation stream
+}
+
+// revocationWatcher is a lease instance whose revocations are being watched.
+type revocationWatcher struct {
+	instance lease.Instance
+	revoked  chan<- struct{}
 }
 
 // NewClient creates a new guardian client that retrieves endpoints from
@@ -189,6 +200,373 @@ func (c *Client) Release(ctx context.Context, subject lease.Subject) (response t
 	return response, nil
 }
 
+// Update will attempt to update the properties of the lease held by
+// subject. If the properties call for a different resource the lease is
+// transmuted into a lease for that resource.
+func (c *Client) Update(ctx context.Context, subject lease.Subject, props lease.Properties) (response transport.UpdateResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Update(ctx, subject, props)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, false)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Update(ctx, subject, props)
+	}
+
+	return response, nil
+}
+
+// Bookings will retrieve the current set of bookings for resource, or for
+// all resources if resource is empty.
+func (c *Client) Bookings(ctx context.Context, resource string) (response transport.BookingsResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Bookings(ctx, resource)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, false)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Bookings(ctx, resource)
+	}
+
+	return response, nil
+}
+
+// History will retrieve the lease history records selected by filter.
+func (c *Client) History(ctx context.Context, filter audit.Filter) (response transport.HistoryResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.History(ctx, filter)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, false)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.History(ctx, filter)
+	}
+
+	return response, nil
+}
+
+// Book will attempt to book units of resource for a window of time on behalf
+// of the administrator identified by token.
+func (c *Client) Book(ctx context.Context, token, resource string, units uint, booking lease.Booking) (response transport.BookingResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Book(ctx, token, resource, units, booking)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Book(ctx, token, resource, units, booking)
+	}
+
+	return response, nil
+}
+
+// Unbook will attempt to cancel the booking of resource with the given
+// identifier on behalf of the administrator identified by token.
+func (c *Client) Unbook(ctx context.Context, token, resource, id string) (response transport.BookingResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Unbook(ctx, token, resource, id)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Unbook(ctx, token, resource, id)
+	}
+
+	return response, nil
+}
+
+// Borrow will attempt to borrow a lease for the given resource and consumer
+// for use while disconnected. If duration is zero the maximum duration
+// permitted by the guardian's policies is requested.
+func (c *Client) Borrow(ctx context.Context, subject lease.Subject, props lease.Properties, duration time.Duration) (response transport.BorrowResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Borrow(ctx, subject, props, duration)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Borrow(ctx, subject, props, duration)
+	}
+
+	return response, nil
+}
+
+// Return will attempt to return a borrowed lease for the given resource and
+// consumer.
+func (c *Client) Return(ctx context.Context, subject lease.Subject) (response transport.ReleaseResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Return(ctx, subject)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Return(ctx, subject)
+	}
+
+	return response, nil
+}
+
+// Revoke will forcibly release the lease held by subject on behalf of the
+// administrator identified by token. If remove is true the lease is deleted
+// instead of released.
+func (c *Client) Revoke(ctx context.Context, token string, subject lease.Subject, remove bool, reason string) (response transport.AdminResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Revoke(ctx, token, subject, remove, reason)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Revoke(ctx, token, subject, remove, reason)
+	}
+
+	return response, nil
+}
+
+// Purge will forcibly release every lease held by host or user on behalf
+// of the administrator identified by token. If resource is not empty only
+// the leases for resource are purged. If remove is true the leases are
+// deleted instead of released.
+func (c *Client) Purge(ctx context.Context, token, resource, host, user string, remove bool, reason string) (response transport.AdminResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Purge(ctx, token, resource, host, user, remove, reason)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Purge(ctx, token, resource, host, user, remove, reason)
+	}
+
+	return response, nil
+}
+
+// Hold will hold units of resource on behalf of the administrator
+// identified by token. If duration is zero the hold lasts until it is
+// removed.
+func (c *Client) Hold(ctx context.Context, token, resource string, units uint, duration time.Duration, id, note string) (response transport.AdminResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Hold(ctx, token, resource, units, duration, id, note)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Hold(ctx, token, resource, units, duration, id, note)
+	}
+
+	return response, nil
+}
+
+// Unhold will remove a hold on behalf of the administrator identified by
+// token.
+func (c *Client) Unhold(ctx context.Context, token, resource, id string) (response transport.AdminResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Unhold(ctx, token, resource, id)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Unhold(ctx, token, resource, id)
+	}
+
+	return response, nil
+}
+
+// Checkpoint will ask the guardian to record a checkpoint of its lease data
+// on behalf of the administrator identified by token.
+func (c *Client) Checkpoint(ctx context.Context, token string) (response transport.AdminResponse, err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	response, err = endpoint.Checkpoint(ctx, token)
+	if err != nil {
+		if isContextErr(err) {
+			return response, err
+		}
+		failover, err2 := c.failover(ctx, true)
+		if err2 != nil {
+			return response, err
+		}
+		return failover.Checkpoint(ctx, token)
+	}
+
+	return response, nil
+}
+
+// Revocations listens for the revocation of leases by administrators and
+// calls fn for each one. It blocks until ctx is cancelled or the stream is
+// closed by the guardian, in which case it returns nil.
+func (c *Client) Revocations(ctx context.Context, fn func(transport.RevocationEvent)) (err error) {
+	c.mutex.RLock()
+	endpoint := c.endpoint
+	c.mutex.RUnlock()
+
+	err = endpoint.Revocations(ctx, fn)
+	if err != nil {
+		if isContextErr(err) {
+			return err
+		}
+		failover, err2 := c.failover(ctx, false)
+		if err2 != nil {
+			return err
+		}
+		return failover.Revocations(ctx, fn)
+	}
+
+	return nil
+}
+
+// WatchRevocations signals revoked each time an administrator revokes the
+// lease of instance, until the returned cancel function is called. Signals
+// are dropped while revoked is not ready to receive them.
+//
+// A single revocation stream is opened for all of the client's watchers
+// while any remain.
+func (c *Client) WatchRevocations(instance lease.Instance, revoked chan<- struct{}) (cancel func()) {
+	w := &revocationWatcher{instance: instance, revoked: revoked}
+
+	c.watchMutex.Lock()
+	defer c.watchMutex.Unlock()
+
+	if c.watchers == nil {
+		c.watchers = make(map[*revocationWatcher]struct{})
+	}
+	c.watchers[w] = struct{}{}
+
+	if c.unwatch == nil {
+		var ctx context.Context
+		ctx, c.unwatch = context.WithCancel(context.Background())
+		go c.watchRevocations(ctx)
+	}
+
+	return func() {
+		c.watchMutex.Lock()
+		defer c.watchMutex.Unlock()
+
+		if _, ok := c.watchers[w]; !ok {
+			return
+		}
+		delete(c.watchers, w)
+
+		if len(c.watchers) == 0 {
+			c.unwatch()
+			c.unwatch = nil
+		}
+	}
+}
+
+// watchRevocations listens for revocations until ctx is cancelled and passes
+// each one to the watchers of the instances it revoked. If the guardian's
+// event stream is interrupted it is reopened after a delay.
+func (c *Client) watchRevocations(ctx context.Context) {
+	for {
+		c.Revocations(ctx, c.dispatchRevocation)
+
+		t := time.NewTimer(lease.MinimumRefresh)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// dispatchRevocation signals the watchers of each instance in revocation.
+func (c *Client) dispatchRevocation(revocation transport.RevocationEvent) {
+	c.watchMutex.Lock()
+	defer c.watchMutex.Unlock()
+
+	for _, instance := range revocation.Instances {
+		for w := range c.watchers {
+			if w.instance != instance {
+				continue
+			}
+			select {
+			case w.revoked <- struct{}{}:
+			default:
+				// A revocation is already pending
+			}
+		}
+	}
+}
+
 func isContextErr(err error) bool {
 	switch err {
 	case context.DeadlineExceeded, context.Canceled:
@@ -0,0 +1,423 @@
+package guardian
+
+import (
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/lease/leaseutil"
+	"github.com/scjalliance/resourceful/policy"
+)
+
+// acquireAll will attempt to acquire a composite lease for subject that
+// consumes each of the given resources.
+//
+// A lease is acquired for each resource and all of them are committed
+// atomically. The component leases are only activated when every one of them
+// can be activated, and are all returned to the queue when any of them
+// can't be. The returned lease is the component lease for the first
+// resource, with a status that is only active when every component is
+// active.
+func (s *Server) acquireAll(subject lease.Subject, props lease.Properties, policies policy.Set, resources []string) (ls lease.Lease, components []lease.Lease, snapshots []lease.Snapshot, err error) {
+	prefix := subject.String()
+
+	var (
+		modes    []string    // Only used for logging
+		previous lease.Lease // The primary lease that was renewed, if any
+	)
+
+	for attempt := 0; attempt < 5; attempt++ {
+		now := time.Now()
+
+		var (
+			txs      []*lease.Tx
+			existing []lease.Lease
+			found    []bool
+			ready    = true // Can all of the components be activated?
+		)
+
+		components = make([]lease.Lease, len(resources))
+		modes = make([]string, len(resources))
+
+		for i, resource := range resources {
+			var revision uint64
+			var leases lease.Set
+
+			revision, leases, err = s.LeaseProvider.LeaseView(resource)
+			if err != nil {
+				break
+			}
+
+			component := subject
+			component.Resource = resource
+
+			cl := s.newLease(component, props, componentPolicies(policies, resource), now)
+			cl.Components = resources
+
+			tx := lease.NewTx(resource, revision, leases)
+			acc := leaseutil.Refresh(tx, now)
+			consumed := acc.Total(cl.Strategy)
+			released := acc.Released(cl.ConsumerKey())
+
+			current, ok := tx.Instance(subject.Instance)
+			switch {
+			case ok && current.Status == lease.Active:
+			case ok && current.Status == lease.Released:
+				ready = ready && renewedTotal(cl.Strategy, consumed, current, cl) <= leaseutil.Limit(acc, cl)
+			case released > 0 && acc.TotalAfterReplacement(cl.ConsumerKey(), cl.Weight(), cl.Strategy) <= leaseutil.Limit(acc, cl):
+			default:
+				ready = ready && leaseutil.CanActivate(cl.Strategy, acc.Active(cl.ConsumerKey()), consumed, cl.Weight(), leaseutil.Limit(acc, cl))
+			}
+			if !ok || current.Status != lease.Active {
+				ready = ready && leaseutil.SubLimit(acc, cl) == ""
+			}
+
+			components[i] = cl
+			txs = append(txs, tx)
+			existing = append(existing, current)
+			found = append(found, ok)
+		}
+		if err != nil {
+			printf(s.Logger, "%s: Lease retrieval failed: %v\n", prefix, err)
+			continue
+		}
+
+		previous = existing[0]
+
+		for i, tx := range txs {
+			cl := &components[i]
+			modes[i] = "Creation"
+
+			if found[i] && existing[i].Status != lease.Released {
+				// Renewal of active or queued lease
+				modes[i] = "Renewal"
+				cl.Started = existing[i].Started
+				cl.Preempt = existing[i].Preempt
+			}
+
+			if !ready {
+				// The components are a unit, so none of them remain active
+				// while any of them is waiting for units
+				cl.Status = lease.Queued
+				cl.Preempt = time.Time{}
+				if found[i] {
+					tx.Update(existing[i].Instance, *cl)
+				} else {
+					tx.Create(*cl)
+				}
+				continue
+			}
+
+			cl.Status = lease.Active
+			if found[i] && existing[i].Status == lease.Queued {
+				// Sessions start when the lease is activated
+				cl.Started = now
+			}
+
+			if found[i] && existing[i].Status != lease.Queued {
+				tx.Update(existing[i].Instance, *cl)
+				continue
+			}
+
+			// When possible, replace an existing lease for the same consumer
+			// that has already been released and is decaying
+			replaceable := tx.Consumer(cl.ConsumerKey()).Status(lease.Released)
+			if len(replaceable) > 0 {
+				if found[i] {
+					tx.Delete(existing[i].Instance)
+				}
+				tx.Update(replaceable[len(replaceable)-1].Instance, *cl)
+				continue
+			}
+
+			if found[i] {
+				tx.Update(existing[i].Instance, *cl)
+			} else {
+				tx.Create(*cl)
+			}
+		}
+
+		// Release components that the current policies no longer require
+		if previous.Composite() {
+			for _, resource := range previous.Components {
+				if containsResource(resources, resource) {
+					continue
+				}
+				var tx *lease.Tx
+				tx, err = s.releaseTx(resource, subject.Instance, now)
+				if err != nil {
+					break
+				}
+				txs = append(txs, tx)
+			}
+			if err != nil {
+				printf(s.Logger, "%s: Lease retrieval failed: %v\n", prefix, err)
+				continue
+			}
+		}
+
+		// Retain the snapshots even if this ends up being an empty transaction
+		snapshots = make([]lease.Snapshot, len(txs))
+		for i, tx := range txs {
+			snapshots[i] = lease.Snapshot{
+				Resource: tx.Resource(),
+				Revision: tx.Revision(),
+				Leases:   tx.Leases(),
+			}
+			snapshots[i].Stats = snapshots[i].Leases.Stats()
+			snapshots[i].Queue = s.queue(tx.Resource(), snapshots[i].Leases, now)
+		}
+
+		// Attempt to commit the transactions
+		err = s.LeaseProvider.LeaseCommitAll(txs...)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Lease acquisition failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	ls = lease.Clone(components[0])
+	for i := range components {
+		if components[i].Status != lease.Active {
+			ls.Status = lease.Queued
+		}
+	}
+
+	now := time.Now()
+	for i, snapshot := range snapshots {
+		s.usage.Observe(snapshot.Resource, snapshot.Leases, now)
+		if i < len(components) {
+			cl := components[i]
+			summary := statsSummary(cl.Limit, snapshot.Stats, cl.Strategy)
+			printf(s.Logger, "%s: %s of %s lease component %s succeeded (%s)\n", prefix, modes[i], cl.Status, cl.Resource, summary)
+			s.publishLeaseUpdate(snapshot, summary)
+		} else {
+			printf(s.Logger, "%s: Release of lease component %s succeeded\n", prefix, snapshot.Resource)
+			s.publishLeaseUpdate(snapshot, snapshot.Resource)
+		}
+	}
+
+	snapshots = snapshots[:len(components)]
+
+	return
+}
+
+// releaseComponents will release the leases held by instance for each of the
+// given resources atomically, except for the skipped resource.
+func (s *Server) releaseComponents(instance lease.Instance, resources []string, skip string) (err error) {
+	prefix := instance.String()
+
+	var txs []*lease.Tx
+
+	for attempt := 0; attempt < 5; attempt++ {
+		now := time.Now()
+
+		txs = txs[:0]
+		for _, resource := range resources {
+			if resource == skip {
+				continue
+			}
+			var tx *lease.Tx
+			tx, err = s.releaseTx(resource, instance, now)
+			if err != nil {
+				break
+			}
+			txs = append(txs, tx)
+		}
+		if err != nil {
+			printf(s.Logger, "%s: Release failed: %v\n", prefix, err)
+			continue
+		}
+
+		// Attempt to commit the transactions
+		err = s.LeaseProvider.LeaseCommitAll(txs...)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Release failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, tx := range txs {
+		s.sessions.Record(tx, now)
+		snapshot := lease.Snapshot{
+			Resource: tx.Resource(),
+			Revision: tx.Revision(),
+			Leases:   tx.Leases(),
+		}
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(tx.Resource(), snapshot.Leases, now)
+		printf(s.Logger, "%s: Release of lease component %s succeeded\n", prefix, tx.Resource())
+		s.publishLeaseUpdate(snapshot, tx.Resource())
+	}
+
+	return nil
+}
+
+// releaseTx returns a transaction that releases the lease held by instance
+// for the given resource.
+func (s *Server) releaseTx(resource string, instance lease.Instance, now time.Time) (*lease.Tx, error) {
+	revision, leases, err := s.LeaseProvider.LeaseView(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := lease.NewTx(resource, revision, leases)
+	leaseutil.Refresh(tx, now) // Update stale values
+	tx.Release(instance, now)
+	leaseutil.Refresh(tx, now) // Updates leases after release
+
+	return tx, nil
+}
+
+// componentStatus returns the status that the active component ls of a
+// composite lease should have to match its other components. Components that
+// are queued, such as when they have been preempted, return ls to the queue.
+// Components that have been released or removed, such as when their sessions
+// have ended, release ls. The leases of each resource are retrieved through
+// view, which returns false if they are unknown.
+func componentStatus(ls lease.Lease, view func(resource string) (lease.Set, bool)) lease.Status {
+	status := lease.Active
+	for _, resource := range ls.Components {
+		if resource == ls.Resource {
+			continue
+		}
+		leases, ok := view(resource)
+		if !ok {
+			continue
+		}
+		component, found := leases.Instance(resource, ls.Instance)
+		switch {
+		case !found, component.Status == lease.Released:
+			return lease.Released
+		case component.Status == lease.Queued:
+			status = lease.Queued
+		}
+	}
+	return status
+}
+
+// demoteComponents returns the active components of composite leases for
+// resource to the queue, or releases them, when their other components are
+// no longer active.
+func (s *Server) demoteComponents(resource string) (err error) {
+	var (
+		tx      *lease.Tx
+		demoted lease.Set
+		now     time.Time
+	)
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+		revision, leases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Component demotion failed: %v\n", resource, err)
+			continue
+		}
+
+		now = time.Now()
+		tx = lease.NewTx(resource, revision, leases)
+		leaseutil.Refresh(tx, now) // Update stale values
+
+		// The other components are viewed after the resource, so that
+		// changes committed to them in the meantime also change its revision
+		views := make(map[string]lease.Set)
+		view := func(other string) (lease.Set, bool) {
+			if leases, ok := views[other]; ok {
+				return leases, true
+			}
+			revision, leases, err := s.LeaseProvider.LeaseView(other)
+			if err != nil {
+				return nil, false
+			}
+			vtx := lease.NewTx(other, revision, leases)
+			leaseutil.Refresh(vtx, now)
+			views[other] = vtx.Leases()
+			return views[other], true
+		}
+
+		demoted = nil
+		tx.Process(func(iter *lease.Iter) {
+			if iter.Status != lease.Active || !iter.Composite() {
+				return
+			}
+			switch componentStatus(iter.Lease, view) {
+			case lease.Queued:
+				iter.Status = lease.Queued
+				iter.Preempt = time.Time{}
+			case lease.Released:
+				iter.Status = lease.Released
+				iter.Released = now
+			default:
+				return
+			}
+			iter.Update()
+			demoted = append(demoted, iter.Lease)
+		})
+
+		if len(demoted) == 0 {
+			return nil
+		}
+
+		leaseutil.Refresh(tx, now) // Units may now be available to queued leases
+
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			s.sessions.Record(tx, now)
+			break
+		}
+
+		printf(s.Logger, "%s: Component demotion failed: %v\n", resource, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	leases := tx.Leases()
+	snapshot := lease.Snapshot{
+		Resource: resource,
+		Revision: tx.Revision(),
+		Leases:   leases,
+		Stats:    leases.Stats(),
+		Queue:    s.queue(resource, leases, now),
+	}
+	summary := statsSummary(demoted[0].Limit, snapshot.Stats, demoted[0].Strategy)
+	for _, ls := range demoted {
+		printf(s.Logger, "%s: Lease component changed to %s to match the other components (%s)\n", ls.Subject, ls.Status, summary)
+	}
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return nil
+}
+
+// componentPolicies returns the subset of policies that apply to the given
+// component resource of a composite lease. Policies that don't specify a
+// resource apply to every component.
+func componentPolicies(policies policy.Set, resource string) (matches policy.Set) {
+	for _, pol := range policies {
+		if pol.Resource == "" || pol.Resource == resource {
+			matches = append(matches, pol)
+		}
+	}
+	return
+}
+
+func containsResource(resources []string, resource string) bool {
+	for _, r := range resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
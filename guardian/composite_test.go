@@ -0,0 +1,163 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/memprov"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+// staticPolicies is a policy provider with a fixed set of policies.
+type staticPolicies policy.Set
+
+func (p staticPolicies) ProviderName() string          { return "static" }
+func (p staticPolicies) Policies() (policy.Set, error) { return policy.Set(p), nil }
+func (p staticPolicies) Close() error                  { return nil }
+
+func TestPreemptedComponentDemotesComposite(t *testing.T) {
+	cad := policy.New("cad", strategy.Instance, 1, time.Minute, nil)
+	render := policy.New("render", strategy.Instance, 1, time.Minute, nil)
+	urgent := cad
+	urgent.Priority = 10
+	urgent.Preemption = time.Millisecond
+
+	s := NewServer(ServerConfig{
+		LeaseProvider:  memprov.New(),
+		PolicyProvider: staticPolicies{cad, render},
+	})
+	props := lease.Properties{"program.name": "suite.exe"}
+
+	composite := lease.Subject{Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+	ls, _, _, err := s.acquireAll(composite, props, policy.Set{cad, render}, []string{"cad", "render"})
+	if err != nil {
+		t.Fatalf("composite acquisition: %v", err)
+	}
+	if ls.Status != lease.Active {
+		t.Fatalf("composite acquisition: got status %s, want %s", ls.Status, lease.Active)
+	}
+
+	// A higher priority lease for one of the components is queued, which
+	// schedules the preemption of the component when leases are next
+	// refreshed
+	preemptor := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws2", User: "asmith", ID: "1"}}
+	if _, _, err := s.acquire(preemptor, props, policy.Set{urgent}); err != nil {
+		t.Fatalf("preempting acquisition: %v", err)
+	}
+	s.refreshLeases()
+
+	// The component is preempted once the warning period has passed
+	time.Sleep(10 * time.Millisecond)
+	s.refreshLeases()
+
+	status := func(resource string, instance lease.Instance) lease.Status {
+		t.Helper()
+		_, leases, err := s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ls, found := leases.Instance(resource, instance)
+		if !found {
+			t.Fatalf("%s: no lease for %s", resource, instance)
+		}
+		return ls.Status
+	}
+
+	if got := status("cad", composite.Instance); got != lease.Queued {
+		t.Errorf("preempted component: got status %s, want %s", got, lease.Queued)
+	}
+	if got := status("render", composite.Instance); got != lease.Queued {
+		t.Errorf("other component: got status %s, want %s", got, lease.Queued)
+	}
+	if got := status("cad", preemptor.Instance); got != lease.Active {
+		t.Errorf("preempting lease: got status %s, want %s", got, lease.Active)
+	}
+}
+
+func TestReleasedComponentReleasesComposite(t *testing.T) {
+	cad := policy.New("cad", strategy.Instance, 1, time.Minute, nil)
+	render := policy.New("render", strategy.Instance, 1, time.Minute, nil)
+
+	s := NewServer(ServerConfig{
+		LeaseProvider:  memprov.New(),
+		PolicyProvider: staticPolicies{cad, render},
+	})
+	props := lease.Properties{"program.name": "suite.exe"}
+
+	composite := lease.Subject{Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+	if _, _, _, err := s.acquireAll(composite, props, policy.Set{cad, render}, []string{"cad", "render"}); err != nil {
+		t.Fatalf("composite acquisition: %v", err)
+	}
+
+	// End the session of one of the components
+	revision, leases, err := s.LeaseProvider.LeaseView("cad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := lease.NewTx("cad", revision, leases)
+	tx.Release(composite.Instance, time.Now())
+	if err := s.LeaseProvider.LeaseCommit(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	s.refreshLeases()
+
+	_, leases, err = s.LeaseProvider.LeaseView("render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls, found := leases.Instance("render", composite.Instance); !found || ls.Status != lease.Released {
+		t.Errorf("other component: got %s lease (found %t), want %s", ls.Status, found, lease.Released)
+	}
+}
+
+func TestCompositeRenewalQueuesEveryComponent(t *testing.T) {
+	cad := policy.New("cad", strategy.Instance, 1, time.Minute, nil)
+	render := policy.New("render", strategy.Instance, 1, time.Minute, nil)
+	policies := policy.Set{cad, render}
+	resources := []string{"cad", "render"}
+
+	s := NewServer(ServerConfig{
+		LeaseProvider:  memprov.New(),
+		PolicyProvider: staticPolicies(policies),
+	})
+	props := lease.Properties{"program.name": "suite.exe"}
+
+	composite := lease.Subject{Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+	_, components, _, err := s.acquireAll(composite, props, policies, resources)
+	if err != nil {
+		t.Fatalf("composite acquisition: %v", err)
+	}
+
+	// Another lease takes the units of one component after it has been
+	// returned to the queue, while the other component is still active
+	revision, leases, err := s.LeaseProvider.LeaseView("cad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := lease.NewTx("cad", revision, leases)
+	queued := components[0]
+	queued.Status = lease.Queued
+	tx.Update(composite.Instance, queued)
+	other := s.newLease(lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws2", User: "asmith", ID: "1"}}, props, policy.Set{cad}, time.Now())
+	other.Status = lease.Active
+	tx.Create(other)
+	if err := s.LeaseProvider.LeaseCommit(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	ls, components, _, err := s.acquireAll(composite, props, policies, resources)
+	if err != nil {
+		t.Fatalf("composite renewal: %v", err)
+	}
+	if ls.Status != lease.Queued {
+		t.Errorf("composite renewal: got status %s, want %s", ls.Status, lease.Queued)
+	}
+	for _, cl := range components {
+		if cl.Status != lease.Queued {
+			t.Errorf("composite renewal: component %s has status %s, want %s", cl.Resource, cl.Status, lease.Queued)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package guardian
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/scjalliance/resourceful/audit"
 	"github.com/scjalliance/resourceful/guardian/transport"
 	"github.com/scjalliance/resourceful/lease"
 )
@@ -40,6 +46,178 @@ func (e Endpoint) Release(ctx context.Context, subject lease.Subject) (response
 	return response, e.post(ctx, "release", subject, nil, &response)
 }
 
+// Update attempts to update the properties of the lease held by subject.
+func (e Endpoint) Update(ctx context.Context, subject lease.Subject, props lease.Properties) (response transport.UpdateResponse, err error) {
+	return response, e.post(ctx, "update", subject, props, &response)
+}
+
+// Bookings returns the current set of bookings for a resource from the
+// endpoint. If resource is empty the bookings for all resources are returned.
+func (e Endpoint) Bookings(ctx context.Context, resource string) (response transport.BookingsResponse, err error) {
+	path := "bookings"
+	if resource != "" {
+		path += "?" + url.Values{"resource": {resource}}.Encode()
+	}
+	return response, e.get(ctx, path, &response)
+}
+
+// History returns the lease history records selected by filter from the
+// endpoint.
+func (e Endpoint) History(ctx context.Context, filter audit.Filter) (response transport.HistoryResponse, err error) {
+	v := url.Values{}
+	if filter.Resource != "" {
+		v.Set("resource", filter.Resource)
+	}
+	if filter.Host != "" {
+		v.Set("host", filter.Host)
+	}
+	if filter.User != "" {
+		v.Set("user", filter.User)
+	}
+	if !filter.Start.IsZero() {
+		v.Set("start", filter.Start.Format(time.RFC3339))
+	}
+	if !filter.End.IsZero() {
+		v.Set("end", filter.End.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		v.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	path := "history"
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	return response, e.get(ctx, path, &response)
+}
+
+// Book attempts to book units of a resource for a window of time on behalf
+// of the administrator identified by token.
+func (e Endpoint) Book(ctx context.Context, token, resource string, units uint, booking lease.Booking) (response transport.BookingResponse, err error) {
+	v := url.Values{}
+	v.Set("resource", resource)
+	v.Set("units", strconv.FormatUint(uint64(units), 10))
+	v.Set("start", booking.Start.Format(time.RFC3339))
+	v.Set("end", booking.End.Format(time.RFC3339))
+	if booking.ID != "" {
+		v.Set("id", booking.ID)
+	}
+	if booking.Note != "" {
+		v.Set("note", booking.Note)
+	}
+	v["user"] = booking.Users
+	v["host"] = booking.Hosts
+	return response, e.postAuthorized(ctx, token, "book", v, &response)
+}
+
+// Unbook attempts to cancel a booking on behalf of the administrator
+// identified by token.
+func (e Endpoint) Unbook(ctx context.Context, token, resource, id string) (response transport.BookingResponse, err error) {
+	v := url.Values{}
+	v.Set("resource", resource)
+	v.Set("id", id)
+	return response, e.postAuthorized(ctx, token, "unbook", v, &response)
+}
+
+// Borrow attempts to borrow a lease for the given resource and consumer for
+// use while disconnected. If duration is zero the maximum duration permitted
+// by the guardian's policies is requested.
+func (e Endpoint) Borrow(ctx context.Context, subject lease.Subject, props lease.Properties, duration time.Duration) (response transport.BorrowResponse, err error) {
+	v := urlValues(subject, props)
+	if duration > 0 {
+		v.Set("duration", duration.String())
+	}
+	return response, e.postValues(ctx, "borrow", v, &response)
+}
+
+// Return attempts to return a borrowed lease for the given resource and
+// consumer.
+func (e Endpoint) Return(ctx context.Context, subject lease.Subject) (response transport.ReleaseResponse, err error) {
+	return response, e.post(ctx, "return", subject, nil, &response)
+}
+
+// Revoke forcibly releases the lease held by subject on behalf of the
+// administrator identified by token. If remove is true the lease is deleted
+// instead of released.
+func (e Endpoint) Revoke(ctx context.Context, token string, subject lease.Subject, remove bool, reason string) (response transport.AdminResponse, err error) {
+	v := urlValues(subject, nil)
+	v.Set("mode", revocationMode(remove))
+	if reason != "" {
+		v.Set("reason", reason)
+	}
+	return response, e.postAuthorized(ctx, token, "admin/revoke", v, &response)
+}
+
+// Purge forcibly releases every lease held by host or user on behalf of the
+// administrator identified by token. If resource is not empty only the
+// leases for resource are purged. If remove is true the leases are deleted
+// instead of released.
+func (e Endpoint) Purge(ctx context.Context, token, resource, host, user string, remove bool, reason string) (response transport.AdminResponse, err error) {
+	v := url.Values{}
+	if resource != "" {
+		v.Set("resource", resource)
+	}
+	if host != "" {
+		v.Set("host", host)
+	}
+	if user != "" {
+		v.Set("user", user)
+	}
+	v.Set("mode", revocationMode(remove))
+	if reason != "" {
+		v.Set("reason", reason)
+	}
+	return response, e.postAuthorized(ctx, token, "admin/purge", v, &response)
+}
+
+// Hold holds units of resource on behalf of the administrator identified by
+// token. If duration is zero the hold lasts until it is removed. If id is
+// empty an identifier is generated by the guardian.
+func (e Endpoint) Hold(ctx context.Context, token, resource string, units uint, duration time.Duration, id, note string) (response transport.AdminResponse, err error) {
+	v := url.Values{}
+	v.Set("resource", resource)
+	v.Set("units", strconv.FormatUint(uint64(units), 10))
+	if duration > 0 {
+		v.Set("duration", duration.String())
+	}
+	if id != "" {
+		v.Set("id", id)
+	}
+	if note != "" {
+		v.Set("note", note)
+	}
+	return response, e.postAuthorized(ctx, token, "admin/hold", v, &response)
+}
+
+// Unhold removes a hold on behalf of the administrator identified by token.
+func (e Endpoint) Unhold(ctx context.Context, token, resource, id string) (response transport.AdminResponse, err error) {
+	v := url.Values{}
+	v.Set("resource", resource)
+	v.Set("id", id)
+	return response, e.postAuthorized(ctx, token, "admin/unhold", v, &response)
+}
+
+// Checkpoint will ask the guardian to record a checkpoint of its lease data
+// on behalf of the administrator identified by token.
+func (e Endpoint) Checkpoint(ctx context.Context, token string) (response transport.AdminResponse, err error) {
+	return response, e.postAuthorized(ctx, token, "admin/checkpoint", url.Values{}, &response)
+}
+
+// Revocations listens to the revocation stream of the endpoint and calls fn
+// for each revocation that it announces. It blocks until ctx is cancelled or
+// the stream is closed.
+func (e Endpoint) Revocations(ctx context.Context, fn func(transport.RevocationEvent)) error {
+	return e.stream(ctx, "revocations", func(event string, data []byte) {
+		if event != "revocation" {
+			return
+		}
+		var revocation transport.RevocationEvent
+		if err := json.Unmarshal(data, &revocation); err != nil {
+			return
+		}
+		fn(revocation)
+	})
+}
+
 // prefix returns the URL prefix for the endpoint.
 func (e Endpoint) prefix() string {
 	u := string(e)
@@ -83,7 +261,83 @@ func (e Endpoint) get(ctx context.Context, path string, response interface{}) (e
 	return json.NewDecoder(resp.Body).Decode(response)
 }
 
+// stream reads server sent events from path and calls fn with the type and
+// data of each event until ctx is cancelled or the stream is closed.
+func (e Endpoint) stream(ctx context.Context, path string, fn func(event string, data []byte)) (err error) {
+	if e == "" {
+		return ErrEmptyEndpoint
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	addr := e.prefix() + path
+	req, err := http.NewRequest("GET", addr, nil)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("http status: %v", resp.Status)
+	}
+
+	var (
+		r     = bufio.NewReader(resp.Body)
+		event string
+		data  []byte
+	)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		// A blank line dispatches the event
+		if line == "" {
+			if len(data) > 0 {
+				fn(event, data)
+			}
+			event, data = "", nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, value...)
+		}
+	}
+}
+
 func (e Endpoint) post(ctx context.Context, path string, subject lease.Subject, props lease.Properties, response interface{}) (err error) {
+	return e.postValues(ctx, path, urlValues(subject, props), response)
+}
+
+func (e Endpoint) postValues(ctx context.Context, path string, values url.Values, response interface{}) (err error) {
+	return e.postAuthorized(ctx, "", path, values, response)
+}
+
+func (e Endpoint) postAuthorized(ctx context.Context, token, path string, values url.Values, response interface{}) (err error) {
 	if e == "" {
 		return ErrEmptyEndpoint
 	}
@@ -93,7 +347,7 @@ func (e Endpoint) post(ctx context.Context, path string, subject lease.Subject,
 	}
 
 	addr := e.prefix() + path
-	body := strings.NewReader(urlValues(subject, props).Encode())
+	body := strings.NewReader(values.Encode())
 	req, err := http.NewRequest("POST", addr, body)
 	if err != nil {
 		return err
@@ -101,6 +355,9 @@ func (e Endpoint) post(ctx context.Context, path string, subject lease.Subject,
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -119,10 +376,21 @@ func (e Endpoint) post(ctx context.Context, path string, subject lease.Subject,
 	case http.StatusOK:
 		return json.NewDecoder(resp.Body).Decode(response)
 	default:
+		// Include the explanation provided by the server, if any
+		if msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512)); len(bytes.TrimSpace(msg)) > 0 {
+			return fmt.Errorf("http status: %v: %s", resp.Status, bytes.TrimSpace(msg))
+		}
 		return fmt.Errorf("http status: %v", resp.Status)
 	}
 }
 
+func revocationMode(remove bool) string {
+	if remove {
+		return revokeDelete
+	}
+	return revokeRelease
+}
+
 func urlValues(subject lease.Subject, props lease.Properties) url.Values {
 	v := url.Values{}
 	if subject.Resource != "" {
@@ -0,0 +1,88 @@
+package guardian
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/scjalliance/resourceful/audit"
+	"github.com/scjalliance/resourceful/guardian/transport"
+)
+
+// historyHandler will return the lease history records that match the
+// resource, host, user and time range specified by the request.
+func (s *Server) historyHandler(w http.ResponseWriter, r *http.Request) {
+	if s.History == nil {
+		http.Error(w, "Lease history is not recorded by this guardian", http.StatusNotFound)
+		return
+	}
+
+	filter, err := parseHistoryFilter(r)
+	if err != nil {
+		printf(s.Logger, "Bad history request: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := s.History.Query(filter)
+	if err != nil {
+		printf(s.Logger, "Lease history retrieval failed: %v\n", err)
+		http.Error(w, "Lease history retrieval failed", http.StatusInternalServerError)
+		return
+	}
+
+	response := transport.HistoryResponse{
+		Records: records,
+	}
+	if response.Records == nil {
+		response.Records = []audit.Record{}
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		printf(s.Logger, "Failed to marshal history response: %v\n", err)
+		http.Error(w, "Failed to marshal response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(data)
+}
+
+// parseHistoryFilter parses a history request. Times must be provided in
+// RFC 3339 format.
+func parseHistoryFilter(r *http.Request) (filter audit.Filter, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+
+	filter.Resource = r.Form.Get("resource")
+	filter.Host = r.Form.Get("host")
+	filter.User = r.Form.Get("user")
+
+	if value := r.Form.Get("start"); value != "" {
+		if filter.Start, err = time.Parse(time.RFC3339, value); err != nil {
+			err = fmt.Errorf("invalid start time: %v", err)
+			return
+		}
+	}
+
+	if value := r.Form.Get("end"); value != "" {
+		if filter.End, err = time.Parse(time.RFC3339, value); err != nil {
+			err = fmt.Errorf("invalid end time: %v", err)
+			return
+		}
+	}
+
+	if value := r.Form.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 0 {
+			err = fmt.Errorf("invalid limit \"%s\"", value)
+			return
+		}
+	}
+
+	return
+}
@@ -29,6 +29,8 @@ type LeaseMaintainer struct {
 	opMutex  sync.RWMutex
 	shutdown chan bool // receives true to release before shutdown
 	stopped  chan struct{}
+	updated  chan struct{} // signaled when the lease properties are updated
+	revoked  chan struct{} // signaled when the lease is revoked by an administrator
 
 	stateMutex sync.RWMutex
 	state      lease.State
@@ -51,6 +53,8 @@ func NewLeaseMaintainer(client *Client, instance lease.Instance, props lease.Pro
 		instance: instance,
 		props:    props,
 		retry:    retry,
+		updated:  make(chan struct{}, 1),
+		revoked:  make(chan struct{}, 1),
 	}
 }
 
@@ -136,12 +140,21 @@ func (lm *LeaseMaintainer) Close() error {
 
 // Update instructs the lease maintainer to update the properties of the
 // lease.
+//
+// If the maintainer is running it asks the guardian to update its lease
+// immediately. The guardian evaluates its policies against the new
+// properties, which might cause the lease to be transmuted into a lease for
+// a different resource. Listeners receive the resulting lease state.
 func (lm *LeaseMaintainer) Update(props lease.Properties) {
 	lm.stateMutex.Lock()
-	defer lm.stateMutex.Unlock()
 	lm.props = props
+	lm.stateMutex.Unlock()
 
-	// TODO: Issue an acquisition?
+	select {
+	case lm.updated <- struct{}{}:
+	default:
+		// An update is already pending
+	}
 }
 
 // State returns the current lease state.
@@ -212,6 +225,11 @@ func (lm *LeaseMaintainer) run(shutdown <-chan bool, stopped chan struct{}) {
 		}()
 	}
 
+	// Listen for revocations so that they take effect immediately instead of
+	// at the next renewal
+	unwatch := lm.client.WatchRevocations(lm.instance, lm.revoked)
+	defer unwatch()
+
 	// Give our operations 10 seconds to complete
 	const timeout = 10 * time.Second
 
@@ -233,6 +251,30 @@ func (lm *LeaseMaintainer) run(shutdown <-chan bool, stopped chan struct{}) {
 			}
 
 			return
+		case <-lm.updated:
+			if !timer.Stop() {
+				<-timer.C
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			state := lm.update(ctx)
+			cancel()
+
+			interval := lm.interval(state)
+			timer.Reset(interval)
+		case <-lm.revoked:
+			if !timer.Stop() {
+				<-timer.C
+			}
+
+			// The guardian explains the revocation when we next try to
+			// acquire the lease
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			state := lm.acquire(ctx)
+			cancel()
+
+			interval := lm.interval(state)
+			timer.Reset(interval)
 		case <-timer.C:
 			ctx, cancel := context.WithTimeout(ctx, timeout)
 			state := lm.acquire(ctx)
@@ -259,6 +301,42 @@ func (lm *LeaseMaintainer) acquire(ctx context.Context) lease.State {
 
 	response, err := lm.client.Acquire(ctx, subject, lm.props)
 
+	return lm.apply(response, err)
+}
+
+func (lm *LeaseMaintainer) update(ctx context.Context) lease.State {
+	lm.stateMutex.Lock()
+
+	// Only active and queued leases for a single resource can be updated.
+	// In all other cases the new properties are applied by an acquisition.
+	current := lm.state.Lease
+	if !lm.state.Acquired || current.Composite() || (current.Status != lease.Active && current.Status != lease.Queued) {
+		lm.stateMutex.Unlock()
+		return lm.acquire(ctx)
+	}
+
+	response, err := lm.client.Update(ctx, current.Subject, lm.props)
+	if err != nil && err != ErrLeaseNotRequired {
+		// The guardian couldn't update the lease, possibly because it has
+		// been revoked, so we fall back to acquiring one
+		lm.stateMutex.Unlock()
+		return lm.acquire(ctx)
+	}
+	defer lm.stateMutex.Unlock()
+
+	return lm.apply(transport.AcquireResponse{
+		Request: response.Request,
+		Lease:   response.Lease,
+		Leases:  response.Leases,
+		Queue:   response.Queue,
+		Message: response.Message,
+	}, err)
+}
+
+// apply updates the lease state with the result of an acquisition or update
+// and broadcasts it to all listeners. The caller must hold a lock on the
+// stateMutex for the duration of the call.
+func (lm *LeaseMaintainer) apply(response transport.AcquireResponse, err error) lease.State {
 	switch err {
 	case nil:
 		lm.state.Online = true
@@ -266,6 +344,8 @@ func (lm *LeaseMaintainer) acquire(ctx context.Context) lease.State {
 		lm.state.Acquired = true
 		lm.state.Lease = response.Lease
 		lm.state.Leases = response.Leases
+		lm.state.Message = response.Message
+		lm.state.Queue = response.Queue
 		lm.state.Err = nil
 	case ErrLeaseNotRequired:
 		lm.state.Online = true
@@ -273,6 +353,8 @@ func (lm *LeaseMaintainer) acquire(ctx context.Context) lease.State {
 		lm.state.Acquired = false
 		lm.state.Lease = lease.Lease{}
 		lm.state.Leases = nil
+		lm.state.Message = ""
+		lm.state.Queue = nil
 		lm.state.Err = nil
 	default:
 		lm.state.Online = false
@@ -0,0 +1,69 @@
+package guardian
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// revocationRetention is the length of time for which a revocation is
+// remembered so that it can be reported to the holder of the revoked lease.
+const revocationRetention = 24 * time.Hour
+
+// revocation describes a lease that was revoked by an administrator.
+type revocation struct {
+	Operator string
+	Reason   string
+	At       time.Time
+}
+
+// Message returns an explanation of the revocation that is suitable for
+// display to the holder of the revoked lease.
+func (r revocation) Message() string {
+	if r.Reason == "" {
+		return fmt.Sprintf("The lease was revoked by %s.", r.Operator)
+	}
+	return fmt.Sprintf("The lease was revoked by %s: %s", r.Operator, r.Reason)
+}
+
+// revocationTracker remembers the instances whose leases have been revoked
+// by an administrator until the revocation has been reported to them.
+//
+// The zero value is ready for use.
+type revocationTracker struct {
+	mutex   sync.Mutex
+	revoked map[lease.Instance]revocation
+}
+
+// Add records the revocation of the lease held by instance.
+func (t *revocationTracker) Add(instance lease.Instance, r revocation) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.revoked == nil {
+		t.revoked = make(map[lease.Instance]revocation)
+	}
+	t.revoked[instance] = r
+}
+
+// Take returns the revocation of the lease held by instance, if there is
+// one, and forgets it.
+func (t *revocationTracker) Take(instance lease.Instance, at time.Time) (r revocation, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Forget revocations that were never reported
+	for inst, rev := range t.revoked {
+		if at.Sub(rev.At) > revocationRetention {
+			delete(t.revoked, inst)
+		}
+	}
+
+	r, ok = t.revoked[instance]
+	if ok {
+		delete(t.revoked, instance)
+	}
+	return
+}
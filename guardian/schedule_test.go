@@ -0,0 +1,79 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/memprov"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestReducedLimitDemotesAfterGrace(t *testing.T) {
+	criteria := policy.Criteria{{Key: "program.name", Comparison: policy.ComparisonExact, Value: "cad.exe"}}
+	unscheduled := policy.New("cad", strategy.Instance, 2, time.Minute, criteria)
+
+	// The schedule reduces the limit at all times
+	scheduled := unscheduled
+	scheduled.Schedule = &policy.Schedule{
+		Grace:   20 * time.Millisecond,
+		Windows: []policy.Window{{Limit: 1}},
+	}
+
+	s := NewServer(ServerConfig{
+		LeaseProvider:  memprov.New(),
+		PolicyProvider: staticPolicies{unscheduled},
+	})
+	props := lease.Properties{"program.name": "cad.exe"}
+
+	older := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+	newer := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws2", User: "asmith", ID: "1"}}
+	for _, subject := range []lease.Subject{older, newer} {
+		ls, _, err := s.acquire(subject, props, policy.Set{unscheduled})
+		if err != nil {
+			t.Fatalf("%s: acquire: %v", subject, err)
+		}
+		if ls.Status != lease.Active {
+			t.Fatalf("%s: got status %s, want %s", subject, ls.Status, lease.Active)
+		}
+		time.Sleep(time.Millisecond) // Give the leases distinct start times
+	}
+
+	status := func(subject lease.Subject) lease.Lease {
+		t.Helper()
+		_, leases, err := s.LeaseProvider.LeaseView(subject.Resource)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ls, found := leases.Instance(subject.Resource, subject.Instance)
+		if !found {
+			t.Fatalf("%s: no lease", subject)
+		}
+		return ls
+	}
+
+	// The newest lease is warned when the schedule reduces the limit, but
+	// remains active during the grace period
+	s.PolicyProvider = staticPolicies{scheduled}
+	s.refreshLeases()
+
+	if ls := status(newer); ls.Status != lease.Active || ls.Preempt.IsZero() {
+		t.Errorf("newer lease during grace: got %s lease with preemption at %v, want an active lease with a preemption time", ls.Status, ls.Preempt)
+	}
+	if ls := status(older); ls.Status != lease.Active || !ls.Preempt.IsZero() {
+		t.Errorf("older lease during grace: got %s lease with preemption at %v, want an active lease without one", ls.Status, ls.Preempt)
+	}
+
+	// Once the grace period has passed the newest lease is returned to the
+	// queue
+	time.Sleep(30 * time.Millisecond)
+	s.refreshLeases()
+
+	if ls := status(newer); ls.Status != lease.Queued {
+		t.Errorf("newer lease after grace: got status %s, want %s", ls.Status, lease.Queued)
+	}
+	if ls := status(older); ls.Status != lease.Active {
+		t.Errorf("older lease after grace: got status %s, want %s", ls.Status, lease.Active)
+	}
+}
@@ -2,6 +2,7 @@ package guardian
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,10 +10,12 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/AndrewBurian/eventsource/v2"
 	"github.com/golang/gddo/httputil"
+	"github.com/scjalliance/resourceful/audit"
 	"github.com/scjalliance/resourceful/guardian/transport"
 	"github.com/scjalliance/resourceful/lease"
 	"github.com/scjalliance/resourceful/lease/leaseutil"
@@ -28,7 +31,10 @@ type ServerConfig struct {
 	RefreshInterval time.Duration // Time between lease refreshes sent to clients
 	ShutdownTimeout time.Duration // Time allowed to the HTTP server to perform a graceful shutdown
 	Logger          *log.Logger
-	Handler         http.Handler // Optional HTTP handler served on "/"
+	Handler         http.Handler       // Optional HTTP handler served on "/"
+	BorrowKey       ed25519.PrivateKey // Key used to sign borrowed lease tokens, or nil if borrowing is disabled
+	Operators       map[string]string  // Maps administrative access tokens to operator names, or nil if administration is disabled
+	History         audit.Store        // Lease history, or nil if it is not recorded
 }
 
 // Server is a resourceful guardian HTTP server that coordinates locks on
@@ -36,6 +42,11 @@ type ServerConfig struct {
 type Server struct {
 	ServerConfig
 	Stream *eventsource.Stream
+
+	usage       usageTracker        // Daily usage of resources with quotas
+	sessions    sessionTracker      // Typical session lengths of resources
+	revocations revocationTracker   // Leases revoked by administrators
+	revoked     *eventsource.Stream // Revocation announcements for lease holders
 }
 
 // NewServer creates a new resourceful guardian server that will handle HTTP
@@ -44,6 +55,7 @@ func NewServer(cfg ServerConfig) *Server {
 	return &Server{
 		ServerConfig: cfg,
 		Stream:       eventsource.NewStream(),
+		revoked:      eventsource.NewStream(),
 	}
 }
 
@@ -78,7 +90,20 @@ func (s *Server) Run(ctx context.Context) (err error) {
 	mux.Handle("/leases", http.HandlerFunc(s.leasesHandler))
 	mux.Handle("/acquire", http.HandlerFunc(s.acquireHandler))
 	mux.Handle("/release", http.HandlerFunc(s.releaseHandler))
+	mux.Handle("/update", http.HandlerFunc(s.updateHandler))
+	mux.Handle("/borrow", http.HandlerFunc(s.borrowHandler))
+	mux.Handle("/return", http.HandlerFunc(s.returnHandler))
+	mux.Handle("/bookings", http.HandlerFunc(s.bookingsHandler))
+	mux.Handle("/book", http.HandlerFunc(s.bookHandler))
+	mux.Handle("/unbook", http.HandlerFunc(s.unbookHandler))
+	mux.Handle("/history", http.HandlerFunc(s.historyHandler))
 	mux.Handle("/stream", http.HandlerFunc(s.streamHandler))
+	mux.Handle("/revocations", s.revoked) // Lease holders listen without receiving every update
+	mux.Handle("/admin/revoke", http.HandlerFunc(s.revokeHandler))
+	mux.Handle("/admin/purge", http.HandlerFunc(s.purgeHandler))
+	mux.Handle("/admin/hold", http.HandlerFunc(s.holdHandler))
+	mux.Handle("/admin/unhold", http.HandlerFunc(s.unholdHandler))
+	mux.Handle("/admin/checkpoint", http.HandlerFunc(s.checkpointHandler))
 	if s.Handler != nil {
 		mux.Handle("/", s.Handler)
 	}
@@ -152,7 +177,7 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	fmt.Fprintf(w, string(data))
+	w.Write(data)
 }
 
 // policiesHandler will return the complete set of policies.
@@ -185,7 +210,7 @@ func (s *Server) policiesHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, string(data))
+		w.Write(data)
 		/*
 			case "text/event-stream":
 				//s.Stream.TopicHandler()
@@ -232,7 +257,7 @@ func (s *Server) leasesHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	fmt.Fprintf(w, string(data))
+	w.Write(data)
 }
 
 // collectLeases returns a complete set of resources names from the providers.
@@ -253,12 +278,14 @@ func (s *Server) collectResources() (resources []string, err error) {
 	for i := 0; i < len(policies); i++ {
 		resource := policies[i].Resource
 		if resource != "" && !seen[resource] {
+			seen[resource] = true
 			resources = append(resources, resource)
 		}
 	}
 
 	for _, resource := range leaseResources {
 		if resource != "" && !seen[resource] {
+			seen[resource] = true
 			resources = append(resources, resource)
 		}
 	}
@@ -306,7 +333,9 @@ func (s *Server) collectSnapshot(resource string) (snapshot lease.Snapshot, err
 
 	// Make a best effort to commit any changes
 	if !tx.Empty() {
-		s.LeaseProvider.LeaseCommit(tx)
+		if s.LeaseProvider.LeaseCommit(tx) == nil {
+			s.sessions.Record(tx, now)
+		}
 	}
 
 	// Take the cleaned-up set of leases
@@ -318,6 +347,7 @@ func (s *Server) collectSnapshot(resource string) (snapshot lease.Snapshot, err
 		Revision: revision,
 		Leases:   leases,
 		Stats:    leases.Stats(),
+		Queue:    s.queue(resource, leases, now),
 	}, nil
 }
 
@@ -330,9 +360,6 @@ func (s *Server) acquireHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: When the matching policy set dictates consumption of more than
-	// one resource, produce a lease for each one.
-
 	// Determine what resource the lease should be issued for
 	if resource := policies.Resource(); resource != req.Resource {
 		if req.Resource != "" {
@@ -362,16 +389,64 @@ func (s *Server) acquireHandler(w http.ResponseWriter, r *http.Request) {
 
 	printf(s.Logger, "%s: Lease acquisition requested\n", prefix)
 
-	ls, snapshot, err := s.acquire(req.Subject, props, policies)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+	var response transport.AcquireResponse
+
+	if rev, revoked := s.revocations.Take(req.Instance, time.Now()); revoked {
+		// An administrator has already released or deleted the lease
+		msg := rev.Message()
+		printf(s.Logger, "%s: Lease refused: %s\n", prefix, msg)
+		response = s.refused(req, props, policies, msg)
+	} else if msg := s.refusal(req.Subject, policies, time.Now()); msg != "" {
+		// The consumer has exhausted its session or its quota, so any lease
+		// it holds is released
+		printf(s.Logger, "%s: Lease refused: %s\n", prefix, msg)
+		if err := s.release(req.Subject, policies); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response = s.refused(req, props, policies, msg)
+	} else if resources := policies.Resources(); len(resources) > 1 {
+		// The matching policies dictate consumption of more than one
+		// resource, so a lease is produced for each one
+		ls, components, snapshots, err := s.acquireAll(req.Subject, props, policies, resources)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-	response := transport.AcquireResponse{
-		Request: req,
-		Lease:   ls,
-		Leases:  snapshot.Leases,
+		response = transport.AcquireResponse{
+			Request: req,
+			Lease:   ls,
+			Leases:  snapshots[0].Leases,
+		}
+		var messages []string
+		for i := range components {
+			response.Components = append(response.Components, transport.Component{
+				Lease:    components[i],
+				Snapshot: snapshots[i],
+			})
+			if msg := queuedMessage(components[i], snapshots[i].Leases, time.Now()); msg != "" {
+				messages = append(messages, msg)
+			}
+			if response.Queue == nil && components[i].Status == lease.Queued {
+				response.Queue = position(snapshots[i].Queue, req.Instance)
+			}
+		}
+		response.Message = strings.Join(messages, "\n")
+	} else {
+		ls, snapshot, err := s.acquire(req.Subject, props, policies)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response = transport.AcquireResponse{
+			Request: req,
+			Lease:   ls,
+			Leases:  snapshot.Leases,
+			Queue:   position(snapshot.Queue, req.Instance),
+			Message: queuedMessage(ls, snapshot.Leases, time.Now()),
+		}
 	}
 
 	data, err := json.Marshal(response)
@@ -383,7 +458,7 @@ func (s *Server) acquireHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	fmt.Fprintf(w, string(data))
+	w.Write(data)
 }
 
 // acquireHandler will attempt to acquire a lease for the specified resource.
@@ -392,12 +467,11 @@ func (s *Server) acquire(subject lease.Subject, props lease.Properties, policies
 
 	strat := policies.Strategy()
 	limit := policies.Limit()
-	duration := policies.Duration()
-	decay := policies.Decay()
-	refresh := policies.Refresh()
 
 	mode := "Creation" // Only used for logging
 
+	var previous lease.Lease // The lease that was replaced, if any
+
 	for attempt := 0; attempt < 5; attempt++ {
 		var revision uint64
 		var leases lease.Set
@@ -409,43 +483,44 @@ func (s *Server) acquire(subject lease.Subject, props lease.Properties, policies
 		}
 		now := time.Now()
 
-		ls = lease.Lease{
-			Subject:    subject,
-			Started:    now,
-			Renewed:    now,
-			Strategy:   strat,
-			Limit:      limit,
-			Duration:   duration,
-			Decay:      decay,
-			Refresh:    refresh,
-			Properties: props,
-		}
-
-		if ls.Refresh.Active != 0 {
-			if ls.Duration <= ls.Refresh.Active {
-				printf(s.Logger, "%s: The lease policy specified an active refresh interval of %s for a lease with a duration of %s. The refresh interval will be overridden.\n", prefix, ls.Refresh.Active.String(), ls.Duration.String())
-				ls.Refresh.Active = 0 // Use the default refresh rate instead of nonsense
-			}
-		}
-		if ls.Refresh.Queued != 0 {
-			if ls.Duration <= ls.Refresh.Queued {
-				printf(s.Logger, "%s: The lease policy specified a queued refresh interval of %s for a lease with a duration of %s. The refresh interval will be overridden.\n", prefix, ls.Refresh.Queued.String(), ls.Duration.String())
-				ls.Refresh.Queued = 0 // Use the default refresh rate instead of nonsense
-			}
-		}
+		ls = s.newLease(subject, props, policies, now)
 
 		tx := lease.NewTx(subject.Resource, revision, leases)
 
 		acc := leaseutil.Refresh(tx, now)
 		consumed := acc.Total(strat)
-		released := acc.Released(subject.HostUser())
+		released := acc.Released(ls.ConsumerKey())
 
 		existing, found := tx.Instance(subject.Instance)
+		previous = existing
+		if !found && strat == strategy.Consumer {
+			// A consumer that has borrowed the resource may use it freely
+			// until the borrowed lease is returned or expires. This only
+			// holds when consumers are counted, otherwise each instance
+			// consumes units of its own and is leased like any other.
+			if borrowed := tx.Consumer(ls.ConsumerKey()).Status(lease.Borrowed); len(borrowed) > 0 {
+				existing, found = borrowed[0], true
+			}
+		}
 		if found {
-			if existing.Status == lease.Released {
+			if existing.Status == lease.Borrowed && existing.Instance != subject.Instance {
+				// Other instances of the consumer share the borrowed lease.
+				// Each is given a lease of its own that isn't recorded and
+				// expires no later than the borrowed lease.
+				mode = "Sharing"
+				ls.Status = lease.Borrowed
+				ls.Started = existing.Started
+				if expiration := existing.ExpirationTime(); expiration.Before(ls.ExpirationTime()) {
+					ls.Duration = expiration.Sub(now)
+				}
+			} else if existing.Status == lease.Borrowed {
+				// Borrowed leases are left untouched until they're returned
+				mode = "Renewal"
+				ls = existing
+			} else if existing.Status == lease.Released {
 				// Renewal of a released lease, possibly because of timing skew
 				// Because the lease has expired we treat this as a creation
-				if consumed <= limit {
+				if renewedTotal(strat, consumed, existing, ls) <= leaseutil.Limit(acc, ls) && leaseutil.SubLimit(acc, ls) == "" {
 					ls.Status = lease.Active
 				} else {
 					ls.Status = lease.Queued
@@ -456,13 +531,14 @@ func (s *Server) acquire(subject lease.Subject, props lease.Properties, policies
 				mode = "Renewal"
 				ls.Status = existing.Status
 				ls.Started = existing.Started
+				ls.Preempt = existing.Preempt
 				tx.Update(existing.Instance, ls)
 			}
 		} else {
-			if released > 0 && consumed <= limit {
+			if released > 0 && acc.TotalAfterReplacement(ls.ConsumerKey(), ls.Weight(), strat) <= leaseutil.Limit(acc, ls) && leaseutil.SubLimit(acc, ls) == "" {
 				// Lease replacement (for an expired or released lease previously
 				// issued to the the same consumer, that's in a decaying state)
-				replaceable := tx.HostUser(subject.Instance.Host, subject.Instance.User).Status(lease.Released)
+				replaceable := tx.Consumer(ls.ConsumerKey()).Status(lease.Released)
 				if uint(len(replaceable)) != released {
 					panic("server: acquireHandler: accumulator returned a different count for relased leases than the transaction")
 				}
@@ -471,7 +547,7 @@ func (s *Server) acquire(subject lease.Subject, props lease.Properties, policies
 				tx.Update(replaced.Instance, ls)
 			} else {
 				// New lease
-				if leaseutil.CanActivate(strat, acc.Active(subject.HostUser()), consumed, limit) {
+				if leaseutil.CanActivate(strat, acc.Active(ls.ConsumerKey()), consumed, ls.Weight(), leaseutil.Limit(acc, ls)) && leaseutil.SubLimit(acc, ls) == "" {
 					ls.Status = lease.Active
 				} else {
 					ls.Status = lease.Queued
@@ -485,6 +561,7 @@ func (s *Server) acquire(subject lease.Subject, props lease.Properties, policies
 		snapshot.Revision = tx.Revision()
 		snapshot.Leases = tx.Leases()
 		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(subject.Resource, snapshot.Leases, now)
 
 		// Don't bother committing empty transactions
 		if tx.Empty() {
@@ -504,14 +581,177 @@ func (s *Server) acquire(subject lease.Subject, props lease.Properties, policies
 		return
 	}
 
+	s.usage.Observe(subject.Resource, snapshot.Leases, time.Now())
+
 	summary := statsSummary(limit, snapshot.Stats, strat)
 	printf(s.Logger, "%s: %s of %s lease succeeded (%s)\n", prefix, mode, ls.Status, summary)
 
 	s.publishLeaseUpdate(snapshot, summary)
 
+	if previous.Composite() {
+		// The lease was previously acquired together with other resources
+		// that the current policies no longer require
+		s.releaseComponents(subject.Instance, previous.Components, subject.Resource)
+	}
+
 	return
 }
 
+// renewedTotal returns the number of units that will be consumed if a
+// released lease is renewed as the given lease.
+func renewedTotal(strat strategy.Strategy, consumed uint, released, renewed lease.Lease) uint {
+	if strat == strategy.Consumer {
+		// Consumers are only counted once
+		return consumed
+	}
+	return consumed - released.Weight() + renewed.Weight()
+}
+
+// refused returns a response that refuses the lease requested by req with
+// the given explanation.
+func (s *Server) refused(req transport.Request, props lease.Properties, policies policy.Set, msg string) transport.AcquireResponse {
+	now := time.Now()
+	ls := s.newLease(req.Subject, props, policies, now)
+	ls.Status = lease.Released
+	ls.Released = now
+
+	return transport.AcquireResponse{
+		Request: req,
+		Lease:   ls,
+		Message: msg,
+	}
+}
+
+// refusal returns an explanation if subject has reached the maximum session
+// length or exhausted the daily quota of any of the resources required by
+// policies. It returns an empty string if the subject may acquire a lease.
+func (s *Server) refusal(subject lease.Subject, policies policy.Set, now time.Time) string {
+	for _, resource := range policies.Resources() {
+		pols := componentPolicies(policies, resource)
+
+		if quota := pols.DailyQuota(); quota > 0 {
+			if used := s.usage.Used(resource, subject.Instance.User, now); used >= quota {
+				return fmt.Sprintf("The daily quota of %s for %s has been exhausted.", quota, resource)
+			}
+		}
+
+		if pols.MaxSession() > 0 {
+			_, leases, err := s.LeaseProvider.LeaseView(resource)
+			if err != nil {
+				continue
+			}
+			if existing, found := leases.Instance(resource, subject.Instance); found && existing.SessionEnded(now) {
+				return fmt.Sprintf("The maximum session length of %s for %s has been reached.", existing.MaxSession, resource)
+			}
+		}
+	}
+	return ""
+}
+
+// queue returns the position of each queued lease within leases, which must
+// be a sorted set of leases for the resource.
+func (s *Server) queue(resource string, leases lease.Set, now time.Time) []lease.Position {
+	return leases.Queue(now, s.sessions.Typical(resource))
+}
+
+// position returns the position of instance within queue, or nil if the
+// instance is not queued.
+func position(queue []lease.Position, instance lease.Instance) *lease.Position {
+	for i := range queue {
+		if queue[i].Instance == instance {
+			pos := queue[i]
+			return &pos
+		}
+	}
+	return nil
+}
+
+// queuedMessage returns an explanation for a queued lease when the lease was
+// queued only because of a reservation, a booking or a per-user or per-host
+// limit. It returns an empty string in all other cases.
+func queuedMessage(ls lease.Lease, leases lease.Set, at time.Time) string {
+	if ls.Status != lease.Queued {
+		return ""
+	}
+
+	acc := leaseutil.NewAccumulator()
+	for _, other := range leases {
+		if other.Status == lease.Booked && other.Booking.Open(at) {
+			acc.AddBooking(other)
+		}
+	}
+	for _, other := range leases {
+		if other.Instance != ls.Instance {
+			acc.Add(other)
+		}
+	}
+
+	active, consumed := acc.Active(ls.ConsumerKey()), acc.Total(ls.Strategy)
+	if !leaseutil.CanActivate(ls.Strategy, active, consumed, ls.Weight(), ls.Limit) {
+		return ""
+	}
+
+	if !leaseutil.CanActivate(ls.Strategy, active, consumed, ls.Weight(), leaseutil.Limit(acc, ls)) {
+		if acc.Booked(ls.Instance, ls.Strategy) > 0 {
+			return fmt.Sprintf("The lease for %s is queued because the remaining units are booked for other users.", ls.Resource)
+		}
+		return fmt.Sprintf("The lease for %s is queued because the remaining units are reserved for other groups.", ls.Resource)
+	}
+
+	reason := leaseutil.SubLimit(acc, ls)
+	if reason == "" {
+		return ""
+	}
+	return fmt.Sprintf("The lease for %s is queued because %s.", ls.Resource, reason)
+}
+
+// newLease returns a new lease for the given subject and properties,
+// according to policies.
+func (s *Server) newLease(subject lease.Subject, props lease.Properties, policies policy.Set, now time.Time) lease.Lease {
+	prefix := subject.String()
+
+	ls := lease.Lease{
+		Subject:    subject,
+		Started:    now,
+		Renewed:    now,
+		Strategy:   policies.Strategy(),
+		Limit:      policies.Limit(),
+		Units:      policies.Units(),
+		MaxSession: policies.MaxSession(),
+		MaxPerUser: policies.MaxPerUser(),
+		MaxPerHost: policies.MaxPerHost(),
+		Grace:      policies.Grace(),
+		Priority:   policies.Priority(),
+		Preemption: policies.Preemption(),
+		Duration:   policies.Duration(),
+		Decay:      policies.Decay(),
+		Refresh:    policies.Refresh(),
+		Consumer:   policies.Consumer(),
+		Properties: props,
+		PolicyHash: policies.Hash().String(),
+	}
+
+	if reservations := policies.Reservations(); len(reservations) > 0 {
+		ls.Reservations = reservations.Leased()
+		ls.Reservation = reservations.Match(props)
+	}
+
+	if ls.Refresh.Active != 0 {
+		if ls.Duration <= ls.Refresh.Active {
+			printf(s.Logger, "%s: The lease policy specified an active refresh interval of %s for a lease with a duration of %s. The refresh interval will be overridden.\n", prefix, ls.Refresh.Active.String(), ls.Duration.String())
+			ls.Refresh.Active = 0 // Use the default refresh rate instead of nonsense
+		}
+	}
+	if ls.Refresh.Queued != 0 {
+		if ls.Duration <= ls.Refresh.Queued {
+			printf(s.Logger, "%s: The lease policy specified a queued refresh interval of %s for a lease with a duration of %s. The refresh interval will be overridden.\n", prefix, ls.Refresh.Queued.String(), ls.Duration.String())
+			ls.Refresh.Queued = 0 // Use the default refresh rate instead of nonsense
+		}
+	}
+
+	return ls
+}
+
 // releaseHandler will attempt to remove the lease for the given resource and
 // consumer.
 func (s *Server) releaseHandler(w http.ResponseWriter, r *http.Request) {
@@ -546,12 +786,19 @@ func (s *Server) releaseHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	fmt.Fprintf(w, string(data))
+	w.Write(data)
 }
 
 func (s *Server) release(subject lease.Subject, policies policy.Set) (err error) {
 	prefix := subject.String()
 
+	// Composite leases are released in their entirety
+	if _, leases, viewErr := s.LeaseProvider.LeaseView(subject.Resource); viewErr == nil {
+		if ls, found := leases.Instance(subject.Resource, subject.Instance); found && ls.Composite() {
+			return s.releaseComponents(subject.Instance, ls.Components, "")
+		}
+	}
+
 	strat := policies.Strategy()
 	limit := policies.Limit()
 
@@ -573,7 +820,10 @@ func (s *Server) release(subject lease.Subject, policies policy.Set) (err error)
 		tx := lease.NewTx(subject.Resource, revision, leases)
 		leaseutil.Refresh(tx, now) // Update stale values
 		ls, found = tx.Instance(subject.Instance)
-		tx.Release(subject.Instance, now)
+		if !found || ls.Status != lease.Borrowed {
+			// Borrowed leases can only be given back by returning them
+			tx.Release(subject.Instance, now)
+		}
 		leaseutil.Refresh(tx, now) // Updates leases after release
 
 		// Retain the snapshot even if this ends up being an empty transaction
@@ -581,6 +831,7 @@ func (s *Server) release(subject lease.Subject, policies policy.Set) (err error)
 		snapshot.Revision = tx.Revision()
 		snapshot.Leases = tx.Leases()
 		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(subject.Resource, snapshot.Leases, now)
 
 		// Don't bother committing empty transactions
 		if tx.Empty() {
@@ -590,6 +841,7 @@ func (s *Server) release(subject lease.Subject, policies policy.Set) (err error)
 		// Attempt to commit the transaction
 		err = s.LeaseProvider.LeaseCommit(tx)
 		if err == nil {
+			s.sessions.Record(tx, now)
 			break
 		}
 
@@ -602,9 +854,12 @@ func (s *Server) release(subject lease.Subject, policies policy.Set) (err error)
 
 	summary := statsSummary(limit, snapshot.Stats, strat)
 	if found {
-		if ls.Status == lease.Released {
+		switch ls.Status {
+		case lease.Borrowed:
+			printf(s.Logger, "%s: Release ignored because the lease is borrowed (%s)\n", prefix, summary)
+		case lease.Released:
 			printf(s.Logger, "%s: Release ignored because the lease had already been released (%s)\n", prefix, summary)
-		} else {
+		default:
 			printf(s.Logger, "%s: Release of %s lease succeeded (%s)\n", prefix, ls.Status, summary)
 		}
 	} else {
@@ -710,6 +965,18 @@ func (s *Server) refreshLeases() {
 		return
 	}
 
+	// Any policy retrieval errors have already been reported by
+	// collectResources
+	policies, _ := s.PolicyProvider.Policies()
+
+	// The refreshed leases of each resource, used to find composite leases
+	// with components that are no longer active
+	refreshed := make(map[string]lease.Set, len(resources))
+	view := func(resource string) (lease.Set, bool) {
+		leases, ok := refreshed[resource]
+		return leases, ok
+	}
+
 	for _, resource := range resources {
 		// Collect relevant leases from the lease provider
 		revision, leases, err := s.LeaseProvider.LeaseView(resource)
@@ -717,18 +984,31 @@ func (s *Server) refreshLeases() {
 			continue
 		}
 
-		// Purge expired leases
 		now := time.Now()
 		tx := lease.NewTx(resource, revision, leases)
+
+		// Apply the limits of any policy schedules that are in effect
+		if policies.HasSchedule() {
+			applySchedules(tx, policies, now)
+		}
+
+		// Purge expired leases
 		leaseutil.Refresh(tx, now)
 
+		// Record the time consumed by active leases
+		s.usage.Observe(resource, tx.Leases(), now)
+
 		// Move on to the next resource if there are no changes
 		if tx.Empty() {
+			refreshed[resource] = tx.Leases()
 			continue
 		}
 
 		// Make a best effort to commit changes
-		s.LeaseProvider.LeaseCommit(tx)
+		if s.LeaseProvider.LeaseCommit(tx) == nil {
+			s.sessions.Record(tx, now)
+			refreshed[resource] = tx.Leases()
+		}
 
 		// Publish the cleaned-up set of leases to all listeners
 		leases = tx.Leases()
@@ -738,11 +1018,46 @@ func (s *Server) refreshLeases() {
 			Revision: revision,
 			Leases:   leases,
 			Stats:    leases.Stats(),
+			Queue:    s.queue(resource, leases, now),
 		}
 
 		s.publishLeaseUpdate(snapshot, resource)
 	}
 
+	// The components of a composite lease are a unit, so when one of them is
+	// preempted or released the others follow
+	for _, resource := range resources {
+		for _, ls := range refreshed[resource] {
+			if ls.Status == lease.Active && ls.Composite() && componentStatus(ls, view) != lease.Active {
+				s.demoteComponents(resource)
+				break
+			}
+		}
+	}
+}
+
+// applySchedules updates the limit of each unreleased lease in tx to match
+// the policy schedules in effect at the given time.
+//
+// The durations of existing leases are not changed. New durations take
+// effect when the leases are renewed.
+func applySchedules(tx *lease.Tx, policies policy.Set, at time.Time) {
+	tx.Process(func(iter *lease.Iter) {
+		if iter.Status == lease.Released || iter.Status == lease.Booked {
+			return
+		}
+		matched := componentPolicies(policies.Match(iter.Properties), iter.Resource)
+		if !matched.HasSchedule() {
+			return
+		}
+		matched = matched.Scheduled(at)
+		limit, grace := matched.Limit(), matched.Grace()
+		if iter.Limit != limit || iter.Grace != grace {
+			iter.Limit = limit
+			iter.Grace = grace
+			iter.Update()
+		}
+	})
 }
 
 // publishLeaseUpdate will attempt to publish an updated set of leases to
@@ -758,6 +1073,32 @@ func (s *Server) publishLeaseUpdate(snapshot lease.Snapshot, summary string) {
 	}()
 }
 
+// publishRevocation will attempt to tell stream and revocation listeners
+// that leases have been revoked, so that the instances holding them can be
+// stopped without waiting for their next renewal.
+func (s *Server) publishRevocation(revocation transport.RevocationEvent) {
+	go func() {
+		evt, err := makeRevocationEvent(revocation)
+		if err != nil {
+			printf(s.Logger, "stream: failed to publish revocation by %s: %v\n", revocation.Operator, err)
+			return
+		}
+		s.Stream.Broadcast(evt)
+		s.revoked.Broadcast(evt)
+	}()
+}
+
+// PublishPolicies will attempt to publish an updated set of policies to
+// stream listeners.
+func (s *Server) PublishPolicies(policies policy.Set) {
+	evt, err := makePoliciesEvent(policies)
+	if err != nil {
+		printf(s.Logger, "stream: failed to publish policy update: %v\n", err)
+		return
+	}
+	s.Stream.Broadcast(evt)
+}
+
 func (s *Server) initRequest(r *http.Request) (req transport.Request, policies policy.Set, err error) {
 	req, err = parseRequest(r)
 	if err != nil {
@@ -776,7 +1117,7 @@ func (s *Server) initRequest(r *http.Request) (req transport.Request, policies p
 		return
 	}
 
-	return req, policies.Match(req.Properties), nil
+	return req, policies.Match(req.Properties).Scheduled(time.Now()), nil
 }
 
 func parseRequest(r *http.Request) (req transport.Request, err error) {
@@ -831,6 +1172,16 @@ func makeLeasesEvent(snapshot lease.Snapshot) (*eventsource.Event, error) {
 	return evt, nil
 }
 
+func makeRevocationEvent(revocation transport.RevocationEvent) (*eventsource.Event, error) {
+	evt := eventsource.TypeEvent("revocation")
+	enc := json.NewEncoder(evt)
+	err := enc.Encode(revocation)
+	if err != nil {
+		return nil, err
+	}
+	return evt, nil
+}
+
 func statsSummary(limit uint, stats lease.Stats, strat strategy.Strategy) string {
 	consumed := stats.Consumed(strat)
 	active := stats.Active(strat)
@@ -842,7 +1193,20 @@ func statsSummary(limit uint, stats lease.Stats, strat strategy.Strategy) string
 	} else {
 		limitStr = strconv.FormatUint(uint64(limit), 10)
 	}
-	return fmt.Sprintf("alloc: %d/%s, active: %d, released: %d, queued: %d", consumed, limitStr, active, released, queued)
+	summary := fmt.Sprintf("alloc: %d/%s, active: %d, released: %d, queued: %d", consumed, limitStr, active, released, queued)
+	if borrowed := stats.Borrowed(strat); borrowed > 0 {
+		summary += fmt.Sprintf(", borrowed: %d", borrowed)
+	}
+	if held := stats.Held(strat); held > 0 {
+		summary += fmt.Sprintf(", held: %d", held)
+	}
+	if booked := stats.Booked(strat); booked > 0 {
+		summary += fmt.Sprintf(", booked: %d", booked)
+	}
+	for _, r := range stats.Reservations {
+		summary += fmt.Sprintf(", %s: %d/%d reserved", r.Name, r.Consumed(strat), r.Units)
+	}
+	return summary
 }
 
 func printf(logger *log.Logger, format string, v ...interface{}) {
@@ -0,0 +1,76 @@
+package guardian
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// sessionSamples is the number of recent sessions that contribute
+// significantly to the typical session length of a resource.
+const sessionSamples = 20
+
+// sessionTracker maintains a moving average of the length of the sessions
+// that have ended for each resource. It is used to estimate when active
+// leases will be released.
+//
+// The zero value is ready for use.
+type sessionTracker struct {
+	mutex   sync.Mutex
+	typical map[string]time.Duration // Moving average of session lengths for each resource
+	count   map[string]int           // Number of sessions recorded for each resource
+}
+
+// Record records the length of each session that was ended by the
+// transaction, which must already have been committed.
+func (t *sessionTracker) Record(tx *lease.Tx, at time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, op := range tx.Ops() {
+		if op.Previous.Status != lease.Active {
+			continue
+		}
+
+		var end time.Time
+		switch {
+		case op.Type == lease.Delete:
+			end = op.Previous.ExpirationTime()
+			if end.After(at) {
+				end = at
+			}
+		case op.Type == lease.Update && op.Lease.Status == lease.Released:
+			end = op.Lease.Released
+		default:
+			continue
+		}
+
+		length := end.Sub(op.Previous.Started)
+		if length <= 0 {
+			continue
+		}
+
+		if t.typical == nil {
+			t.typical = make(map[string]time.Duration)
+			t.count = make(map[string]int)
+		}
+
+		resource := tx.Resource()
+		n := t.count[resource]
+		if n < sessionSamples {
+			n++
+			t.count[resource] = n
+		}
+		t.typical[resource] += (length - t.typical[resource]) / time.Duration(n)
+	}
+}
+
+// Typical returns the typical session length for the resource, or zero if
+// no sessions have been recorded.
+func (t *sessionTracker) Typical(resource string) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.typical[resource]
+}
@@ -2,6 +2,7 @@
 package transport
 
 import (
+	"github.com/scjalliance/resourceful/audit"
 	"github.com/scjalliance/resourceful/lease"
 	"github.com/scjalliance/resourceful/policy"
 )
@@ -30,9 +31,18 @@ type LeasesResponse struct {
 // AcquireResponse reports the result of a resource acquisition attempt.
 type AcquireResponse struct {
 	Request
-	Lease   lease.Lease `json:"lease,omitempty"`
-	Leases  lease.Set   `json:"leases"`
-	Message string      `json:"message,omitempty"`
+	Lease      lease.Lease     `json:"lease,omitempty"`
+	Leases     lease.Set       `json:"leases"`
+	Components []Component     `json:"components,omitempty"`
+	Queue      *lease.Position `json:"queue,omitempty"` // Position of the lease within the queue, if it is queued
+	Message    string          `json:"message,omitempty"`
+}
+
+// Component reports the lease and lease snapshot for one of the resources
+// consumed by a multi-resource acquisition.
+type Component struct {
+	Lease    lease.Lease    `json:"lease"`
+	Snapshot lease.Snapshot `json:"snapshot"`
 }
 
 // ReleaseResponse reports the result of a resource release attempt.
@@ -42,9 +52,53 @@ type ReleaseResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
-// UpdateResponse represents a lease environment update request.
+// BorrowResponse reports the result of a lease borrowing attempt.
+type BorrowResponse struct {
+	Request
+	Lease   lease.Lease `json:"lease"`
+	Token   string      `json:"token,omitempty"` // Signed lease token
+	Message string      `json:"message,omitempty"`
+}
+
+// UpdateResponse reports the result of a lease property update attempt.
 type UpdateResponse struct {
 	Request
-	Success bool   `json:"success"`
-	Message string `json:"message,omitempty"`
+	Lease   lease.Lease     `json:"lease,omitempty"`
+	Leases  lease.Set       `json:"leases"`
+	Queue   *lease.Position `json:"queue,omitempty"` // Position of the lease within the queue, if it is queued
+	Success bool            `json:"success"`
+	Message string          `json:"message,omitempty"`
+}
+
+// HistoryResponse reports the lease history records that matched a query.
+type HistoryResponse struct {
+	Records []audit.Record `json:"records"`
+}
+
+// BookingsResponse reports the current set of booking placeholder leases.
+type BookingsResponse struct {
+	Bookings lease.Set `json:"bookings"`
+}
+
+// BookingResponse reports the result of a booking or cancellation attempt.
+type BookingResponse struct {
+	Booking lease.Lease `json:"booking"`
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+}
+
+// RevocationEvent announces the revocation of leases by an administrator to
+// stream listeners, so that the affected instances can stop immediately.
+type RevocationEvent struct {
+	Operator  string           `json:"operator"`  // Identity of the administrator that revoked the leases
+	Instances []lease.Instance `json:"instances"` // Instances whose leases were revoked
+	Message   string           `json:"message"`   // Explanation suitable for display to the holders
+}
+
+// AdminResponse reports the result of an administrative action.
+type AdminResponse struct {
+	Operator string    `json:"operator"` // Identity of the administrator that took the action
+	Leases   lease.Set `json:"leases"`   // Leases affected by the action
+	Success  bool      `json:"success"`
+	Message  string    `json:"message,omitempty"`
 }
@@ -0,0 +1,317 @@
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian/transport"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/lease/leaseutil"
+	"github.com/scjalliance/resourceful/policy"
+)
+
+var (
+	// errNotUpdatable is returned when a lease cannot be updated because it
+	// is neither active nor queued.
+	errNotUpdatable = errors.New("only active and queued leases can be updated")
+
+	// errCompositeUpdate is returned when an update involves a lease that
+	// consumes more than one resource. Such leases must be acquired again
+	// instead.
+	errCompositeUpdate = errors.New("leases that consume more than one resource cannot be updated")
+)
+
+// updateHandler will attempt to update the properties of an existing lease.
+//
+// The policies are evaluated against the new properties. If they dictate use
+// of the same resource as before, the terms and properties of the lease are
+// replaced. If they dictate use of a different resource, the lease is
+// transmuted into a lease for that resource. In either case the lease keeps
+// its start time, and with it its place in the queue.
+func (s *Server) updateHandler(w http.ResponseWriter, r *http.Request) {
+	req, policies, err := s.initRequest(r)
+	if err != nil {
+		printf(s.Logger, "Bad update request: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Resource == "" {
+		printf(s.Logger, "Bad update request: resource not specified\n")
+		http.Error(w, "resource must be specified", http.StatusBadRequest)
+		return
+	}
+
+	prefix := req.Subject.String()
+
+	printf(s.Logger, "%s: Lease update requested\n", prefix)
+
+	resources := policies.Resources()
+	switch {
+	case len(resources) == 0:
+		// The new properties no longer require a lease, so the existing
+		// lease is released
+		if err := s.release(req.Subject, policies); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Cache-Control", "max-age=300")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case len(resources) > 1:
+		http.Error(w, errCompositeUpdate.Error(), http.StatusConflict)
+		return
+	}
+
+	// Updates are not renewals, so a consumer that must be refused a lease
+	// is sent back to acquire one
+	if msg := s.refusal(req.Subject, policies, time.Now()); msg != "" {
+		printf(s.Logger, "%s: Lease update refused: %s\n", prefix, msg)
+		http.Error(w, msg, http.StatusConflict)
+		return
+	}
+
+	// Merge the client-provided properties with the policy-provided properties
+	props := lease.MergeProperties(req.Properties, policies.Properties())
+
+	ls, snapshot, err := s.update(req.Subject, props, policies)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err {
+		case errLeaseNotFound:
+			status = http.StatusNotFound
+		case errNotUpdatable, errCompositeUpdate:
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	req.Resource = ls.Resource
+
+	response := transport.UpdateResponse{
+		Request: req,
+		Lease:   ls,
+		Leases:  snapshot.Leases,
+		Queue:   position(snapshot.Queue, req.Instance),
+		Success: true,
+		Message: queuedMessage(ls, snapshot.Leases, time.Now()),
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		printf(s.Logger, "%s: Failed to marshal response: %v\n", prefix, err)
+		http.Error(w, "Failed to marshal response", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(data)
+}
+
+// update will attempt to update the properties of the lease held by subject.
+// The policies must dictate use of a single resource. If it differs from the
+// resource of the subject the lease is transmuted.
+func (s *Server) update(subject lease.Subject, props lease.Properties, policies policy.Set) (ls lease.Lease, snapshot lease.Snapshot, err error) {
+	if resource := policies.Resource(); resource != subject.Resource {
+		return s.transmute(subject, resource, props, policies)
+	}
+	return s.amend(subject, props, policies)
+}
+
+// amend will attempt to replace the properties and terms of the lease held
+// by subject without changing its resource.
+//
+// The lease keeps its status, start time and renewal time. Changes to its
+// terms might allow queued leases to be promoted, but they never cause the
+// lease itself to be queued.
+func (s *Server) amend(subject lease.Subject, props lease.Properties, policies policy.Set) (ls lease.Lease, snapshot lease.Snapshot, err error) {
+	prefix := subject.String()
+
+	strat := policies.Strategy()
+	limit := policies.Limit()
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision uint64
+		var leases lease.Set
+
+		revision, leases, err = s.LeaseProvider.LeaseView(subject.Resource)
+		if err != nil {
+			printf(s.Logger, "%s: Lease retrieval failed: %v\n", prefix, err)
+			continue
+		}
+		now := time.Now()
+
+		tx := lease.NewTx(subject.Resource, revision, leases)
+		leaseutil.Refresh(tx, now)
+
+		existing, found := tx.Instance(subject.Instance)
+		if err = updatable(existing, found); err != nil {
+			printf(s.Logger, "%s: Lease update refused: %v\n", prefix, err)
+			return
+		}
+
+		ls = s.newLease(subject, props, policies, now)
+		ls.Status = existing.Status
+		ls.Started = existing.Started
+		ls.Renewed = existing.Renewed
+		ls.Preempt = existing.Preempt
+		tx.Update(existing.Instance, ls)
+
+		leaseutil.Refresh(tx, now) // Apply the new terms
+		ls, _ = tx.Instance(subject.Instance)
+
+		// Retain the snapshot even if this ends up being an empty transaction
+		snapshot.Resource = tx.Resource()
+		snapshot.Revision = tx.Revision()
+		snapshot.Leases = tx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(subject.Resource, snapshot.Leases, now)
+
+		// Don't bother committing empty transactions
+		if tx.Empty() {
+			break
+		}
+
+		// Attempt to commit the transaction
+		err = s.LeaseProvider.LeaseCommit(tx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Lease update failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	s.usage.Observe(subject.Resource, snapshot.Leases, time.Now())
+
+	summary := statsSummary(limit, snapshot.Stats, strat)
+	printf(s.Logger, "%s: Update of %s lease succeeded (%s)\n", prefix, ls.Status, summary)
+
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return
+}
+
+// transmute will attempt to move the lease held by subject to a different
+// resource.
+//
+// The lease is deleted from its current resource and created for the new
+// one, and both transactions are committed atomically. An active lease
+// remains active if a unit of the new resource is available. Otherwise it is
+// queued. Because the lease keeps its start time it is queued ahead of the
+// leases that were requested after it.
+func (s *Server) transmute(subject lease.Subject, resource string, props lease.Properties, policies policy.Set) (ls lease.Lease, snapshot lease.Snapshot, err error) {
+	prefix := subject.String()
+
+	strat := policies.Strategy()
+	limit := policies.Limit()
+
+	target := subject
+	target.Resource = resource
+
+	var (
+		existing lease.Lease    // The lease that was moved
+		previous lease.Snapshot // Snapshot of the resource the lease was moved from
+	)
+
+	for attempt := 0; attempt < 5; attempt++ {
+		var revision, targetRevision uint64
+		var leases, targetLeases lease.Set
+
+		revision, leases, err = s.LeaseProvider.LeaseView(subject.Resource)
+		if err != nil {
+			printf(s.Logger, "%s: Lease retrieval failed: %v\n", prefix, err)
+			continue
+		}
+		targetRevision, targetLeases, err = s.LeaseProvider.LeaseView(resource)
+		if err != nil {
+			printf(s.Logger, "%s: Lease retrieval failed: %v\n", prefix, err)
+			continue
+		}
+		now := time.Now()
+
+		tx := lease.NewTx(subject.Resource, revision, leases)
+		leaseutil.Refresh(tx, now)
+
+		var found bool
+		existing, found = tx.Instance(subject.Instance)
+		if err = updatable(existing, found); err != nil {
+			printf(s.Logger, "%s: Lease transmutation refused: %v\n", prefix, err)
+			return
+		}
+
+		ls = s.newLease(target, props, policies, now)
+		ls.Status = lease.Queued
+		ls.Started = existing.Started
+		ls.Renewed = existing.Renewed
+
+		targetTx := lease.NewTx(resource, targetRevision, targetLeases)
+		acc := leaseutil.Refresh(targetTx, now)
+		if existing.Status == lease.Active {
+			if leaseutil.CanActivate(strat, acc.Active(ls.ConsumerKey()), acc.Total(strat), ls.Weight(), leaseutil.Limit(acc, ls)) && leaseutil.SubLimit(acc, ls) == "" {
+				ls.Status = lease.Active
+			}
+		}
+		targetTx.Create(ls)
+		leaseutil.Refresh(targetTx, now) // Promotes the lease if its place in the queue permits
+		ls, _ = targetTx.Instance(subject.Instance)
+
+		tx.Delete(existing.Instance)
+		leaseutil.Refresh(tx, now) // Promotes queued leases into the units given up
+
+		previous.Resource = tx.Resource()
+		previous.Revision = tx.Revision()
+		previous.Leases = tx.Leases()
+		previous.Stats = previous.Leases.Stats()
+		previous.Queue = s.queue(subject.Resource, previous.Leases, now)
+
+		snapshot.Resource = targetTx.Resource()
+		snapshot.Revision = targetTx.Revision()
+		snapshot.Leases = targetTx.Leases()
+		snapshot.Stats = snapshot.Leases.Stats()
+		snapshot.Queue = s.queue(resource, snapshot.Leases, now)
+
+		// Attempt to commit the transactions
+		err = s.LeaseProvider.LeaseCommitAll(tx, targetTx)
+		if err == nil {
+			break
+		}
+
+		printf(s.Logger, "%s: Lease transmutation failed: %v\n", prefix, err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	s.usage.Observe(subject.Resource, previous.Leases, time.Now())
+	s.usage.Observe(resource, snapshot.Leases, time.Now())
+
+	summary := statsSummary(limit, snapshot.Stats, strat)
+	printf(s.Logger, "%s: Transmutation of %s lease to %s succeeded (%s)\n", prefix, existing.Status, resource, summary)
+
+	s.publishLeaseUpdate(previous, statsSummary(existing.Limit, previous.Stats, existing.Strategy))
+	s.publishLeaseUpdate(snapshot, summary)
+
+	return
+}
+
+// updatable returns an error if ls cannot be updated.
+func updatable(ls lease.Lease, found bool) error {
+	switch {
+	case !found:
+		return errLeaseNotFound
+	case ls.Composite():
+		return errCompositeUpdate
+	case ls.Status != lease.Active && ls.Status != lease.Queued:
+		return errNotUpdatable
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,30 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/provider/memprov"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestAmendObservesUsage(t *testing.T) {
+	s := NewServer(ServerConfig{LeaseProvider: memprov.New()})
+	policies := policy.Set{policy.New("cad", strategy.Instance, 1, time.Minute, nil)}
+	subject := lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}}
+
+	if _, _, err := s.acquire(subject, lease.Properties{"program.name": "cad.exe"}, policies); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, _, err := s.amend(subject, lease.Properties{"program.name": "cad.exe", "program.version": "2"}, policies); err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+
+	if used := s.usage.Used("cad", "jdoe", time.Now()); used < 10*time.Millisecond {
+		t.Errorf("got %s of usage, want at least 10ms", used)
+	}
+}
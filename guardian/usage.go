@@ -0,0 +1,88 @@
+package guardian
+
+import (
+	"sync"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// usageKey identifies a user of a resource.
+type usageKey struct {
+	Resource string
+	User     string
+}
+
+// usageTracker accumulates the amount of time that each user has held active
+// leases for each resource during the current day. The day is determined by
+// the guardian's local time zone.
+//
+// Usage is observed whenever the guardian examines a resource's leases and is
+// held in memory. It is lost when the guardian restarts.
+//
+// The zero value is ready for use.
+type usageTracker struct {
+	mutex sync.Mutex
+	day   time.Time                               // Midnight at the start of the current day
+	used  map[usageKey]time.Duration              // Active time for each user of each resource
+	seen  map[string]map[lease.Instance]time.Time // Last observation of each active lease for each resource
+}
+
+// Observe records the usage of the active leases within the given set of
+// leases for a resource.
+func (u *usageTracker) Observe(resource string, leases lease.Set, at time.Time) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.rollover(at)
+
+	last := u.seen[resource]
+	next := make(map[lease.Instance]time.Time, len(last))
+	for _, ls := range leases {
+		if ls.Status != lease.Active || ls.Resource != resource {
+			continue
+		}
+		if previous, ok := last[ls.Instance]; ok {
+			if previous.Before(u.day) {
+				previous = u.day
+			}
+			if elapsed := at.Sub(previous); elapsed > 0 {
+				u.used[usageKey{Resource: resource, User: ls.Instance.User}] += elapsed
+			}
+		}
+		next[ls.Instance] = at
+	}
+
+	if len(next) > 0 {
+		u.seen[resource] = next
+	} else {
+		delete(u.seen, resource)
+	}
+}
+
+// Used returns the amount of time that the user has held active leases for
+// the resource during the current day.
+func (u *usageTracker) Used(resource, user string, at time.Time) time.Duration {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.rollover(at)
+
+	return u.used[usageKey{Resource: resource, User: user}]
+}
+
+// rollover resets the accumulated usage when a new day has started.
+//
+// rollover assumes that a lock is held for the duration of the call.
+func (u *usageTracker) rollover(at time.Time) {
+	y, m, d := at.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, at.Location())
+	if u.used != nil && day.Equal(u.day) {
+		return
+	}
+	u.day = day
+	u.used = make(map[usageKey]time.Duration)
+	if u.seen == nil {
+		u.seen = make(map[string]map[lease.Instance]time.Time)
+	}
+}
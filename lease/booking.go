@@ -0,0 +1,126 @@
+package lease
+
+import (
+	"strings"
+	"time"
+)
+
+// BookingPrefix is the prefix of the instance identifier of each booking
+// placeholder lease.
+const BookingPrefix = "booking:"
+
+// Booking is a number of units of a resource that have been set aside for a
+// group of users or hosts during a window of time.
+//
+// A booking is held by a placeholder lease with a status of Booked. While
+// the booking is open its units count against the limit of the resource and
+// can only be used by the users and hosts named by the booking.
+type Booking struct {
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Users []string  `json:"users,omitempty"` // Users permitted to use the booked units
+	Hosts []string  `json:"hosts,omitempty"` // Hosts permitted to use the booked units
+	Note  string    `json:"note,omitempty"`  // Description of the booking, such as "Training session"
+}
+
+// NewBooking returns a placeholder lease that holds the given number of
+// units of resource for a booking.
+func NewBooking(resource string, units uint, booking Booking) Lease {
+	return Lease{
+		Subject: Subject{
+			Resource: resource,
+			Instance: Instance{ID: BookingPrefix + booking.ID},
+		},
+		Properties: Properties{},
+		Status:     Booked,
+		Units:      units,
+		Consumer:   "{instance.id}", // Each booking is its own consumer
+		Booking:    &booking,
+	}
+}
+
+// Open returns true if the booking is in effect at the given time.
+func (b *Booking) Open(at time.Time) bool {
+	return b != nil && !at.Before(b.Start) && at.Before(b.End)
+}
+
+// Ended returns true if the booking has ended at the given time.
+func (b *Booking) Ended(at time.Time) bool {
+	return b == nil || !at.Before(b.End)
+}
+
+// Overlaps returns true if the booking is in effect at any time within the
+// window between start and end.
+func (b *Booking) Overlaps(start, end time.Time) bool {
+	return b != nil && b.Start.Before(end) && start.Before(b.End)
+}
+
+// Admits returns true if the booking permits instance to use its units.
+// Users and hosts are compared without regard to case.
+func (b *Booking) Admits(instance Instance) bool {
+	if b == nil {
+		return false
+	}
+	for _, user := range b.Users {
+		if strings.EqualFold(user, instance.User) {
+			return true
+		}
+	}
+	for _, host := range b.Hosts {
+		if strings.EqualFold(host, instance.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy of the booking. It returns nil if b is nil.
+func (b *Booking) Clone() *Booking {
+	if b == nil {
+		return nil
+	}
+	clone := *b
+	if b.Users != nil {
+		clone.Users = append([]string(nil), b.Users...)
+	}
+	if b.Hosts != nil {
+		clone.Hosts = append([]string(nil), b.Hosts...)
+	}
+	return &clone
+}
+
+// Booked returns the greatest number of units that are booked at any one
+// time within the window between start and end by the placeholder leases in
+// the set.
+func (s Set) Booked(start, end time.Time) (units uint) {
+	var bookings []*Lease
+	for i := range s {
+		if s[i].Status == Booked && s[i].Booking.Overlaps(start, end) {
+			bookings = append(bookings, &s[i])
+		}
+	}
+
+	// The number of booked units can only increase when a booking starts,
+	// so it's sufficient to check the start of the window and the start of
+	// each booking within it.
+	check := func(at time.Time) {
+		var total uint
+		for _, ls := range bookings {
+			if ls.Booking.Open(at) {
+				total += ls.Weight()
+			}
+		}
+		if total > units {
+			units = total
+		}
+	}
+
+	check(start)
+	for _, ls := range bookings {
+		if ls.Booking.Start.After(start) {
+			check(ls.Booking.Start)
+		}
+	}
+	return
+}
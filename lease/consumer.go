@@ -0,0 +1,95 @@
+package lease
+
+import (
+	"errors"
+	"strings"
+)
+
+// ConsumerKey returns the key that identifies the consumer of a lease with
+// the given subject and properties, according to a consumer template.
+//
+// A consumer template is a string in which each {key} placeholder is
+// replaced with the value of the lease property with that key. The
+// placeholders {instance.host}, {instance.user}, {instance.id} and
+// {resource} refer to the subject of the lease unless a property with the
+// same key is present. For example, a template of "{user.account}" counts
+// each user account as a single consumer, regardless of its host.
+//
+// If the template is empty the host and user of the subject identify the
+// consumer. They also identify the consumer if a placeholder refers to a
+// property that is missing or empty, so that subjects lacking the property
+// aren't all counted as a single consumer.
+func ConsumerKey(template string, subject Subject, props Properties) string {
+	if template == "" {
+		return subject.HostUser()
+	}
+
+	var (
+		key  strings.Builder
+		rest = template
+	)
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			break
+		}
+		value := consumerValue(rest[open+1:open+end], subject, props)
+		if value == "" {
+			return subject.HostUser()
+		}
+		key.WriteString(rest[:open])
+		key.WriteString(value)
+		rest = rest[open+end+1:]
+	}
+	key.WriteString(rest)
+
+	return key.String()
+}
+
+// ValidateConsumerTemplate returns an error if the given consumer template is
+// malformed.
+func ValidateConsumerTemplate(template string) error {
+	depth := 0
+	for _, r := range template {
+		switch r {
+		case '{':
+			if depth > 0 {
+				return errors.New("consumer template contains a nested placeholder")
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				return errors.New("consumer template contains an unopened placeholder")
+			}
+			depth--
+		}
+	}
+	if depth > 0 {
+		return errors.New("consumer template contains an unclosed placeholder")
+	}
+	return nil
+}
+
+// consumerValue returns the value of the placeholder with the given key, or
+// an empty string if it has no value.
+func consumerValue(key string, subject Subject, props Properties) string {
+	if value := props[key]; value != "" {
+		return value
+	}
+	switch key {
+	case "instance.host":
+		return subject.Instance.Host
+	case "instance.user":
+		return subject.Instance.User
+	case "instance.id":
+		return subject.Instance.ID
+	case "resource":
+		return subject.Resource
+	default:
+		return ""
+	}
+}
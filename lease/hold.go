@@ -0,0 +1,46 @@
+package lease
+
+import "time"
+
+// HoldPrefix is the prefix of the instance identifier of each held lease.
+const HoldPrefix = "hold:"
+
+// Hold is a number of units of a resource that have been set aside by an
+// administrator, such as for a seat that must be kept free.
+//
+// A hold is described by a lease with a status of Held. Its units count
+// against the limit of the resource until the hold is removed or expires.
+type Hold struct {
+	ID       string    `json:"id"`
+	Operator string    `json:"operator"`       // Identity of the administrator that created the hold
+	Created  time.Time `json:"created"`        // Time at which the hold was created
+	Note     string    `json:"note,omitempty"` // Description of the hold, such as "Reserved for the build server"
+}
+
+// NewHold returns a held lease that holds the given number of units of
+// resource. If duration is zero the hold lasts until it is removed.
+func NewHold(resource string, units uint, duration time.Duration, hold Hold) Lease {
+	return Lease{
+		Subject: Subject{
+			Resource: resource,
+			Instance: Instance{ID: HoldPrefix + hold.ID},
+		},
+		Properties: Properties{},
+		Status:     Held,
+		Started:    hold.Created,
+		Renewed:    hold.Created,
+		Units:      units,
+		Duration:   duration,
+		Consumer:   "{instance.id}", // Each hold is its own consumer
+		Hold:       &hold,
+	}
+}
+
+// Clone returns a copy of the hold. It returns nil if h is nil.
+func (h *Hold) Clone() *Hold {
+	if h == nil {
+		return nil
+	}
+	clone := *h
+	return &clone
+}
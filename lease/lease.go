@@ -9,16 +9,31 @@ import (
 // Lease describes a single assignment of a leased resource.
 type Lease struct {
 	Subject
-	Properties Properties        `json:"properties"` // Properties of the lease
-	Status     Status            `json:"status"`
-	Started    time.Time         `json:"started,omitempty"`
-	Renewed    time.Time         `json:"renewed,omitempty"`
-	Released   time.Time         `json:"released,omitempty"`
-	Strategy   strategy.Strategy `json:"strategy,omitempty"`
-	Limit      uint              `json:"limit"`
-	Duration   time.Duration     `json:"duration"`
-	Decay      time.Duration     `json:"decay"`
-	Refresh    Refresh           `json:"refresh,omitempty"`
+	Properties   Properties        `json:"properties"` // Properties of the lease
+	Status       Status            `json:"status"`
+	Started      time.Time         `json:"started,omitempty"`
+	Renewed      time.Time         `json:"renewed,omitempty"`
+	Released     time.Time         `json:"released,omitempty"`
+	Strategy     strategy.Strategy `json:"strategy,omitempty"`
+	Limit        uint              `json:"limit"`
+	Units        uint              `json:"units,omitempty"` // Units of the resource consumed by the lease
+	Duration     time.Duration     `json:"duration"`
+	Decay        time.Duration     `json:"decay"`
+	Refresh      Refresh           `json:"refresh,omitempty"`
+	Consumer     string            `json:"consumer,omitempty"`     // Consumer template
+	Components   []string          `json:"components,omitempty"`   // Resources acquired together with this one, including itself
+	MaxPerUser   uint              `json:"max_per_user,omitempty"` // Max concurrent units for each user, or zero for no limit
+	MaxPerHost   uint              `json:"max_per_host,omitempty"` // Max concurrent units for each host, or zero for no limit
+	MaxSession   time.Duration     `json:"max_session,omitempty"`  // Time after which the lease is released, regardless of renewal
+	Reservations []Reservation     `json:"reservations,omitempty"` // Units of the resource reserved for groups of consumers
+	Reservation  string            `json:"reservation,omitempty"`  // Name of the reservation the lease belongs to, if any
+	Priority     int               `json:"priority,omitempty"`     // Queue priority, with higher values promoted first
+	Preemption   time.Duration     `json:"preemption,omitempty"`   // Warning period given to lower priority leases that are preempted by this one
+	Grace        time.Duration     `json:"grace,omitempty"`        // Time given to the lease before it is returned to the queue when it is above a reduced limit
+	Preempt      time.Time         `json:"preempt,omitempty"`      // Time at which the lease will be preempted by a higher priority lease
+	Booking      *Booking          `json:"booking,omitempty"`      // Booking held by a placeholder lease
+	Hold         *Hold             `json:"hold,omitempty"`         // Administrative hold described by a held lease
+	PolicyHash   string            `json:"policy_hash,omitempty"`  // Hash of the policies that issued the lease
 }
 
 // MatchResource returns true if the lease is for the given resource.
@@ -31,6 +46,18 @@ func (ls *Lease) MatchHostUser(resource, host, user string) (matched bool) {
 	return ls.Resource == resource && ls.Instance.Host == host && ls.Instance.User == user
 }
 
+// MatchConsumer returns true if the lease is for the given resource and
+// consumer key.
+func (ls *Lease) MatchConsumer(resource, consumer string) (matched bool) {
+	return ls.Resource == resource && ls.ConsumerKey() == consumer
+}
+
+// ConsumerKey returns the key that identifies the consumer of the lease,
+// according to its consumer template.
+func (ls *Lease) ConsumerKey() string {
+	return ConsumerKey(ls.Consumer, ls.Subject, ls.Properties)
+}
+
 // MatchInstance returns true if the lease is for the given resource and instance.
 func (ls *Lease) MatchInstance(resource string, inst Instance) (matched bool) {
 	return ls.Resource == resource && ls.Instance == inst
@@ -41,16 +68,46 @@ func (ls *Lease) MatchStatus(status Status) (matched bool) {
 	return ls.Status == status
 }
 
-// Consumptive returns true if the lease is active or released.
+// Consumptive returns true if the lease is active, borrowed, held or
+// released.
 func (ls *Lease) Consumptive() (matched bool) {
 	switch ls.Status {
-	case Active, Released:
+	case Active, Borrowed, Held, Released:
 		return true
 	default:
 		return false
 	}
 }
 
+// Preempted returns true if the lease is scheduled to be preempted by a
+// higher priority lease at or before the given time.
+func (ls *Lease) Preempted(at time.Time) bool {
+	return !ls.Preempt.IsZero() && !at.Before(ls.Preempt)
+}
+
+// SessionEnded returns true if the lease is active and subject to a maximum
+// session length that has been reached at the given time. Sessions are
+// measured from the time the lease was activated.
+func (ls *Lease) SessionEnded(at time.Time) bool {
+	return ls.Status == Active && ls.MaxSession > 0 && !at.Before(ls.Started.Add(ls.MaxSession))
+}
+
+// Weight returns the number of units of the resource consumed by the lease.
+// Leases that don't specify a number of units consume a single unit.
+func (ls *Lease) Weight() uint {
+	if ls.Units == 0 {
+		return 1
+	}
+	return ls.Units
+}
+
+// Composite returns true if the lease is one component of a multi-resource
+// lease. Composite leases are activated only when every component can be
+// activated.
+func (ls *Lease) Composite() bool {
+	return len(ls.Components) > 1
+}
+
 // ExpirationTime returns the time at which the lease expires.
 func (ls *Lease) ExpirationTime() time.Time {
 	return ls.Renewed.Add(ls.Duration)
@@ -127,8 +184,27 @@ func Clone(from Lease) (to Lease) {
 	to.Released = from.Released
 	to.Strategy = from.Strategy
 	to.Limit = from.Limit
+	to.Units = from.Units
 	to.Duration = from.Duration
 	to.Decay = from.Decay
 	to.Refresh = from.Refresh
+	to.Consumer = from.Consumer
+	to.MaxSession = from.MaxSession
+	to.MaxPerUser = from.MaxPerUser
+	to.MaxPerHost = from.MaxPerHost
+	to.Priority = from.Priority
+	to.Preemption = from.Preemption
+	to.Preempt = from.Preempt
+	to.Grace = from.Grace
+	if from.Reservations != nil {
+		to.Reservations = append([]Reservation(nil), from.Reservations...)
+	}
+	to.Reservation = from.Reservation
+	to.Booking = from.Booking.Clone()
+	to.Hold = from.Hold.Clone()
+	to.PolicyHash = from.PolicyHash
+	if from.Components != nil {
+		to.Components = append([]string(nil), from.Components...)
+	}
 	return
 }
@@ -0,0 +1,3 @@
+// Package leasetoken issues and verifies signed lease tokens, which allow a
+// borrowed lease to be trusted while the guardian cannot be reached.
+package leasetoken
@@ -0,0 +1,69 @@
+package leasetoken
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+var (
+	// ErrMalformed is returned when a token cannot be decoded.
+	ErrMalformed = errors.New("leasetoken: malformed token")
+
+	// ErrInvalidSignature is returned when the signature of a token does not
+	// match its lease.
+	ErrInvalidSignature = errors.New("leasetoken: invalid signature")
+)
+
+var encoding = base64.RawURLEncoding
+
+// Sign returns a token for ls that has been signed with key.
+//
+// A token is made up of the base64-encoded JSON representation of the lease
+// and the base64-encoded signature of that representation, separated by a
+// period.
+func Sign(ls lease.Lease, key ed25519.PrivateKey) (string, error) {
+	data, err := json.Marshal(ls)
+	if err != nil {
+		return "", fmt.Errorf("leasetoken: unable to marshal lease: %v", err)
+	}
+	payload := encoding.EncodeToString(data)
+	signature := ed25519.Sign(key, []byte(payload))
+	return payload + "." + encoding.EncodeToString(signature), nil
+}
+
+// Verify checks the signature of token with key and returns the lease it
+// holds.
+func Verify(token string, key ed25519.PublicKey) (ls lease.Lease, err error) {
+	if len(key) != ed25519.PublicKeySize {
+		return ls, errors.New("leasetoken: invalid public key")
+	}
+
+	payload, sig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok {
+		return ls, ErrMalformed
+	}
+
+	signature, err := encoding.DecodeString(sig)
+	if err != nil {
+		return ls, ErrMalformed
+	}
+	if !ed25519.Verify(key, []byte(payload), signature) {
+		return ls, ErrInvalidSignature
+	}
+
+	data, err := encoding.DecodeString(payload)
+	if err != nil {
+		return ls, ErrMalformed
+	}
+	if err = json.Unmarshal(data, &ls); err != nil {
+		return ls, ErrMalformed
+	}
+
+	return ls, nil
+}
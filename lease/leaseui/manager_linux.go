@@ -1,36 +1,83 @@
-//go:build !windows
-// +build !windows
-
 package leaseui
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+func call(callback Callback, t Type, result Result) {
+	if callback == nil {
+		return
+	}
+
+	callback(t, result, nil)
+}
 
 func (m *Manager) none(ctx context.Context, callback Callback) error {
+	m.mutex.Lock()
+	m.model = nil
+	m.mutex.Unlock()
+
 	<-ctx.Done()
-	callback(None, Success, nil)
+
+	call(callback, None, Success)
+
 	return nil
 }
 
 func (m *Manager) startup(ctx context.Context, callback Callback) error {
-	<-ctx.Done()
-	callback(Startup, Success, nil)
-	return nil
+	return m.status(ctx, Startup, callback)
 }
 
 func (m *Manager) queued(ctx context.Context, callback Callback) error {
-	<-ctx.Done()
-	callback(Queued, Success, nil)
-	return nil
+	return m.status(ctx, Queued, callback)
 }
 
 func (m *Manager) connected(ctx context.Context, callback Callback) error {
-	<-ctx.Done()
-	callback(Connected, Success, nil)
+	if !IsTerminal(os.Stderr) {
+		<-ctx.Done()
+		call(callback, Connected, ContextCancelled)
+		return nil
+	}
+
+	// There is nothing to count down once the connection has been restored,
+	// so the notice is printed once and dismissed immediately.
+	fmt.Fprintf(os.Stderr, "The connection to the server has been restored. Your work is no longer at risk.\n")
+
+	call(callback, Connected, Success)
+
 	return nil
 }
 
 func (m *Manager) disconnected(ctx context.Context, callback Callback) error {
+	return m.status(ctx, Disconnected, callback)
+}
+
+// status displays a live terminal status of type t until ctx is cancelled.
+//
+// If standard error is not a terminal, status blocks without displaying
+// anything.
+func (m *Manager) status(ctx context.Context, t Type, callback Callback) error {
+	if IsTerminal(os.Stderr) {
+		model := m.terminalModel(t)
+		defer model.Close()
+	}
+
 	<-ctx.Done()
-	callback(Disconnected, Success, nil)
+
+	call(callback, t, ContextCancelled)
+
 	return nil
 }
+
+func (m *Manager) terminalModel(t Type) (model *TerminalModel) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	model = NewTerminalModel(m.cfg, t, os.Stderr, m.state)
+	model.Update(m.state)
+	m.model = model
+
+	return
+}
@@ -0,0 +1,20 @@
+package leaseui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// waitString returns a sentence describing the estimated wait for a queued
+// lease. It returns an empty string if the wait could not be estimated.
+func waitString(pos *lease.Position) string {
+	if pos == nil || !pos.Estimated {
+		return ""
+	}
+	if pos.Wait < time.Minute {
+		return "A license is expected to become available in less than a minute."
+	}
+	return fmt.Sprintf("A license is expected to become available in about %s.", pos.Wait.Round(time.Minute))
+}
@@ -83,7 +83,11 @@ func (dlg *QueuedDialog) title() string {
 
 // description returns the description for the dialog.
 func (dlg *QueuedDialog) description() string {
-	return fmt.Sprintf("%s could not be started because %d of %d license(s) are in use.", dlg.model.ResourceName(), dlg.model.Consumed(), dlg.model.state.Lease.Limit)
+	description := fmt.Sprintf("%s could not be started because %d of %d license(s) are in use.", dlg.model.ResourceName(), dlg.model.Consumed(), dlg.model.state.Lease.Limit)
+	if wait := waitString(dlg.model.state.Queue); wait != "" {
+		description += " " + wait
+	}
+	return description
 }
 
 // tableCaption returns the caption for the table.
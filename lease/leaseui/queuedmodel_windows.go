@@ -120,7 +120,7 @@ func (m *QueuedModel) Value(row, col int) interface{} {
 	case 2:
 		return ls.Properties["host.name"]
 	case 3:
-		if ls.Status == lease.Released {
+		if ls.Status == lease.Released || ls.Status == lease.Booked {
 			return ""
 		}
 		started := ls.Started.Round(time.Second)
@@ -130,7 +130,7 @@ func (m *QueuedModel) Value(row, col int) interface{} {
 		if ls.Decay == 0 {
 			return ""
 		}
-		if ls.Instance.Host == m.Instance.Host && ls.Instance.User == m.Instance.User {
+		if ls.ConsumerKey() == m.consumer() {
 			// There is no decay period for leases belonging to the same consumer.
 			return ""
 		}
@@ -150,3 +150,12 @@ func (m *QueuedModel) Value(row, col int) interface{} {
 	}
 	return nil
 }
+
+// consumer returns the consumer key of our own lease.
+func (m *QueuedModel) consumer() string {
+	ls := m.state.Lease
+	if ls.Instance.Empty() {
+		ls.Subject = lease.Subject{Resource: ls.Resource, Instance: m.Instance}
+	}
+	return ls.ConsumerKey()
+}
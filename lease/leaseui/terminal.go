@@ -0,0 +1,12 @@
+package leaseui
+
+import "os"
+
+// IsTerminal returns true if f is a character device, such as a terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
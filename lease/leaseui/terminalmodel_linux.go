@@ -0,0 +1,297 @@
+package leaseui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+// TerminalModel is a view model that renders the lease user interface as a
+// live status block on a terminal.
+//
+// Each time the model is updated or refreshed, the previously rendered block
+// is erased and drawn again in place. Disconnected statuses are the
+// exception. They are only shown while the program is running and writing to
+// the same terminal, so they are printed as a single line each time the
+// status changes instead.
+type TerminalModel struct {
+	Config
+
+	mutex  sync.Mutex
+	t      Type
+	out    io.Writer
+	state  lease.State
+	lines  int    // The number of lines drawn by the last render
+	last   string // The last status line printed
+	closed bool
+}
+
+// NewTerminalModel returns a terminal view model for the given type of user
+// interface that renders to out.
+func NewTerminalModel(config Config, t Type, out io.Writer, state lease.State) *TerminalModel {
+	return &TerminalModel{
+		Config: config,
+		t:      t,
+		out:    out,
+		state:  state,
+	}
+}
+
+// Update will replace the current model's lease state with the one provided
+// and redraw the status.
+func (m *TerminalModel) Update(state lease.State) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.state = state
+
+	if m.closed {
+		return
+	}
+
+	m.render()
+}
+
+// Refresh will redraw the status so that its timers stay current.
+func (m *TerminalModel) Refresh() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	m.render()
+}
+
+// Close will erase the status and prevent the model from drawing it again.
+func (m *TerminalModel) Close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	m.erase()
+	m.closed = true
+}
+
+// ResourceName returns the user-friendly name of the resource.
+func (m *TerminalModel) ResourceName() string {
+	name := m.state.Lease.ResourceName()
+	if name != "" {
+		return name
+	}
+
+	return m.Program
+}
+
+// Consumed returns the current number of resources that have been consumed.
+func (m *TerminalModel) Consumed() uint {
+	strat := m.state.Lease.Strategy
+	if !strategy.Valid(strat) || strat == strategy.Empty {
+		strat = policy.DefaultStrategy
+	}
+	stats := m.state.Leases.Stats()
+	return stats.Consumed(strat)
+}
+
+// Position returns the one-based position of our lease within the queue,
+// along with the total number of queued leases. If our lease is not queued
+// position will be zero.
+func (m *TerminalModel) Position() (position, total int) {
+	queued := m.state.Leases.Status(lease.Queued)
+	for i := range queued {
+		if queued[i].Instance == m.Instance {
+			position = i + 1
+		}
+	}
+	return position, len(queued)
+}
+
+// TimeRemaining returns the time remaining until the current lease expires,
+// rounded to the nearest whole second.
+func (m *TerminalModel) TimeRemaining() (remaining time.Duration) {
+	now := time.Now().Round(time.Second)
+	expiration := m.state.Lease.ExpirationTime().Round(time.Second)
+	if now.After(expiration) {
+		return
+	}
+	return expiration.Sub(now)
+}
+
+// render erases the previous status and draws the current one.
+func (m *TerminalModel) render() {
+	if m.t == Disconnected {
+		// Redrawing in place would overwrite the program's output
+		if line := m.disconnectedLine(); line != m.last {
+			fmt.Fprintf(m.out, "%s\n", line)
+			m.last = line
+		}
+		return
+	}
+
+	var lines []string
+	switch m.t {
+	case Startup:
+		lines = m.startupLines()
+	case Queued:
+		lines = m.queuedLines()
+	}
+
+	m.erase()
+	for _, line := range lines {
+		fmt.Fprintf(m.out, "%s\n", line)
+	}
+	m.lines = len(lines)
+}
+
+// erase removes the previously drawn status from the terminal.
+func (m *TerminalModel) erase() {
+	if m.lines == 0 {
+		return
+	}
+	// Move the cursor to the start of the block and clear to the end of
+	// the screen.
+	fmt.Fprintf(m.out, "\x1b[%dA\x1b[J", m.lines)
+	m.lines = 0
+}
+
+func (m *TerminalModel) startupLines() []string {
+	lines := []string{
+		fmt.Sprintf("Unable to launch %s", m.Program),
+		fmt.Sprintf("%s could not be started because an active lease could not be acquired.", m.ResourceName()),
+		"This is probably due to a network or server failure.",
+		"It will be started automatically once the server can be contacted and a lease has been acquired.",
+	}
+	if err := m.state.Err; err != nil {
+		lines = append(lines, fmt.Sprintf("Error Details: %v", err))
+	}
+	return append(lines, "Press Ctrl+C to stop waiting.")
+}
+
+func (m *TerminalModel) queuedLines() []string {
+	lines := []string{
+		fmt.Sprintf("Unable to launch %s", m.Program),
+		fmt.Sprintf("%s could not be started because %d of %s license(s) are in use.", m.ResourceName(), m.Consumed(), limitString(m.state.Lease.Limit)),
+	}
+	if position, total := m.Position(); position > 0 {
+		lines = append(lines, fmt.Sprintf("You are number %d of %d in the queue.", position, total))
+	}
+	if wait := waitString(m.state.Queue); wait != "" {
+		lines = append(lines, wait)
+	}
+	lines = append(lines, "Here's a list of everyone that's using or waiting for a license right now:", "")
+	lines = append(lines, m.table()...)
+	return append(lines, "", "Press Ctrl+C to stop waiting.")
+}
+
+// disconnectedLine returns the status of a lease that could not be renewed.
+// It gives the time at which the lease expires rather than a countdown, so
+// that it only changes when the lease does.
+func (m *TerminalModel) disconnectedLine() string {
+	expiration := m.state.Lease.ExpirationTime().Round(time.Second).Local().Format("15:04:05")
+	return fmt.Sprintf("The lease for %s could not be renewed, probably due to a network or server failure. %s will forcibly be shut down when its lease expires at %s. Please save your work and close it before then.", m.ResourceName(), m.Program, expiration)
+}
+
+// table returns the current lease holders as a set of aligned lines.
+func (m *TerminalModel) table() []string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  STATUS\tUSER\tCOMPUTER\tTIME\tEXPIRES\tEARLIEST AVAILABILITY\n")
+
+	now := time.Now().Round(time.Second)
+	for _, ls := range m.state.Leases {
+		marker := " "
+		if ls.Instance == m.Instance {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\t%s\n", marker, ls.Status, userName(ls), ls.Properties["host.name"], elapsed(ls, now), expires(ls, now), m.availability(ls, now))
+	}
+	w.Flush()
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// availability returns the time at which the resource consumed by ls is
+// expected to become available.
+func (m *TerminalModel) availability(ls lease.Lease, now time.Time) string {
+	if ls.Decay == 0 {
+		return ""
+	}
+	if ls.ConsumerKey() == m.consumer() {
+		// There is no decay period for leases belonging to the same consumer.
+		return ""
+	}
+	switch ls.Status {
+	case lease.Active:
+		return ls.Decay.String()
+	case lease.Released:
+		available := ls.DecayTime().Round(time.Second)
+		if now.Before(available) {
+			return available.Sub(now).String()
+		}
+		return "now"
+	default:
+		return ""
+	}
+}
+
+// userName returns the most user-friendly name available for the holder of
+// ls.
+func userName(ls lease.Lease) string {
+	for _, key := range []string{"user.name", "user.account"} {
+		if name := ls.Properties[key]; name != "" {
+			return name
+		}
+	}
+	return ls.Instance.User
+}
+
+// elapsed returns the amount of time that has passed since ls was started.
+func elapsed(ls lease.Lease, now time.Time) string {
+	if ls.Status == lease.Released || ls.Status == lease.Booked {
+		return ""
+	}
+	started := ls.Started.Round(time.Second)
+	return now.Sub(started).String()
+}
+
+// expires returns the amount of time remaining until ls expires.
+func expires(ls lease.Lease, now time.Time) string {
+	if ls.Status != lease.Active && ls.Status != lease.Borrowed {
+		return ""
+	}
+	expiration := ls.ExpirationTime().Round(time.Second)
+	if now.After(expiration) {
+		return "expired"
+	}
+	return expiration.Sub(now).String()
+}
+
+// limitString returns a string representation of a lease limit.
+func limitString(limit uint) string {
+	if limit == policy.DefaultLimit {
+		return "∞"
+	}
+	return strconv.FormatUint(uint64(limit), 10)
+}
+
+// consumer returns the consumer key of our own lease.
+func (m *TerminalModel) consumer() string {
+	ls := m.state.Lease
+	if ls.Instance.Empty() {
+		ls.Subject = lease.Subject{Resource: ls.Resource, Instance: m.Instance}
+	}
+	return ls.ConsumerKey()
+}
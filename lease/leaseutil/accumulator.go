@@ -7,56 +7,144 @@ import (
 	"github.com/scjalliance/resourceful/strategy"
 )
 
-// Accumulator tracks the number of leases with each status. It is used
-// internally by the refresh function as it processes lease sets.
+// Accumulator tracks the number of units consumed by leases with each
+// status. It is used internally by the refresh function as it processes
+// lease sets.
 type Accumulator struct {
-	total        uint            // The total number of active or released leases
-	active       map[string]uint // The number of active leases for each consumer
-	released     map[string]uint // The number of released leases for each consumer
-	consumed     map[string]uint // The number of active or released leases for each consumer
-	replacements map[string]uint // The number of outstanding lease replacements for each consumer
+	total        uint              // The total number of units consumed by active or released leases
+	active       map[string]uint   // The number of active or borrowed units for each consumer
+	released     map[string][]uint // The units of each released lease for each consumer, in the order they were added
+	consumed     map[string]uint   // The number of active or released units for each consumer
+	weight       map[string]uint   // The units of the first active or released lease for each consumer
+	replacements map[string]uint   // The number of outstanding lease replacements for each consumer
+	users        holders           // The number of active units for each consumer of each user
+	hosts        holders           // The number of active units for each consumer on each host
+	reserved     holders           // The number of active or released units for each consumer within each reservation
+	bookings     []lease.Lease     // The placeholder leases of open bookings
+	booked       holders           // The number of active or released units for each consumer within each open booking
+}
+
+// holders tracks the number of active units for each consumer, grouped by
+// user or host.
+type holders map[string]map[string]uint
+
+func (h holders) add(holder, consumer string, units uint) {
+	consumers, ok := h[holder]
+	if !ok {
+		consumers = make(map[string]uint)
+		h[holder] = consumers
+	}
+	consumers[consumer] += units
+}
+
+func (h holders) sub(holder, consumer string, units uint) {
+	consumers, ok := h[holder]
+	if !ok {
+		return
+	}
+	if consumers[consumer] > units {
+		consumers[consumer] -= units
+	} else {
+		consumers[consumer] = 0
+	}
 }
 
 // NewAccumulator returns a new lease accumulator that tracks the number of
-// leases with each status.
+// units consumed by leases with each status.
 func NewAccumulator() *Accumulator {
 	return &Accumulator{
 		active:       make(map[string]uint),
-		released:     make(map[string]uint),
+		released:     make(map[string][]uint),
 		consumed:     make(map[string]uint),
+		weight:       make(map[string]uint),
 		replacements: make(map[string]uint),
+		users:        make(holders),
+		hosts:        make(holders),
+		reserved:     make(holders),
+		booked:       make(holders),
 	}
 }
 
-// Add will record a lease with the given status and consumer within the
-// accumulator.
-func (a *Accumulator) Add(consumer string, status lease.Status) {
-	switch status {
-	case lease.Active:
-		a.total++
-		a.active[consumer]++
-		a.consumed[consumer]++
+// Add will record the lease within the accumulator according to its status,
+// consumer and number of units.
+func (a *Accumulator) Add(ls lease.Lease) {
+	consumer, units := ls.ConsumerKey(), ls.Weight()
+	switch ls.Status {
+	case lease.Active, lease.Borrowed:
+		a.total += units
+		a.active[consumer] += units
+		a.users.add(ls.Instance.User, consumer, units)
+		a.hosts.add(ls.Instance.Host, consumer, units)
+	case lease.Held:
+		a.total += units
+		a.active[consumer] += units
 	case lease.Released:
-		a.total++
-		a.released[consumer]++
-		a.consumed[consumer]++
+		a.total += units
+		a.released[consumer] = append(a.released[consumer], units)
+	default:
+		return
+	}
+	if ls.Reservation != "" {
+		a.reserved.add(ls.Reservation, consumer, units)
+	}
+	if booking := a.booking(ls.Instance); booking != "" {
+		a.booked.add(booking, consumer, units)
+	}
+	a.consumed[consumer] += units
+	if _, ok := a.weight[consumer]; !ok {
+		a.weight[consumer] = units
 	}
 }
 
-// StartReplacement will record the start of a lease replacement for consumer.
+// StartReplacement will record the start of a lease replacement for the
+// consumer of ls. The most recently added released lease for the consumer
+// will be replaced by ls, which will become active.
 //
 // If there are no released leases available for replacement the function
 // will panic.
-func (a *Accumulator) StartReplacement(consumer string) {
-	if a.released[consumer] == 0 {
+func (a *Accumulator) StartReplacement(ls lease.Lease) {
+	consumer, units := ls.ConsumerKey(), ls.Weight()
+	released := a.released[consumer]
+	if len(released) == 0 {
 		panic(fmt.Errorf("leaseutil: accumulator: cannot start replacement lease for \"%s\" because no leases are replaceable", consumer))
 	}
+	replaced := released[len(released)-1]
 	a.replacements[consumer]++
-	a.active[consumer]++
-	a.released[consumer]--
-	if a.released[consumer] == 0 {
+	a.active[consumer] += units
+	a.users.add(ls.Instance.User, consumer, units)
+	a.hosts.add(ls.Instance.Host, consumer, units)
+	if ls.Reservation != "" {
+		a.reserved.sub(ls.Reservation, consumer, replaced)
+		a.reserved.add(ls.Reservation, consumer, units)
+	}
+	if booking := a.booking(ls.Instance); booking != "" {
+		a.booked.sub(booking, consumer, replaced)
+		a.booked.add(booking, consumer, units)
+	}
+	a.consumed[consumer] = a.consumed[consumer] - replaced + units
+	a.total = a.total - replaced + units
+	if len(released) == 1 {
 		delete(a.released, consumer)
+	} else {
+		a.released[consumer] = released[:len(released)-1]
+	}
+}
+
+// AddBooking will record the placeholder lease of a booking that is open.
+// Bookings must be added before the leases that use them.
+func (a *Accumulator) AddBooking(ls lease.Lease) {
+	a.bookings = append(a.bookings, ls)
+}
+
+// booking returns the instance identifier of the first open booking that
+// admits instance, or an empty string if there isn't one.
+func (a *Accumulator) booking(instance lease.Instance) string {
+	for i := range a.bookings {
+		if a.bookings[i].Booking.Admits(instance) {
+			return a.bookings[i].Instance.ID
+		}
 	}
+	return ""
 }
 
 // FinishReplacement will record the completion of a lease replacement for
@@ -73,27 +161,86 @@ func (a *Accumulator) FinishReplacement(consumer string) {
 	}
 }
 
-// Active returns the number of active leases for the consumer.
+// Active returns the number of active units for the consumer.
 func (a *Accumulator) Active(consumer string) uint {
 	return a.active[consumer]
 }
 
+// UserActive returns the number of active units held by the user according
+// to the resource counting strategy.
+func (a *Accumulator) UserActive(user string, strat strategy.Strategy) uint {
+	return a.held(a.users[user], strat)
+}
+
+// HostActive returns the number of active units held on the host according
+// to the resource counting strategy.
+func (a *Accumulator) HostActive(host string, strat strategy.Strategy) uint {
+	return a.held(a.hosts[host], strat)
+}
+
+// Withheld returns the number of units set aside by reservations other than
+// the given one that have not yet been consumed, according to the resource
+// counting strategy.
+func (a *Accumulator) Withheld(reservations []lease.Reservation, reservation string, strat strategy.Strategy) (units uint) {
+	for _, r := range reservations {
+		if r.Name == reservation {
+			continue
+		}
+		if used := a.held(a.reserved[r.Name], strat); used < r.Units {
+			units += r.Units - used
+		}
+	}
+	return
+}
+
+// Booked returns the number of units set aside by open bookings that don't
+// admit instance and have not yet been consumed, according to the resource
+// counting strategy.
+func (a *Accumulator) Booked(instance lease.Instance, strat strategy.Strategy) (units uint) {
+	for i := range a.bookings {
+		b := &a.bookings[i]
+		if b.Booking.Admits(instance) {
+			continue
+		}
+		if used := a.held(a.booked[b.Instance.ID], strat); used < b.Weight() {
+			units += b.Weight() - used
+		}
+	}
+	return
+}
+
+// held returns the number of active units held by the given consumers
+// according to the resource counting strategy.
+//
+// Under the consumer strategy each consumer holds the units of its first
+// active or released lease.
+func (a *Accumulator) held(consumers map[string]uint, strat strategy.Strategy) (total uint) {
+	for consumer, units := range consumers {
+		if strat == strategy.Consumer {
+			total += a.weight[consumer]
+		} else {
+			total += units
+		}
+	}
+	return
+}
+
 // Released returns the number of released leases for the consumer.
 func (a *Accumulator) Released(consumer string) uint {
-	return a.released[consumer]
+	return uint(len(a.released[consumer]))
 }
 
-// Consumed returns the number of consumed resources for the consumer according
+// Consumed returns the number of consumed units for the consumer according
 // to the resource counting strategy.
 func (a *Accumulator) Consumed(consumer string, strat strategy.Strategy) uint {
 	switch strat {
 	default:
+		return a.consumed[consumer]
+	case strategy.Consumer:
 		if a.consumed[consumer] > 0 {
-			return 1
+			return a.weight[consumer]
 		}
 		return 0
-	case strategy.Consumer:
-		return a.consumed[consumer]
 	}
 }
 
@@ -109,13 +256,38 @@ func (a *Accumulator) ReplacementsRecorded() bool {
 	return len(a.replacements) > 0
 }
 
-// Total returns the total number of consumed resources according to the
+// Total returns the total number of consumed units according to the
 // resource counting strategy.
+//
+// Under the consumer strategy each consumer consumes the units of its first
+// active or released lease.
 func (a *Accumulator) Total(strat strategy.Strategy) uint {
 	switch strat {
 	default:
 		return a.total
 	case strategy.Consumer:
-		return uint(len(a.consumed))
+		var total uint
+		for consumer, units := range a.consumed {
+			if units > 0 {
+				total += a.weight[consumer]
+			}
+		}
+		return total
+	}
+}
+
+// TotalAfterReplacement returns the total number of consumed units according
+// to the resource counting strategy if the most recently added released
+// lease for consumer were replaced by an active lease with the given number
+// of units.
+//
+// If consumer has no released leases the current total is returned.
+func (a *Accumulator) TotalAfterReplacement(consumer string, units uint, strat strategy.Strategy) uint {
+	total := a.Total(strat)
+	released := a.released[consumer]
+	if len(released) == 0 || strat == strategy.Consumer {
+		// Consumers are only counted once
+		return total
 	}
+	return total - released[len(released)-1] + units
 }
@@ -0,0 +1,76 @@
+package leaseutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+// reservedLease returns a lease for a resource with a limit of 3 units, 2 of
+// which are reserved for the drafting group.
+func reservedLease(user string, status lease.Status, reservation string, started time.Time) lease.Lease {
+	return lease.Lease{
+		Subject:      lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws-" + user, User: user, ID: "1"}},
+		Status:       status,
+		Started:      started,
+		Renewed:      started,
+		Strategy:     strategy.Instance,
+		Limit:        3,
+		Duration:     time.Hour,
+		Reservations: []lease.Reservation{{Name: "drafting", Units: 2}},
+		Reservation:  reservation,
+	}
+}
+
+func TestReservationUsedByGroup(t *testing.T) {
+	now := time.Now()
+	tx := lease.NewTx("cad", 0, lease.Set{
+		reservedLease("other", lease.Active, "", now.Add(-3*time.Minute)),
+		reservedLease("draft1", lease.Queued, "drafting", now.Add(-2*time.Minute)),
+		reservedLease("draft2", lease.Queued, "drafting", now.Add(-time.Minute)),
+	})
+
+	acc := Refresh(tx, now)
+
+	// Members of the group may use every unit reserved for it
+	for _, user := range []string{"draft1", "draft2"} {
+		ls, _ := tx.Instance(lease.Instance{Host: "ws-" + user, User: user, ID: "1"})
+		if ls.Status != lease.Active {
+			t.Errorf("%s: got status %s, want %s", user, ls.Status, lease.Active)
+		}
+	}
+	if got := acc.Withheld(reservedLease("other", lease.Queued, "", now).Reservations, "", strategy.Instance); got != 0 {
+		t.Errorf("withheld once the reservation is used: got %d, want 0", got)
+	}
+}
+
+func TestReservationWithheldFromOthers(t *testing.T) {
+	now := time.Now()
+	tx := lease.NewTx("cad", 0, lease.Set{
+		reservedLease("other1", lease.Active, "", now.Add(-3*time.Minute)),
+		reservedLease("other2", lease.Queued, "", now.Add(-2*time.Minute)),
+	})
+
+	acc := Refresh(tx, now)
+
+	// Consumers outside the group are held to limit - reserved, even though
+	// the reserved units are idle
+	queued := reservedLease("other2", lease.Queued, "", now)
+	if got := acc.Withheld(queued.Reservations, queued.Reservation, queued.Strategy); got != 2 {
+		t.Errorf("withheld: got %d, want 2", got)
+	}
+	if got := Limit(acc, queued); got != 1 {
+		t.Errorf("limit for other consumers: got %d, want 1", got)
+	}
+	if ls, _ := tx.Instance(queued.Instance); ls.Status != lease.Queued {
+		t.Errorf("other2: got status %s, want %s", ls.Status, lease.Queued)
+	}
+
+	// Members of the group aren't held back by their own reservation
+	member := reservedLease("draft1", lease.Queued, "drafting", now)
+	if got := Limit(acc, member); got != 3 {
+		t.Errorf("limit for members: got %d, want 3", got)
+	}
+}
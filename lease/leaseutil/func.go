@@ -1,14 +1,20 @@
 package leaseutil
 
-import "github.com/scjalliance/resourceful/strategy"
+import (
+	"fmt"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/strategy"
+)
 
 // CanActivate returns true if a lease can be made active under the specified
 // resource counting strategy.
 //
-// Active is the number of active leases for the consumer requesting a lease.
-// Consumed is the total number of consumed resources according to strategy.
+// Active is the number of active units for the consumer requesting a lease.
+// Consumed is the total number of consumed units according to strategy.
+// Units is the number of units the lease would consume.
 // Limit is the resource allocation limit.
-func CanActivate(strat strategy.Strategy, active, consumed, limit uint) bool {
+func CanActivate(strat strategy.Strategy, active, consumed, units, limit uint) bool {
 	if limit == 0 {
 		return false
 	}
@@ -20,5 +26,41 @@ func CanActivate(strat strategy.Strategy, active, consumed, limit uint) bool {
 			return true
 		}
 	}
-	return consumed < limit
+	return units <= limit-consumed
+}
+
+// Limit returns the number of units of the resource that are available to
+// ls, which is its limit less any units that are reserved or booked for other
+// groups of consumers and have not yet been consumed.
+func Limit(acc *Accumulator, ls lease.Lease) uint {
+	withheld := acc.Withheld(ls.Reservations, ls.Reservation, ls.Strategy)
+	withheld += acc.Booked(ls.Instance, ls.Strategy)
+	if withheld >= ls.Limit {
+		return 0
+	}
+	return ls.Limit - withheld
+}
+
+// SubLimit returns a description of the per-user or per-host limit that
+// prevents ls from being activated. It returns an empty string if ls is
+// within its sub-limits.
+//
+// A consumer that already holds active units under the consumer strategy
+// is not subject to its sub-limits.
+func SubLimit(acc *Accumulator, ls lease.Lease) string {
+	if ls.Strategy == strategy.Consumer && acc.Active(ls.ConsumerKey()) > 0 {
+		return ""
+	}
+	units := ls.Weight()
+	if ls.MaxPerUser > 0 {
+		if held := acc.UserActive(ls.Instance.User, ls.Strategy); held+units > ls.MaxPerUser {
+			return fmt.Sprintf("user %s already holds %d of the %d units permitted per user", ls.Instance.User, held, ls.MaxPerUser)
+		}
+	}
+	if ls.MaxPerHost > 0 {
+		if held := acc.HostActive(ls.Instance.Host, ls.Strategy); held+units > ls.MaxPerHost {
+			return fmt.Sprintf("host %s already holds %d of the %d units permitted per host", ls.Instance.Host, held, ls.MaxPerHost)
+		}
+	}
+	return ""
 }
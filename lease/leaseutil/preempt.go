@@ -0,0 +1,167 @@
+package leaseutil
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+// victim is a set of active leases that would free units if preempted
+// together. Under the consumer strategy every active lease held by a consumer
+// must be preempted before any units are freed.
+type victim struct {
+	leases   lease.Set
+	units    uint      // Units freed by preempting the leases
+	priority int       // Highest priority among the leases
+	newest   time.Time // Latest start time among the leases
+	marked   bool      // Has preemption already been scheduled?
+}
+
+func (v *victim) add(ls lease.Lease) {
+	if len(v.leases) == 0 || ls.Priority > v.priority {
+		v.priority = ls.Priority
+	}
+	if ls.Started.After(v.newest) {
+		v.newest = ls.Started
+	}
+	if !ls.Preempt.IsZero() {
+		v.marked = true
+	}
+	v.leases = append(v.leases, ls)
+}
+
+// preempt schedules the preemption of active leases on behalf of queued leases
+// that have opted into preemption. Lower priority leases are preempted newest
+// first, and only when doing so would free enough units for the queued lease.
+// When the active leases exceed a reduced limit the newest leases are also
+// preempted once their grace period has elapsed. Scheduled preemptions that
+// are no longer needed are cancelled.
+func preempt(tx *lease.Tx, acc *Accumulator, at time.Time) {
+	var (
+		queued    lease.Set
+		victims   []*victim
+		consumers = make(map[string]*victim)
+		oldest    *lease.Lease // The oldest active lease
+	)
+
+	leases := tx.Leases()
+	for i, ls := range leases {
+		switch ls.Status {
+		case lease.Active:
+			if oldest == nil {
+				oldest = &leases[i]
+			}
+			var v *victim
+			if ls.Strategy == strategy.Consumer {
+				v = consumers[ls.ConsumerKey()]
+			}
+			if v == nil {
+				v = &victim{units: ls.Weight()}
+				victims = append(victims, v)
+				if ls.Strategy == strategy.Consumer {
+					consumers[ls.ConsumerKey()] = v
+				}
+			}
+			v.add(ls)
+		case lease.Queued:
+			if ls.Preemption > 0 {
+				queued = append(queued, ls)
+			}
+		}
+	}
+
+	// Prefer victims that have already been warned, then the newest
+	sort.SliceStable(victims, func(i, j int) bool {
+		if victims[i].marked != victims[j].marked {
+			return victims[i].marked
+		}
+		return victims[i].newest.After(victims[j].newest)
+	})
+
+	var (
+		deadlines = make(map[lease.Instance]time.Time)
+		chosen    = make(map[*victim]bool)
+		freed     uint // Units freed by chosen victims
+		claimed   uint // Units claimed by queued leases
+	)
+
+	// Leases above a limit that has been reduced, such as by a policy
+	// schedule, are returned to the queue when their grace period ends
+	if oldest != nil && oldest.Grace > 0 {
+		if total := acc.Total(oldest.Strategy); total > oldest.Limit {
+			var reclaimed uint
+			for _, v := range victims {
+				if reclaimed >= total-oldest.Limit {
+					break
+				}
+				chosen[v] = true
+				reclaimed += v.units
+				for _, ls := range v.leases {
+					deadlines[ls.Instance] = at.Add(oldest.Grace)
+				}
+			}
+		}
+	}
+
+	for _, q := range queued {
+		if SubLimit(acc, q) != "" {
+			// Preemption won't help a lease held back by its sub-limits
+			continue
+		}
+
+		var available uint
+		if total, limit := acc.Total(q.Strategy), Limit(acc, q); total < limit {
+			available = limit - total
+		}
+		available += freed
+		if available < claimed {
+			available = 0
+		} else {
+			available -= claimed
+		}
+
+		need := q.Weight()
+		var selection []*victim
+		for _, v := range victims {
+			if available >= need {
+				break
+			}
+			if chosen[v] || v.priority >= q.Priority {
+				continue
+			}
+			selection = append(selection, v)
+			available += v.units
+		}
+
+		if available < need {
+			// Preempting every eligible lease still wouldn't make room
+			continue
+		}
+
+		for _, v := range selection {
+			chosen[v] = true
+			freed += v.units
+			for _, ls := range v.leases {
+				deadlines[ls.Instance] = at.Add(q.Preemption)
+			}
+		}
+		claimed += need
+	}
+
+	tx.Process(func(iter *lease.Iter) {
+		if iter.Status != lease.Active {
+			return
+		}
+		deadline, scheduled := deadlines[iter.Instance]
+		switch {
+		case scheduled && iter.Preempt.IsZero():
+			iter.Preempt = deadline
+			iter.Update()
+		case !scheduled && !iter.Preempt.IsZero():
+			iter.Preempt = time.Time{}
+			iter.Update()
+		}
+	})
+}
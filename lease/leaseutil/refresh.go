@@ -7,7 +7,13 @@ import (
 )
 
 // Refresh will update lease statuses and remove all decayed leases through the
-// transaction.
+// transaction. Active leases that have been preempted by higher priority
+// leases are returned to the queue. The placeholders of bookings that have
+// ended and holds that have expired are removed.
+//
+// Only the leases of the transaction's resource are refreshed. When a
+// component of a composite lease is preempted or released, it is up to the
+// caller to bring the components for other resources into line.
 //
 // Refresh returns an accumulator that can be queried lease information.
 func Refresh(tx *lease.Tx, at time.Time) *Accumulator {
@@ -15,6 +21,14 @@ func Refresh(tx *lease.Tx, at time.Time) *Accumulator {
 
 	replacements := make(lease.Set, 0, 5)
 
+	// Open bookings must be known before the leases that use them are
+	// processed
+	for _, ls := range tx.Leases() {
+		if ls.Status == lease.Booked && ls.Booking.Open(at) {
+			acc.AddBooking(ls)
+		}
+	}
+
 	tx.Process(func(iter *lease.Iter) {
 		switch iter.Status {
 		case lease.Active:
@@ -29,20 +43,66 @@ func Refresh(tx *lease.Tx, at time.Time) *Accumulator {
 				iter.Update()
 			}
 
-			acc.Add(iter.HostUser(), iter.Status)
+			// End the session when the lease has been held for as long as it
+			// is permitted
+			if iter.SessionEnded(at) {
+				iter.Status = lease.Released
+				iter.Released = iter.Started.Add(iter.MaxSession)
+				iter.Update()
+			}
+
+			// Return the lease to the queue when a higher priority lease has
+			// preempted it
+			if iter.Status == lease.Active && iter.Preempted(at) {
+				iter.Status = lease.Queued
+				iter.Preempt = time.Time{}
+				iter.Update()
+				return
+			}
+
+			acc.Add(iter.Lease)
+		case lease.Borrowed:
+			if iter.Decayed(at) {
+				iter.Delete()
+				return
+			}
+
+			// Borrowed leases are not renewed, so they are released when the
+			// borrowing period ends
+			if iter.Expired(at) {
+				iter.Status = lease.Released
+				iter.Released = iter.ExpirationTime()
+				iter.Update()
+			}
+
+			acc.Add(iter.Lease)
+		case lease.Held:
+			// Holds without a duration last until they're removed
+			if iter.Duration > 0 && iter.Expired(at) {
+				iter.Delete()
+				return
+			}
+
+			acc.Add(iter.Lease)
 		case lease.Released:
 			if iter.Decayed(at) {
 				iter.Delete()
 				return
 			}
 
-			acc.Add(iter.HostUser(), iter.Status)
+			acc.Add(iter.Lease)
 		case lease.Queued:
 			if iter.Expired(at) {
 				iter.Delete()
 				return
 			}
 
+			// Components of a multi-resource lease can only be activated
+			// together, which happens when the lease is acquired
+			if iter.Composite() {
+				return
+			}
+
 			consumed := acc.Total(iter.Strategy)
 
 			// If we're already over-allocated there's no way this lease can be
@@ -51,22 +111,41 @@ func Refresh(tx *lease.Tx, at time.Time) *Accumulator {
 				return
 			}
 
+			// The lease can't be promoted while its user or host is holding
+			// as many units as it is permitted
+			if SubLimit(acc, iter.Lease) != "" {
+				return
+			}
+
 			// When possible, replace an existing lease for the same consumer
 			// that has already been released and is decaying.
-			if acc.Released(iter.HostUser()) > 0 {
+			if acc.Released(iter.ConsumerKey()) > 0 {
+				// The replacement might consume more units than the lease it
+				// replaces
+				if acc.TotalAfterReplacement(iter.ConsumerKey(), iter.Weight(), iter.Strategy) > Limit(acc, iter.Lease) {
+					return
+				}
+
 				// This requires two passes. In this pass we'll note the replacement
 				// and delete the queued lease. In the second pass we'll update the
 				// decaying lease.
 				replacements = append(replacements, iter.Lease)
 				iter.Delete()
-				acc.StartReplacement(iter.HostUser())
+				acc.StartReplacement(iter.Lease)
 				return
 			}
 
-			if CanActivate(iter.Strategy, acc.Active(iter.HostUser()), consumed, iter.Limit) {
+			if CanActivate(iter.Strategy, acc.Active(iter.ConsumerKey()), consumed, iter.Weight(), Limit(acc, iter.Lease)) {
+				// Sessions start when the lease is activated, not when it
+				// was queued
 				iter.Status = lease.Active
+				iter.Started = at
 				iter.Update()
-				acc.Add(iter.HostUser(), iter.Status)
+				acc.Add(iter.Lease)
+			}
+		case lease.Booked:
+			if iter.Booking.Ended(at) {
+				iter.Delete()
 			}
 		}
 	})
@@ -78,16 +157,19 @@ func Refresh(tx *lease.Tx, at time.Time) *Accumulator {
 			if iter.Status != lease.Released {
 				return
 			}
-			if acc.Replacements(iter.HostUser()) == 0 {
+			if acc.Replacements(iter.ConsumerKey()) == 0 {
 				return
 			}
-			acc.FinishReplacement(iter.HostUser())
+			acc.FinishReplacement(iter.ConsumerKey())
 			iter.Lease = replacements[r]
 			iter.Status = lease.Active
+			iter.Started = at
 			iter.Update()
 			r++
 		})
 	}
 
+	preempt(tx, acc, at)
+
 	return acc
 }
@@ -0,0 +1,48 @@
+package leaseutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestRefreshStartsSessionOnPromotion(t *testing.T) {
+	now := time.Now()
+	queued := lease.Lease{
+		Subject:    lease.Subject{Resource: "cad", Instance: lease.Instance{Host: "ws1", User: "jdoe", ID: "1"}},
+		Status:     lease.Queued,
+		Started:    now.Add(-2 * time.Hour),
+		Renewed:    now,
+		Strategy:   strategy.Instance,
+		Limit:      1,
+		Duration:   time.Minute,
+		MaxSession: time.Hour,
+	}
+
+	// Time spent in the queue doesn't count toward the session
+	if queued.SessionEnded(now) {
+		t.Errorf("queued lease: session ended after waiting %s", now.Sub(queued.Started))
+	}
+
+	tx := lease.NewTx("cad", 0, lease.Set{queued})
+	Refresh(tx, now)
+
+	ls, found := tx.Instance(queued.Instance)
+	if !found {
+		t.Fatal("promoted lease was removed")
+	}
+	if ls.Status != lease.Active {
+		t.Fatalf("got status %s, want %s", ls.Status, lease.Active)
+	}
+	if !ls.Started.Equal(now) {
+		t.Errorf("promoted lease: session started at %s, want %s", ls.Started, now)
+	}
+	if ls.SessionEnded(now.Add(time.Minute)) {
+		t.Error("promoted lease: session ended on its next renewal")
+	}
+	if !ls.SessionEnded(now.Add(time.Hour)) {
+		t.Error("promoted lease: session didn't end after the maximum session length")
+	}
+}
@@ -32,6 +32,28 @@ func (t Action) String() string {
 	}
 }
 
+// MarshalText returns a text representation of the action type.
+func (t Action) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText parses a text representation of an action type.
+func (t *Action) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "none":
+		*t = None
+	case "create":
+		*t = Create
+	case "update":
+		*t = Update
+	case "delete":
+		*t = Delete
+	default:
+		return fmt.Errorf("unknown lease action \"%s\"", text)
+	}
+	return nil
+}
+
 // UpdateType is a type of lease update.
 type UpdateType uint32
 
@@ -67,7 +89,7 @@ func (t UpdateType) String() string {
 
 // Effect is an effect of a transaction.
 type Effect struct {
-	Action Action
+	Action Action `json:"action"`
 	Lease
 }
 
@@ -81,6 +103,7 @@ type Op struct {
 	Type     Action
 	Previous Lease
 	Lease    Lease
+	Operator string // Administrator responsible for the operation, if any
 }
 
 // UpdateType returns the type of update for update operations.
@@ -93,7 +116,7 @@ func (op *Op) UpdateType() UpdateType {
 	case op.Lease.Instance != op.Previous.Instance:
 		return Replace
 	case op.Lease.Status != op.Previous.Status:
-		// Active < Released < Queued
+		// Active < Borrowed < Held < Released < Queued
 		if op.Lease.Status.Order() < op.Previous.Status.Order() {
 			return Upgrade
 		}
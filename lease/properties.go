@@ -23,3 +23,16 @@ func MergeProperties(props ...Properties) (merged Properties) {
 	}
 	return
 }
+
+// Equal returns true if p and other contain the same keys and values.
+func (p Properties) Equal(other Properties) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for k, v := range p {
+		if ov, ok := other[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
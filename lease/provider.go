@@ -15,6 +15,11 @@ type Provider interface {
 	// LeaseCommit will attempt to commit the lease transaction.
 	LeaseCommit(tx *Tx) (err error)
 
+	// LeaseCommitAll will attempt to commit a set of lease transactions for
+	// different resources atomically. Either all of the transactions are
+	// committed or none of them are.
+	LeaseCommitAll(txs ...*Tx) (err error)
+
 	// Close releases any resources consumed by the provider.
 	Close() error
 }
@@ -0,0 +1,127 @@
+package lease
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+// Position describes the place of a queued lease within the queue for its
+// resource.
+type Position struct {
+	Instance  Instance      `json:"instance"`
+	Position  int           `json:"position"`  // One-based position within the queue
+	Ahead     int           `json:"ahead"`     // Number of consumers queued ahead of the lease
+	Wait      time.Duration `json:"wait"`      // Estimated time until the lease is activated
+	Estimated bool          `json:"estimated"` // Was it possible to estimate the wait?
+}
+
+// Queue returns the position of each queued lease within the set, in queue
+// order.
+//
+// The wait for each lease is estimated from the decay times of released
+// leases and the expected release times of active leases. Active leases are
+// expected to be released once they have been held for the typical session
+// length. If session is zero the wait is only estimated when enough units
+// will be freed by released leases.
+//
+// The set must be sorted prior to calling this function.
+func (s Set) Queue(at time.Time, session time.Duration) (queue []Position) {
+	queued := s.Status(Queued)
+	if len(queued) == 0 {
+		return nil
+	}
+
+	var (
+		ahead    = make(map[string]struct{}) // Consumers queued ahead of the current lease
+		demand   uint                        // Units demanded by leases ahead of the current lease
+		releases = s.releases(at, session)
+		stats    = s.tally()
+	)
+
+	for i, ls := range queued {
+		pos := Position{
+			Instance: ls.Instance,
+			Position: i + 1,
+		}
+
+		consumer := ls.ConsumerKey()
+		for c := range ahead {
+			if c != consumer {
+				pos.Ahead++
+			}
+		}
+
+		pos.Wait, pos.Estimated = wait(&ls, &stats, demand+ls.Weight(), releases, at)
+
+		queue = append(queue, pos)
+		ahead[consumer] = struct{}{}
+		demand += ls.Weight()
+	}
+
+	return
+}
+
+// release is a point in time at which units are expected to become
+// available.
+type release struct {
+	at    time.Time
+	units uint
+}
+
+// releases returns the times at which the units consumed by active and
+// released leases within the set are expected to become available, in
+// chronological order.
+func (s Set) releases(at time.Time, session time.Duration) (releases []release) {
+	for i := range s {
+		ls := &s[i]
+		switch ls.Status {
+		case Released:
+			releases = append(releases, release{at: ls.DecayTime(), units: ls.Weight()})
+		case Active:
+			if session <= 0 {
+				continue
+			}
+			end := ls.Started.Add(session)
+			if end.Before(at) {
+				end = at
+			}
+			releases = append(releases, release{at: end.Add(ls.Decay), units: ls.Weight()})
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].at.Before(releases[j].at)
+	})
+	return
+}
+
+// wait estimates the time until the given number of units will be available
+// under the limit of ls.
+func wait(ls *Lease, stats *Stats, units uint, releases []release, at time.Time) (wait time.Duration, ok bool) {
+	strat := ls.Strategy
+	if !strategy.Valid(strat) || strat == strategy.Empty {
+		strat = strategy.Instance
+	}
+	consumed := stats.Consumed(strat)
+
+	var available uint
+	if consumed < ls.Limit {
+		available = ls.Limit - consumed
+	}
+	if available >= units {
+		return 0, true
+	}
+
+	for _, r := range releases {
+		available += r.units
+		if available >= units {
+			if r.at.After(at) {
+				wait = r.at.Sub(at)
+			}
+			return wait, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,9 @@
+package lease
+
+// Reservation is a number of units of a resource that are set aside for a
+// group of consumers. Consumers outside the group cannot use the reserved
+// units, even when they are idle.
+type Reservation struct {
+	Name  string `json:"name"`
+	Units uint   `json:"units"`
+}
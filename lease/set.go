@@ -29,6 +29,17 @@ func (s Set) HostUser(resource, host, user string) (matched Set) {
 	return
 }
 
+// Consumer returns the set of leases matching the requested resource and
+// consumer key.
+func (s Set) Consumer(resource, consumer string) (matched Set) {
+	for i := range s {
+		if s[i].MatchConsumer(resource, consumer) {
+			matched = append(matched, Clone(s[i]))
+		}
+	}
+	return
+}
+
 // User returns the set of leases for the given user.
 func (s Set) User(user string) (matched Set) {
 	for i := range s {
@@ -91,24 +102,63 @@ func (s Set) Status(status Status) (matched Set) {
 //
 // The set must be sorted prior to calling this function.
 func (s Set) Stats() (stats Stats) {
+	stats = s.tally()
+	stats.Reservations = s.reservationStats()
+	return
+}
+
+// tally returns the resource consumption statistics for the set, without
+// regard to reservations.
+func (s Set) tally() (stats Stats) {
 	consumers := make(map[string]struct{}, len(s)) // Consumers that have already been seen
 
 	for _, ls := range s {
 		// The instance strategy is a simple tally of each kind of lease.
-		stats.Instance.Add(ls.Instance.User, ls.Status)
+		stats.Instance.Add(ls.Instance.User, ls.Status, ls.Weight())
 
 		// The consumer strategy is more complicated; it requires that we only count
 		// each consumer once, despite how many instances the consumer may have.
 		//
 		// Leases are processed in sorted order, which means the active lease will
 		// be processed first. Consumers with both active and released leases will
-		// only count as active.
-		c := ls.HostUser()
+		// only count as active, with the units of their first lease.
+		c := ls.ConsumerKey()
 		if _, seen := consumers[c]; !seen {
 			consumers[c] = struct{}{}
-			stats.Consumer.Add(ls.Instance.User, ls.Status)
+			stats.Consumer.Add(ls.Instance.User, ls.Status, ls.Weight())
+		}
+	}
+	return
+}
+
+// reservationStats returns the resource consumption statistics for each
+// reservation declared by members of the set.
+func (s Set) reservationStats() (stats []ReservationStats) {
+	var (
+		index   = make(map[string]int) // Index of each reservation within stats
+		members []Set                  // Leases belonging to each reservation
+	)
+
+	for _, ls := range s {
+		for _, r := range ls.Reservations {
+			if _, exists := index[r.Name]; !exists {
+				index[r.Name] = len(stats)
+				stats = append(stats, ReservationStats{Name: r.Name, Units: r.Units})
+				members = append(members, nil)
+			}
+		}
+	}
+
+	for _, ls := range s {
+		if i, exists := index[ls.Reservation]; exists {
+			members[i] = append(members[i], ls)
 		}
 	}
+
+	for i := range stats {
+		stats[i].Stats = members[i].tally()
+	}
+
 	return
 }
 
@@ -194,7 +244,16 @@ func (s Set) Less(i, j int) bool {
 			return false
 		}
 		fallthrough
-	case Active, Queued:
+	case Active, Borrowed, Held, Queued:
+		if s[i].Status == Queued {
+			// Priority: Highest first
+			if s[i].Priority > s[j].Priority {
+				return true
+			}
+			if s[i].Priority < s[j].Priority {
+				return false
+			}
+		}
 		s1 := s[i].Started
 		s2 := s[j].Started
 		if s1.Before(s2) {
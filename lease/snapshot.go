@@ -2,8 +2,9 @@ package lease
 
 // Snapshot includes a set of leases for a particular resource at a revision.
 type Snapshot struct {
-	Resource string `json:"resource"`
-	Revision uint64 `json:"revision"`
-	Leases   Set    `json:"leases"`
-	Stats    Stats  `json:"stats"`
+	Resource string     `json:"resource"`
+	Revision uint64     `json:"revision"`
+	Leases   Set        `json:"leases"`
+	Stats    Stats      `json:"stats"`
+	Queue    []Position `json:"queue,omitempty"` // Position of each queued lease
 }
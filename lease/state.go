@@ -11,6 +11,8 @@ type State struct {
 	Acquired         bool          // Have we acquired a lease of any status?
 	Lease            Lease         // The most recent lease received from the server
 	Leases           Set           // All leases for our lease resource
+	Message          string        // The most recent explanation provided by the server, if any
+	Queue            *Position     // Our place in the queue when the lease is queued
 	Retry            time.Duration // Retry interval when not holding a lease
 	Err              error         // The most recent acquisition error
 }
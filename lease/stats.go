@@ -5,8 +5,17 @@ import "github.com/scjalliance/resourceful/strategy"
 // Stats is a set of resource consumption statistics for each resource
 // counting strategy.
 type Stats struct {
-	Instance Tally `json:"instance"` // The number of leases with each lease status
-	Consumer Tally `json:"consumer"` // The number of consumers with each lease status
+	Instance     Tally              `json:"instance"`               // The units consumed by leases with each lease status
+	Consumer     Tally              `json:"consumer"`               // The units consumed by consumers with each lease status
+	Reservations []ReservationStats `json:"reservations,omitempty"` // The units consumed within each reservation
+}
+
+// ReservationStats is a set of resource consumption statistics for the
+// leases belonging to a reservation.
+type ReservationStats struct {
+	Name  string `json:"name"`
+	Units uint   `json:"units"` // The number of units reserved
+	Stats
 }
 
 // Active returns the number of active resources according to the provided
@@ -22,6 +31,32 @@ func (s *Stats) Active(strat strategy.Strategy) uint {
 	}
 }
 
+// Borrowed returns the number of borrowed resources according to the
+// provided resource counting strategy.
+func (s *Stats) Borrowed(strat strategy.Strategy) uint {
+	switch strat {
+	case strategy.Instance:
+		return s.Instance.Borrowed
+	case strategy.Consumer:
+		return s.Consumer.Borrowed
+	default:
+		panic("unknown strategy")
+	}
+}
+
+// Held returns the number of resources held by administrators according to
+// the provided resource counting strategy.
+func (s *Stats) Held(strat strategy.Strategy) uint {
+	switch strat {
+	case strategy.Instance:
+		return s.Instance.Held
+	case strategy.Consumer:
+		return s.Consumer.Held
+	default:
+		panic("unknown strategy")
+	}
+}
+
 // Released returns the number of released resources according to the provided
 // resource counting strategy.
 func (s *Stats) Released(strat strategy.Strategy) uint {
@@ -48,6 +83,19 @@ func (s *Stats) Queued(strat strategy.Strategy) uint {
 	}
 }
 
+// Booked returns the number of resources held by booking placeholders
+// according to the provided resource counting strategy.
+func (s *Stats) Booked(strat strategy.Strategy) uint {
+	switch strat {
+	case strategy.Instance:
+		return s.Instance.Booked
+	case strategy.Consumer:
+		return s.Consumer.Booked
+	default:
+		panic("unknown strategy")
+	}
+}
+
 // Consumed returns the number of consumed resources according to the provided
 // resource counting strategy.
 //
@@ -85,29 +133,45 @@ func (s *Stats) Users(strat strategy.Strategy) map[string]uint {
 }
 
 // Tally is a set of resource statistics for a particular resource counting
-// strategy.
+// strategy. Each lease contributes the number of units it consumes.
 type Tally struct {
 	Active   uint            `json:"active"`
+	Borrowed uint            `json:"borrowed,omitempty"`
+	Held     uint            `json:"held,omitempty"`
 	Released uint            `json:"released"`
 	Queued   uint            `json:"queued"`
+	Booked   uint            `json:"booked,omitempty"`
 	Consumed uint            `json:"consumed"`
 	Users    map[string]uint `json:"-"`
 }
 
-// Add will increase the tally for the specified status.
-func (t *Tally) Add(user string, status Status) {
+// Add will increase the tally for the specified status by the given number
+// of units.
+func (t *Tally) Add(user string, status Status, units uint) {
 	switch status {
 	case Active:
-		t.Active++
-		t.Consumed++
+		t.Active += units
+		t.Consumed += units
+		if t.Users == nil {
+			t.Users = make(map[string]uint)
+		}
+		t.Users[user] += units
+	case Borrowed:
+		t.Borrowed += units
+		t.Consumed += units
 		if t.Users == nil {
 			t.Users = make(map[string]uint)
 		}
-		t.Users[user]++
+		t.Users[user] += units
+	case Held:
+		t.Held += units
+		t.Consumed += units
 	case Released:
-		t.Released++
-		t.Consumed++
+		t.Released += units
+		t.Consumed += units
 	case Queued:
-		t.Queued++
+		t.Queued += units
+	case Booked:
+		t.Booked += units
 	}
 }
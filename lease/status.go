@@ -12,27 +12,53 @@ const (
 	// allocation counts.
 	Active Status = "active"
 
+	// Borrowed indicates that a lease has been checked out for use while
+	// disconnected from the guardian. Borrowed leases are included in the
+	// resource allocation counts until they are returned or expire, and do
+	// not need to be renewed.
+	Borrowed Status = "borrowed"
+
+	// Held indicates that a lease is an administrative hold on units of a
+	// resource. Held leases are included in the resource allocation counts
+	// until they are removed or expire, and are never renewed.
+	Held Status = "held"
+
 	// Released indicates that a lease has ended but is in a state of decay.
 	// Decaying leases are still included in resource allocation counts.
 	Released Status = "released"
+
+	// Booked indicates that a lease is a placeholder for a booking. Booked
+	// leases withhold units from other consumers while their booking is
+	// open, but are not themselves included in the resource allocation
+	// counts.
+	Booked Status = "booked"
 )
 
 // Order returns an ordinal value reflecting the status' sort order. The order
 // is:
 //
-//   0: Active
-//   1: Released
-//   2: Queued
-//   3: (any invalid or unrecognized status)
+//	0: Active
+//	1: Borrowed
+//	2: Held
+//	3: Released
+//	4: Queued
+//	5: Booked
+//	6: (any invalid or unrecognized status)
 func (s Status) Order() int {
 	switch s {
 	case Active:
 		return 0
-	case Released:
+	case Borrowed:
 		return 1
-	case Queued:
+	case Held:
 		return 2
-	default:
+	case Released:
 		return 3
+	case Queued:
+		return 4
+	case Booked:
+		return 5
+	default:
+		return 6
 	}
 }
@@ -12,6 +12,7 @@ type Tx struct {
 	revision uint64
 	leases   Set
 	ops      []Op
+	operator string
 }
 
 // NewTx creates a new transaction for the given resource, revision and lease
@@ -53,12 +54,14 @@ func (tx *Tx) apply(action Action, ls Lease, i int, step int) (shift int) {
 			Type:     Update,
 			Previous: tx.leases[i],
 			Lease:    ls,
+			Operator: tx.operator,
 		})
 		tx.leases[i] = ls
 	case Delete:
 		tx.ops = append(tx.ops, Op{
 			Type:     Delete,
 			Previous: tx.leases[i],
+			Operator: tx.operator,
 		})
 		tx.leases = append(tx.leases[:i], tx.leases[i+1:]...)
 		shift = -step
@@ -77,11 +80,24 @@ func (tx *Tx) Revision() uint64 {
 	return tx.revision
 }
 
+// SetOperator records the identity of the administrator that is responsible
+// for the operations that are subsequently added to the transaction. It
+// should be cleared by passing an empty string before operations that are
+// merely consequences of the administrative action are added.
+func (tx *Tx) SetOperator(operator string) {
+	tx.operator = operator
+}
+
 // HostUser returns the set of leases matching the requested host and user.
 func (tx *Tx) HostUser(host, user string) (matched Set) {
 	return tx.leases.HostUser(tx.resource, host, user)
 }
 
+// Consumer returns the set of leases matching the requested consumer key.
+func (tx *Tx) Consumer(consumer string) (matched Set) {
+	return tx.leases.Consumer(tx.resource, consumer)
+}
+
 // Instance returns the first lease that matches the given instance.
 func (tx *Tx) Instance(instance Instance) (ls Lease, found bool) {
 	return tx.leases.Instance(tx.resource, instance)
@@ -96,8 +112,9 @@ func (tx *Tx) Leases() Set {
 func (tx *Tx) Create(ls Lease) error {
 	tx.leases = append(tx.leases, ls)
 	tx.ops = append(tx.ops, Op{
-		Type:  Create,
-		Lease: ls,
+		Type:     Create,
+		Lease:    ls,
+		Operator: tx.operator,
 	})
 	sort.Sort(tx.leases)
 	return nil
@@ -118,7 +135,7 @@ func (tx *Tx) Update(instance Instance, ls Lease) error {
 func (tx *Tx) Release(instance Instance, at time.Time) error {
 	tx.Process(func(iter *Iter) {
 		if iter.MatchInstance(tx.resource, instance) {
-			if iter.Status == Active {
+			if iter.Status == Active || iter.Status == Borrowed {
 				iter.Status = Released
 				iter.Released = at
 				iter.Update()
@@ -11,14 +11,33 @@ import (
 
 // Comparison types for matching policy criteria.
 const (
-	ComparisonExact      = "exact"
-	ComparisonIgnoreCase = "ignorecase"
-	ComparisonRegex      = "regex"
+	ComparisonExact       = "exact"
+	ComparisonIgnoreCase  = "ignorecase"
+	ComparisonRegex       = "regex"
+	ComparisonGlob        = "glob"
+	ComparisonPrefix      = "prefix"
+	ComparisonSuffix      = "suffix"
+	ComparisonIn          = "in"
+	ComparisonExists      = "exists"
+	ComparisonMissing     = "missing"
+	ComparisonLessThan    = "lt"
+	ComparisonGreaterThan = "gt"
+	ComparisonVersion     = "version"
+)
+
+// Group operators for combining policy criteria.
+const (
+	GroupAll = "all"
+	GroupAny = "any"
+	GroupNot = "not"
 )
 
 const (
 	// DefaultLimit is the limit returned for empty policy sets.
 	DefaultLimit = ^uint(0)
+	// DefaultUnits is the number of units consumed by each lease for policy
+	// sets that don't specify a number of units.
+	DefaultUnits = 1
 	// DefaultDuration is the duration returned for empty policy sets.
 	DefaultDuration = time.Minute * 15
 	// DefaultStrategy is the default resource counting strategy.
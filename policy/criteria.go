@@ -1,7 +1,8 @@
 package policy
 
 import (
-	"regexp"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/scjalliance/resourceful/lease"
@@ -23,47 +24,219 @@ func (c Criteria) Match(props lease.Properties) bool {
 	return true
 }
 
+// MatchAny returns true if any of the criteria match the provided lease
+// properties.
+func (c Criteria) MatchAny(props lease.Properties) bool {
+	for _, criterion := range c {
+		if criterion.Match(props) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the criteria are malformed or contain
+// comparisons that cannot be compiled.
+func (c Criteria) Validate() error {
+	_, err := c.Compile()
+	return err
+}
+
+// Compile returns a copy of the criteria with each comparison compiled in
+// advance, so that it need not be compiled each time it is matched. An error
+// is returned if the criteria are malformed or a comparison cannot be
+// compiled.
+//
+// Criteria that have not been compiled are still matched correctly, but
+// their comparisons are compiled on every match.
+func (c Criteria) Compile() (Criteria, error) {
+	if c == nil {
+		return nil, nil
+	}
+	compiled := make(Criteria, len(c))
+	for i := range c {
+		var err error
+		compiled[i], err = c[i].Compile()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return compiled, nil
+}
+
 // String returns a string representation of the criteria.
 func (c Criteria) String() string {
+	return c.join("⋅")
+}
+
+func (c Criteria) join(sep string) string {
 	parts := make([]string, 0, len(c))
 	for i := range c {
 		parts = append(parts, c[i].String())
 	}
-	return strings.Join(parts, "⋅")
+	return strings.Join(parts, sep)
 }
 
 // Criterion describes a single condition required for a policy to match.
+//
+// A criterion is either a comparison of a lease property with a value, or a
+// group of nested criteria. Exactly one of the following forms must be used:
+//
+//	{"key": "program.name", "comparison": "exact", "value": "ustation.exe"}
+//	{"all": [...]} // Matches when all of the nested criteria match
+//	{"any": [...]} // Matches when any of the nested criteria match
+//	{"not": [...]} // Matches when the nested criteria do not all match
 type Criterion struct {
-	Key        string `json:"key"`        // The lease property to be examined
-	Comparison string `json:"comparison"` // The operator of the comparison
-	Value      string `json:"value"`      // The value the property will be compared with
+	Key        string   `json:"key,omitempty"`        // The lease property to be examined
+	Comparison string   `json:"comparison,omitempty"` // The operator of the comparison
+	Value      string   `json:"value,omitempty"`      // The value the property will be compared with
+	Values     []string `json:"values,omitempty"`     // The values the property will be compared with by ComparisonIn
+
+	All Criteria `json:"all,omitempty"` // A group of criteria that must all match
+	Any Criteria `json:"any,omitempty"` // A group of criteria of which one must match
+	Not Criteria `json:"not,omitempty"` // A group of criteria that must not all match
+
+	matcher Matcher // The compiled comparison, if the criterion has been compiled
+}
 
-	// TODO: cache compiled regular expressions?
+// Group returns the group operator of the criterion, which will be one of
+// GroupAll, GroupAny or GroupNot. It returns an empty string if c is not a
+// group.
+func (c *Criterion) Group() string {
+	switch {
+	case c.All != nil:
+		return GroupAll
+	case c.Any != nil:
+		return GroupAny
+	case c.Not != nil:
+		return GroupNot
+	default:
+		return ""
+	}
+}
+
+// Children returns the nested criteria of a group. It returns nil if c is
+// not a group.
+func (c *Criterion) Children() Criteria {
+	switch c.Group() {
+	case GroupAll:
+		return c.All
+	case GroupAny:
+		return c.Any
+	case GroupNot:
+		return c.Not
+	default:
+		return nil
+	}
 }
 
 // Match returns true if the given process is a match.
 func (c *Criterion) Match(props lease.Properties) bool {
-	value := props[c.Key]
+	switch c.Group() {
+	case GroupAll:
+		return c.All.Match(props)
+	case GroupAny:
+		return c.Any.MatchAny(props)
+	case GroupNot:
+		return len(c.Not) > 0 && !c.Not.Match(props)
+	}
 
-	switch c.Comparison {
-	case ComparisonExact:
-		return c.Value == value
-	case ComparisonIgnoreCase:
-		return strings.ToLower(c.Value) == strings.ToLower(value)
-	case ComparisonRegex:
-		re, err := regexp.Compile(c.Value)
+	matcher := c.matcher
+	if matcher == nil {
+		var err error
+		matcher, err = c.Matcher()
 		if err != nil {
-			// TODO: Log error
 			return false
 		}
-		return re.MatchString(value)
+	}
+
+	value, present := props[c.Key]
+	return matcher(value, present)
+}
+
+// Matcher returns a matcher for the comparison of c. It returns an error if
+// c is a group or its comparison cannot be compiled.
+func (c *Criterion) Matcher() (Matcher, error) {
+	if group := c.Group(); group != "" {
+		return nil, fmt.Errorf("criteria group \"%s\" is not a comparison", group)
+	}
+	return NewMatcher(c.Comparison, c.Value, c.Values)
+}
+
+// Compile returns a copy of c with its comparison compiled in advance. If c
+// is a group its nested criteria are compiled. An error is returned if c is
+// malformed or its comparison cannot be compiled.
+func (c Criterion) Compile() (Criterion, error) {
+	if err := c.validateForm(); err != nil {
+		return Criterion{}, err
+	}
+
+	var err error
+	switch c.Group() {
+	case GroupAll:
+		c.All, err = c.All.Compile()
+	case GroupAny:
+		c.Any, err = c.Any.Compile()
+	case GroupNot:
+		c.Not, err = c.Not.Compile()
 	default:
-		return false
+		c.matcher, err = c.Matcher()
+		if err != nil {
+			err = fmt.Errorf("criterion \"%s\": %v", c.String(), err)
+		}
+	}
+	if err != nil {
+		return Criterion{}, err
+	}
+
+	return c, nil
+}
+
+// validateForm returns an error if c is not exactly one of a comparison or
+// a non-empty group.
+func (c *Criterion) validateForm() error {
+	forms := 0
+	for _, group := range []Criteria{c.All, c.Any, c.Not} {
+		if group != nil {
+			forms++
+		}
+	}
+
+	if forms == 0 {
+		return nil
+	}
+
+	if forms > 1 || c.Key != "" || c.Comparison != "" || c.Value != "" || len(c.Values) > 0 {
+		return errors.New("criterion must be either a comparison or a single group")
 	}
+
+	if len(c.Children()) == 0 {
+		return fmt.Errorf("criteria group \"%s\" is empty", c.Group())
+	}
+
+	return nil
 }
 
 // String returns a string representation of the criterion.
 func (c *Criterion) String() string {
+	switch c.Group() {
+	case GroupAll:
+		return "(" + c.All.join("⋅") + ")"
+	case GroupAny:
+		return "(" + c.Any.join("+") + ")"
+	case GroupNot:
+		return "¬(" + c.Not.join("⋅") + ")"
+	}
+
+	switch c.Comparison {
+	case ComparisonExists:
+		return "∃" + c.Key
+	case ComparisonMissing:
+		return "∄" + c.Key
+	case ComparisonIn:
+		return c.Key + "∈{" + strings.Join(c.Values, ",") + "}"
+	}
+
 	// Key
 	output := c.Key
 
@@ -75,6 +248,10 @@ func (c *Criterion) String() string {
 		output += "≈"
 	case ComparisonRegex:
 		output += "~"
+	case ComparisonLessThan:
+		output += "<"
+	case ComparisonGreaterThan:
+		output += ">"
 	default:
 		output += "." + c.Comparison + "."
 	}
@@ -0,0 +1,196 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestNewMatcher(t *testing.T) {
+	tests := []struct {
+		comparison string
+		value      string
+		values     []string
+		input      string
+		present    bool
+		want       bool
+	}{
+		{ComparisonExact, "acad.exe", nil, "acad.exe", true, true},
+		{ComparisonExact, "acad.exe", nil, "ACAD.EXE", true, false},
+		{ComparisonIgnoreCase, "acad.exe", nil, "ACAD.EXE", true, true},
+		{ComparisonRegex, "^acad", nil, "acad.exe", true, true},
+		{ComparisonRegex, "^acad", nil, "notacad.exe", true, false},
+		{ComparisonGlob, "*.exe", nil, `C:\Program Files\acad.exe`, true, true},
+		{ComparisonGlob, "acad?.exe", nil, "acad2.exe", true, true},
+		{ComparisonGlob, "acad?.exe", nil, "acad.exe", true, false},
+		{ComparisonGlob, "a.c", nil, "abc", true, false},
+		{ComparisonPrefix, "C:\\Program Files", nil, `C:\Program Files\acad.exe`, true, true},
+		{ComparisonPrefix, "C:\\Program Files", nil, `D:\acad.exe`, true, false},
+		{ComparisonPrefix, "", nil, "", false, false},
+		{ComparisonSuffix, ".exe", nil, "acad.exe", true, true},
+		{ComparisonSuffix, ".exe", nil, "acad.dll", true, false},
+		{ComparisonIn, "", []string{"jdoe", "asmith"}, "asmith", true, true},
+		{ComparisonIn, "", []string{"jdoe", "asmith"}, "bjones", true, false},
+		{ComparisonIn, "", []string{""}, "", false, false},
+		{ComparisonExists, "", nil, "", true, true},
+		{ComparisonExists, "", nil, "", false, false},
+		{ComparisonMissing, "", nil, "", false, true},
+		{ComparisonMissing, "", nil, "x", true, false},
+		{ComparisonLessThan, "10", nil, "9.5", true, true},
+		{ComparisonLessThan, "10", nil, "10", true, false},
+		{ComparisonLessThan, "10", nil, "ten", true, false},
+		{ComparisonGreaterThan, "10", nil, "11", true, true},
+		{ComparisonGreaterThan, "10", nil, " 10 ", true, false},
+		{ComparisonVersion, ">=2.1", nil, "2.10", true, true},
+		{ComparisonVersion, ">=2.1", nil, "2.0.9", true, false},
+		{ComparisonVersion, "<2", nil, "1.9", true, true},
+		{ComparisonVersion, "!=1.0", nil, "1.0.0", true, false},
+		{ComparisonVersion, "1.0", nil, "v1.0", true, true},
+		{ComparisonVersion, ">1.0", nil, "", false, false},
+	}
+
+	for _, tt := range tests {
+		matcher, err := NewMatcher(tt.comparison, tt.value, tt.values)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.comparison, tt.value, err)
+			continue
+		}
+		if got := matcher(tt.input, tt.present); got != tt.want {
+			t.Errorf("%s %q %v: matching %q (present %t): got %t, want %t", tt.comparison, tt.value, tt.values, tt.input, tt.present, got, tt.want)
+		}
+	}
+}
+
+func TestNewMatcherErrors(t *testing.T) {
+	tests := []struct {
+		comparison string
+		value      string
+		values     []string
+	}{
+		{"unknown", "x", nil},
+		{ComparisonRegex, "(", nil},
+		{ComparisonIn, "", nil},
+		{ComparisonLessThan, "ten", nil},
+		{ComparisonGreaterThan, "", nil},
+		{ComparisonVersion, ">=", nil},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewMatcher(tt.comparison, tt.value, tt.values); err == nil {
+			t.Errorf("%s %q %v: expected an error", tt.comparison, tt.value, tt.values)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"2.0.1", "2.0", 1},
+		{"1.0", "1.0-beta", 1},
+		{"1.0-alpha", "1.0-beta", -1},
+		{"10.0_5", "10.0+4", 1},
+	}
+
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q): got %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCriteriaGroups(t *testing.T) {
+	const data = `[
+		{"key": "program.name", "comparison": "ignorecase", "value": "acad.exe"},
+		{"any": [
+			{"key": "user.name", "comparison": "in", "values": ["jdoe", "asmith"]},
+			{"all": [
+				{"key": "host.name", "comparison": "prefix", "value": "lab-"},
+				{"key": "program.version", "comparison": "version", "value": ">=2024"}
+			]}
+		]},
+		{"not": [
+			{"key": "user.contractor", "comparison": "exists"}
+		]}
+	]`
+
+	var criteria Criteria
+	if err := json.Unmarshal([]byte(data), &criteria); err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := criteria.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		props lease.Properties
+		want  bool
+	}{
+		{"listed user", lease.Properties{"program.name": "ACAD.EXE", "user.name": "jdoe"}, true},
+		{"unlisted user", lease.Properties{"program.name": "acad.exe", "user.name": "bjones"}, false},
+		{"lab host", lease.Properties{"program.name": "acad.exe", "host.name": "lab-04", "program.version": "2024.1"}, true},
+		{"lab host with old version", lease.Properties{"program.name": "acad.exe", "host.name": "lab-04", "program.version": "2023.9"}, false},
+		{"contractor", lease.Properties{"program.name": "acad.exe", "user.name": "jdoe", "user.contractor": "true"}, false},
+		{"other program", lease.Properties{"program.name": "revit.exe", "user.name": "jdoe"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := compiled.Match(tt.props); got != tt.want {
+			t.Errorf("%s: compiled criteria: got %t, want %t", tt.name, got, tt.want)
+		}
+		if got := criteria.Match(tt.props); got != tt.want {
+			t.Errorf("%s: uncompiled criteria: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCriteriaCompileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria Criteria
+	}{
+		{"empty group", Criteria{{Any: Criteria{}}}},
+		{"two groups", Criteria{{
+			All: Criteria{{Key: "a", Comparison: ComparisonExists}},
+			Any: Criteria{{Key: "b", Comparison: ComparisonExists}},
+		}}},
+		{"group with comparison", Criteria{{
+			Key:        "a",
+			Comparison: ComparisonExists,
+			Not:        Criteria{{Key: "b", Comparison: ComparisonExists}},
+		}}},
+		{"invalid nested comparison", Criteria{{Any: Criteria{{Key: "a", Comparison: ComparisonRegex, Value: "("}}}}},
+		{"unknown comparison", Criteria{{Key: "a", Comparison: "unknown"}}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.criteria.Validate(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestFlatCriteriaHashUnchanged(t *testing.T) {
+	p := New("cad", strategy.Instance, 5, 15*time.Minute, Criteria{
+		{Key: "program.name", Comparison: ComparisonIgnoreCase, Value: "acad.exe"},
+		{Key: "user.name", Comparison: ComparisonRegex, Value: "^j"},
+	})
+
+	// The hash of a policy with flat criteria, as computed before criteria
+	// could be grouped
+	const want = "CuMdHKls62yrKKXw9fNCe-15PCtpxaYqAjmXcg"
+	if got := p.Hash().String(); got != want {
+		t.Errorf("got hash %s, want %s", got, want)
+	}
+}
@@ -26,3 +26,32 @@ func (w hashWriter) WriteString(s string) {
 	w.WriteInt(len(s))
 	w.Writer.WriteString(s)
 }
+
+func (w hashWriter) WriteCriteria(criteria Criteria) {
+	w.WriteInt(len(criteria))
+	for i := range criteria {
+		w.WriteCriterion(&criteria[i])
+	}
+}
+
+func (w hashWriter) WriteCriterion(c *Criterion) {
+	if group := c.Group(); group != "" {
+		// Groups are written in the same shape as comparisons, with the
+		// group operator in place of the comparison operator. This keeps
+		// the hashes of policies without groups unchanged.
+		w.WriteString("")
+		w.WriteString(group)
+		w.WriteString("")
+		w.WriteCriteria(c.Children())
+		return
+	}
+	w.WriteString(c.Key)
+	w.WriteString(c.Comparison)
+	w.WriteString(c.Value)
+	if len(c.Values) > 0 {
+		w.WriteInt(len(c.Values))
+		for _, value := range c.Values {
+			w.WriteString(value)
+		}
+	}
+}
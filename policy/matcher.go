@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher reports whether the value of a lease property satisfies a
+// comparison. The present flag indicates whether the property was present at
+// all.
+type Matcher func(value string, present bool) bool
+
+// NewMatcher returns a matcher for the given comparison. The values are
+// only used by ComparisonIn.
+//
+// An error is returned if the comparison is unrecognized or its value
+// cannot be parsed.
+func NewMatcher(comparison, value string, values []string) (Matcher, error) {
+	switch comparison {
+	case ComparisonExact:
+		return func(v string, present bool) bool {
+			return v == value
+		}, nil
+	case ComparisonIgnoreCase:
+		return func(v string, present bool) bool {
+			return strings.EqualFold(v, value)
+		}, nil
+	case ComparisonRegex:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression \"%s\": %v", value, err)
+		}
+		return func(v string, present bool) bool {
+			return re.MatchString(v)
+		}, nil
+	case ComparisonGlob:
+		re, err := compileGlob(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(v string, present bool) bool {
+			return re.MatchString(v)
+		}, nil
+	case ComparisonPrefix:
+		return func(v string, present bool) bool {
+			return present && strings.HasPrefix(v, value)
+		}, nil
+	case ComparisonSuffix:
+		return func(v string, present bool) bool {
+			return present && strings.HasSuffix(v, value)
+		}, nil
+	case ComparisonIn:
+		if len(values) == 0 {
+			return nil, errors.New("the \"in\" comparison requires a list of values")
+		}
+		set := make(map[string]bool, len(values))
+		for _, member := range values {
+			set[member] = true
+		}
+		return func(v string, present bool) bool {
+			return present && set[v]
+		}, nil
+	case ComparisonExists:
+		return func(v string, present bool) bool {
+			return present
+		}, nil
+	case ComparisonMissing:
+		return func(v string, present bool) bool {
+			return !present
+		}, nil
+	case ComparisonLessThan, ComparisonGreaterThan:
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid numeric value \"%s\" for \"%s\" comparison", value, comparison)
+		}
+		less := comparison == ComparisonLessThan
+		return func(v string, present bool) bool {
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return false
+			}
+			if less {
+				return n < threshold
+			}
+			return n > threshold
+		}, nil
+	case ComparisonVersion:
+		op, version := splitVersionOperator(value)
+		if version == "" {
+			return nil, fmt.Errorf("invalid version value \"%s\"", value)
+		}
+		var accept func(int) bool
+		switch op {
+		case "", "=", "==":
+			accept = func(c int) bool { return c == 0 }
+		case "!=":
+			accept = func(c int) bool { return c != 0 }
+		case "<":
+			accept = func(c int) bool { return c < 0 }
+		case "<=":
+			accept = func(c int) bool { return c <= 0 }
+		case ">":
+			accept = func(c int) bool { return c > 0 }
+		case ">=":
+			accept = func(c int) bool { return c >= 0 }
+		}
+		return func(v string, present bool) bool {
+			if !present || v == "" {
+				return false
+			}
+			return accept(CompareVersions(v, version))
+		}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized comparison type: %s", comparison)
+	}
+}
+
+// splitVersionOperator splits a version comparison value such as ">=1.2"
+// into its operator and version.
+func splitVersionOperator(value string) (op, version string) {
+	value = strings.TrimSpace(value)
+	for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			return candidate, strings.TrimSpace(value[len(candidate):])
+		}
+	}
+	return "", value
+}
+
+// compileGlob converts a glob pattern into an anchored regular expression.
+// An asterisk matches any sequence of characters, including path
+// separators, and a question mark matches any single character.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern \"%s\": %v", pattern, err)
+	}
+	return re, nil
+}
@@ -12,26 +12,30 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
-// TODO: Consider giving policies the ability to construct a "consumer" from
-// any number of properties. Doing this would allow policies flexibility in
-// describing the unit of consumption.
-//
-// We could use something like https://github.com/valyala/fasttemplate for
-// this construction.
-
 // Policy describes the matching conditions and rules for handling a particular
 // resource.
 //
 // A policy is applied only if all of its conditions are matched.
 type Policy struct {
-	Resource   string            `json:"resource,omitempty"`   // Which resource pool this policy counts against
-	Criteria   Criteria          `json:"criteria,omitempty"`   // Matching criteria for lease properties
-	Strategy   strategy.Strategy `json:"strategy,omitempty"`   // Lease counting strategy
-	Limit      uint              `json:"limit,omitempty"`      // Max concurrent leases
-	Duration   time.Duration     `json:"duration,omitempty"`   // Time before a leased resource is automatically released
-	Decay      time.Duration     `json:"decay,omitempty"`      // Time before a released resource is considered available again
-	Refresh    lease.Refresh     `json:"refresh,omitempty"`    // Time between lease acquisitions while maintaining a lease
-	Properties lease.Properties  `json:"properties,omitempty"` // Merged with each lease's properties
+	Resource     string            `json:"resource,omitempty"`     // Which resource pool this policy counts against
+	Criteria     Criteria          `json:"criteria,omitempty"`     // Matching criteria for lease properties
+	Strategy     strategy.Strategy `json:"strategy,omitempty"`     // Lease counting strategy
+	Consumer     string            `json:"consumer,omitempty"`     // Consumer template, such as "{user.account}"
+	Limit        uint              `json:"limit,omitempty"`        // Max concurrent units
+	Units        uint              `json:"units,omitempty"`        // Units consumed by each lease
+	MaxPerUser   uint              `json:"max_per_user,omitempty"` // Max concurrent units for each user
+	MaxPerHost   uint              `json:"max_per_host,omitempty"` // Max concurrent units for each host
+	Duration     time.Duration     `json:"duration,omitempty"`     // Time before a leased resource is automatically released
+	Decay        time.Duration     `json:"decay,omitempty"`        // Time before a released resource is considered available again
+	Schedule     *Schedule         `json:"schedule,omitempty"`     // Varies the limit and duration by time of day
+	MaxSession   time.Duration     `json:"max_session,omitempty"`  // Time after which an active lease is released, regardless of renewal
+	DailyQuota   time.Duration     `json:"daily_quota,omitempty"`  // Time each user may hold an active lease each day
+	MaxBorrow    time.Duration     `json:"max_borrow,omitempty"`   // Longest time for which a lease may be borrowed for offline use, or zero if borrowing is not permitted
+	Reservations Reservations      `json:"reservations,omitempty"` // Units of the resource reserved for groups of consumers
+	Priority     int               `json:"priority,omitempty"`     // Queue priority, with higher values promoted first
+	Preemption   time.Duration     `json:"preemption,omitempty"`   // Warning period given to lower priority leases before they are preempted
+	Refresh      lease.Refresh     `json:"refresh,omitempty"`      // Time between lease acquisitions while maintaining a lease
+	Properties   lease.Properties  `json:"properties,omitempty"`   // Merged with each lease's properties
 }
 
 // New returns a new policy for a particular resource with the given limit,
@@ -55,13 +59,21 @@ func (p *Policy) MarshalJSON() ([]byte, error) {
 	}
 	return json.Marshal(&struct {
 		*pol
-		Duration string  `json:"duration"`
-		Decay    string  `json:"decay"`
-		Refresh  refresh `json:"refresh,omitempty"`
+		Duration   string  `json:"duration"`
+		Decay      string  `json:"decay"`
+		Preemption string  `json:"preemption,omitempty"`
+		MaxSession string  `json:"max_session,omitempty"`
+		DailyQuota string  `json:"daily_quota,omitempty"`
+		MaxBorrow  string  `json:"max_borrow,omitempty"`
+		Refresh    refresh `json:"refresh,omitempty"`
 	}{
-		pol:      (*pol)(p),
-		Duration: p.Duration.String(),
-		Decay:    p.Decay.String(),
+		pol:        (*pol)(p),
+		Duration:   p.Duration.String(),
+		Decay:      p.Decay.String(),
+		Preemption: durationString(p.Preemption),
+		MaxSession: durationString(p.MaxSession),
+		DailyQuota: durationString(p.DailyQuota),
+		MaxBorrow:  durationString(p.MaxBorrow),
 		Refresh: refresh{
 			Active: p.Refresh.Active.String(),
 			Queued: p.Refresh.Queued.String(),
@@ -69,6 +81,15 @@ func (p *Policy) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// durationString returns the string representation of d, or an empty string
+// if d is zero.
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
 // UnmarshalJSON will decode JSON policy data.
 func (p *Policy) UnmarshalJSON(data []byte) error {
 	type pol Policy
@@ -78,9 +99,13 @@ func (p *Policy) UnmarshalJSON(data []byte) error {
 	}
 	aux := &struct {
 		*pol
-		Duration string  `json:"duration"`
-		Decay    string  `json:"decay"`
-		Refresh  refresh `json:"refresh,omitempty"`
+		Duration   string  `json:"duration"`
+		Decay      string  `json:"decay"`
+		Preemption string  `json:"preemption,omitempty"`
+		MaxSession string  `json:"max_session,omitempty"`
+		DailyQuota string  `json:"daily_quota,omitempty"`
+		MaxBorrow  string  `json:"max_borrow,omitempty"`
+		Refresh    refresh `json:"refresh,omitempty"`
 	}{
 		pol: (*pol)(p),
 	}
@@ -98,6 +123,26 @@ func (p *Policy) UnmarshalJSON(data []byte) error {
 			return err
 		}
 	}
+	if aux.Preemption != "" {
+		if p.Preemption, err = time.ParseDuration(aux.Preemption); err != nil {
+			return err
+		}
+	}
+	if aux.MaxSession != "" {
+		if p.MaxSession, err = time.ParseDuration(aux.MaxSession); err != nil {
+			return err
+		}
+	}
+	if aux.DailyQuota != "" {
+		if p.DailyQuota, err = time.ParseDuration(aux.DailyQuota); err != nil {
+			return err
+		}
+	}
+	if aux.MaxBorrow != "" {
+		if p.MaxBorrow, err = time.ParseDuration(aux.MaxBorrow); err != nil {
+			return err
+		}
+	}
 	if aux.Refresh.Active != "" {
 		if p.Refresh.Active, err = time.ParseDuration(aux.Refresh.Active); err != nil {
 			return err
@@ -111,6 +156,33 @@ func (p *Policy) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Scheduled returns a copy of the policy with the limit and duration of its
+// schedule at the given time applied. If the policy doesn't have a schedule
+// it is returned unchanged.
+func (p *Policy) Scheduled(at time.Time) Policy {
+	if w, ok := p.Schedule.Window(at); ok {
+		return w.Apply(*p)
+	}
+	return *p
+}
+
+// Compile returns a copy of the policy with its criteria, reservations and
+// schedule compiled in advance. An error is returned if any of them are
+// invalid.
+func (p *Policy) Compile() (compiled Policy, err error) {
+	compiled = *p
+	if compiled.Criteria, err = p.Criteria.Compile(); err != nil {
+		return Policy{}, fmt.Errorf("invalid criteria: %v", err)
+	}
+	if compiled.Reservations, err = p.Reservations.Compile(); err != nil {
+		return Policy{}, fmt.Errorf("invalid reservations: %v", err)
+	}
+	if compiled.Schedule, err = p.Schedule.Compile(); err != nil {
+		return Policy{}, fmt.Errorf("invalid schedule: %v", err)
+	}
+	return compiled, nil
+}
+
 // Match returns true if the policy applies to a lease with the given
 // properites.
 //
@@ -131,15 +203,48 @@ func (p *Policy) String() string {
 	if p.Strategy != "" {
 		parts = append(parts, fmt.Sprintf("Strategy: %s", p.Strategy))
 	}
+	if p.Consumer != "" {
+		parts = append(parts, fmt.Sprintf("Consumer: %q", p.Consumer))
+	}
 	if p.Limit != 0 {
 		parts = append(parts, fmt.Sprintf("Limit: %d", p.Limit))
 	}
+	if p.Units != 0 {
+		parts = append(parts, fmt.Sprintf("Units: %d", p.Units))
+	}
+	if p.MaxPerUser != 0 {
+		parts = append(parts, fmt.Sprintf("Max Per User: %d", p.MaxPerUser))
+	}
+	if p.MaxPerHost != 0 {
+		parts = append(parts, fmt.Sprintf("Max Per Host: %d", p.MaxPerHost))
+	}
 	if p.Duration != 0 {
 		parts = append(parts, fmt.Sprintf("Duration: %s", p.Duration))
 	}
 	if p.Decay != 0 {
 		parts = append(parts, fmt.Sprintf("Decay: %s", p.Decay))
 	}
+	if p.MaxSession != 0 {
+		parts = append(parts, fmt.Sprintf("Max Session: %s", p.MaxSession))
+	}
+	if p.DailyQuota != 0 {
+		parts = append(parts, fmt.Sprintf("Daily Quota: %s", p.DailyQuota))
+	}
+	if p.MaxBorrow != 0 {
+		parts = append(parts, fmt.Sprintf("Max Borrow: %s", p.MaxBorrow))
+	}
+	if p.Schedule != nil {
+		parts = append(parts, fmt.Sprintf("Schedule: %q", p.Schedule.String()))
+	}
+	if len(p.Reservations) > 0 {
+		parts = append(parts, fmt.Sprintf("Reservations: %q", p.Reservations.String()))
+	}
+	if p.Priority != 0 {
+		parts = append(parts, fmt.Sprintf("Priority: %d", p.Priority))
+	}
+	if p.Preemption != 0 {
+		parts = append(parts, fmt.Sprintf("Preemption: %s", p.Preemption))
+	}
 	if p.Refresh.Active != 0 {
 		parts = append(parts, fmt.Sprintf("Active Refresh: %s", p.Refresh.Active))
 	}
@@ -160,12 +265,7 @@ func (p *Policy) Hash() Hash {
 	)
 
 	w.WriteString(p.Resource)
-	w.WriteInt(len(p.Criteria))
-	for _, c := range p.Criteria {
-		w.WriteString(c.Key)
-		w.WriteString(c.Comparison)
-		w.WriteString(c.Value)
-	}
+	w.WriteCriteria(p.Criteria)
 	w.WriteString(string(p.Strategy))
 	w.WriteInt(int(p.Limit))
 	w.WriteDuration(p.Duration)
@@ -178,6 +278,74 @@ func (p *Policy) Hash() Hash {
 		w.WriteString(value)
 	}
 
+	// The optional fields below are only written when present, each preceded
+	// by a tag, so that the hashes of policies that don't use them are
+	// unchanged
+	if p.Consumer != "" {
+		w.WriteString("consumer")
+		w.WriteString(p.Consumer)
+	}
+	if p.Units != 0 {
+		w.WriteString("units")
+		w.WriteInt(int(p.Units))
+	}
+	if p.MaxPerUser != 0 {
+		w.WriteString("max_per_user")
+		w.WriteInt(int(p.MaxPerUser))
+	}
+	if p.MaxPerHost != 0 {
+		w.WriteString("max_per_host")
+		w.WriteInt(int(p.MaxPerHost))
+	}
+	if p.MaxSession != 0 {
+		w.WriteString("max_session")
+		w.WriteDuration(p.MaxSession)
+	}
+	if p.DailyQuota != 0 {
+		w.WriteString("daily_quota")
+		w.WriteDuration(p.DailyQuota)
+	}
+	if p.MaxBorrow != 0 {
+		w.WriteString("max_borrow")
+		w.WriteDuration(p.MaxBorrow)
+	}
+	if p.Schedule != nil {
+		w.WriteString("schedule")
+		w.WriteString(p.Schedule.Zone)
+		w.WriteDuration(p.Schedule.Grace)
+		w.WriteInt(len(p.Schedule.Windows))
+		for i := range p.Schedule.Windows {
+			win := &p.Schedule.Windows[i]
+			w.WriteString(strings.Join(win.Days, ","))
+			w.WriteString(win.Start)
+			w.WriteString(win.End)
+			w.WriteInt(int(win.Limit))
+			w.WriteDuration(win.Duration)
+			if win.Disabled {
+				w.WriteInt(1)
+			} else {
+				w.WriteInt(0)
+			}
+		}
+	}
+	if len(p.Reservations) > 0 {
+		w.WriteString("reservations")
+		w.WriteInt(len(p.Reservations))
+		for i := range p.Reservations {
+			w.WriteString(p.Reservations[i].Name)
+			w.WriteCriteria(p.Reservations[i].Criteria)
+			w.WriteInt(int(p.Reservations[i].Units))
+		}
+	}
+	if p.Priority != 0 {
+		w.WriteString("priority")
+		w.WriteInt(p.Priority)
+	}
+	if p.Preemption != 0 {
+		w.WriteString("preemption")
+		w.WriteDuration(p.Preemption)
+	}
+
 	if err := w.Flush(); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,37 @@
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+func TestPolicyCompile(t *testing.T) {
+	// Policies received from a guardian are decoded without being compiled
+	const data = `{
+		"resource": "cad",
+		"criteria": [{"key": "program.name", "comparison": "regex", "value": "^acad"}],
+		"schedule": {"zone": "America/Los_Angeles", "windows": [{"days": ["weekdays"], "start": "08:00", "end": "17:00", "limit": 2}]}
+	}`
+
+	var pol Policy
+	if err := json.Unmarshal([]byte(data), &pol); err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := pol.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compiled.Match(lease.Properties{"program.name": "acad.exe"}) {
+		t.Error("compiled policy did not match")
+	}
+	if compiled.Hash() != pol.Hash() {
+		t.Error("compiling the policy changed its hash")
+	}
+
+	pol.Criteria[0].Value = "("
+	if _, err := pol.Compile(); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -11,3 +11,11 @@ type Provider interface {
 	// Close releases any resources consumed by the provider.
 	Close() error
 }
+
+// Versioner is a source of policies that is able to detect changes to its
+// policies without loading them.
+type Versioner interface {
+	// PolicyVersion returns an opaque value that changes whenever the
+	// provider's policies might have changed.
+	PolicyVersion() (string, error)
+}
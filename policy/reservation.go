@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// Reservation sets aside a number of units of a resource for the consumers
+// that match its criteria. Other consumers are not permitted to use the
+// reserved units, even when they are idle.
+type Reservation struct {
+	Name     string   `json:"name"`               // Name of the group for which the units are reserved
+	Criteria Criteria `json:"criteria,omitempty"` // Matching criteria for members of the group
+	Units    uint     `json:"units"`              // Number of units reserved for the group
+}
+
+// Reservations is a set of reservations.
+type Reservations []Reservation
+
+// Match returns the name of the first reservation whose criteria match the
+// given properties. It returns an empty string if none of the reservations
+// match.
+func (rs Reservations) Match(props lease.Properties) string {
+	for i := range rs {
+		if rs[i].Criteria.Match(props) {
+			return rs[i].Name
+		}
+	}
+	return ""
+}
+
+// Compile returns a copy of the reservations with their criteria compiled.
+// It returns an error if a reservation is unnamed, if two reservations share
+// a name or if any of the criteria are invalid.
+func (rs Reservations) Compile() (Reservations, error) {
+	if rs == nil {
+		return nil, nil
+	}
+	compiled := make(Reservations, len(rs))
+	seen := make(map[string]bool, len(rs))
+	for i, r := range rs {
+		if r.Name == "" {
+			return nil, errors.New("reservation name is missing")
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("reservation \"%s\" is declared more than once", r.Name)
+		}
+		seen[r.Name] = true
+		criteria, err := r.Criteria.Compile()
+		if err != nil {
+			return nil, fmt.Errorf("reservation \"%s\": %v", r.Name, err)
+		}
+		r.Criteria = criteria
+		compiled[i] = r
+	}
+	return compiled, nil
+}
+
+// Leased returns the reservations in the form in which they are recorded
+// on leases.
+func (rs Reservations) Leased() []lease.Reservation {
+	if len(rs) == 0 {
+		return nil
+	}
+	leased := make([]lease.Reservation, len(rs))
+	for i := range rs {
+		leased[i] = lease.Reservation{Name: rs[i].Name, Units: rs[i].Units}
+	}
+	return leased
+}
+
+// String returns a string representation of the reservations.
+func (rs Reservations) String() string {
+	parts := make([]string, len(rs))
+	for i := range rs {
+		parts[i] = fmt.Sprintf("%s(%d): %s", rs[i].Name, rs[i].Units, rs[i].Criteria.String())
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,283 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule varies the limit and duration of a policy according to the time
+// of day and the day of the week.
+//
+// The first window that contains the current time is applied. When none of
+// the windows contain the current time the policy is unchanged.
+type Schedule struct {
+	Zone    string        `json:"zone,omitempty"`  // IANA time zone name, such as "America/Los_Angeles"
+	Grace   time.Duration `json:"grace,omitempty"` // Time given to leases above a reduced limit before they are returned to the queue
+	Windows []Window      `json:"windows"`
+
+	location *time.Location // Compiled time zone
+}
+
+// Window is a recurring period of time during which the limit or duration of
+// a policy is overridden.
+//
+// A window that ends before it starts spans midnight, in which case its days
+// refer to the day on which it starts.
+type Window struct {
+	Days     []string      `json:"days,omitempty"`     // Days of the week, such as "mon" or "weekends", or every day if empty
+	Start    string        `json:"start,omitempty"`    // Time of day at which the window opens, such as "08:00"
+	End      string        `json:"end,omitempty"`      // Time of day at which the window closes, such as "17:30"
+	Limit    uint          `json:"limit,omitempty"`    // Overrides the policy limit when non-zero
+	Duration time.Duration `json:"duration,omitempty"` // Overrides the policy duration when non-zero
+	Disabled bool          `json:"disabled,omitempty"` // Prevents any leases from being activated
+
+	days       [7]bool       // Compiled days of the week
+	start, end time.Duration // Compiled times of day
+}
+
+var weekdays = map[string][]time.Weekday{
+	"sun":       {time.Sunday},
+	"sunday":    {time.Sunday},
+	"mon":       {time.Monday},
+	"monday":    {time.Monday},
+	"tue":       {time.Tuesday},
+	"tuesday":   {time.Tuesday},
+	"wed":       {time.Wednesday},
+	"wednesday": {time.Wednesday},
+	"thu":       {time.Thursday},
+	"thursday":  {time.Thursday},
+	"fri":       {time.Friday},
+	"friday":    {time.Friday},
+	"sat":       {time.Saturday},
+	"saturday":  {time.Saturday},
+	"weekdays":  {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"weekends":  {time.Saturday, time.Sunday},
+}
+
+// Compile returns a copy of the schedule with its time zone and windows
+// compiled. It returns an error if the schedule is invalid.
+//
+// Compile returns nil if s is nil.
+func (s *Schedule) Compile() (*Schedule, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	compiled := *s
+
+	location, err := time.LoadLocation(s.Zone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone \"%s\": %v", s.Zone, err)
+	}
+	compiled.location = location
+
+	compiled.Windows = make([]Window, len(s.Windows))
+	for i := range s.Windows {
+		w, err := s.Windows[i].compile()
+		if err != nil {
+			return nil, fmt.Errorf("schedule window %d: %v", i+1, err)
+		}
+		compiled.Windows[i] = w
+	}
+
+	return &compiled, nil
+}
+
+// Window returns the first window of the schedule that contains the given
+// time.
+func (s *Schedule) Window(at time.Time) (w Window, ok bool) {
+	if s == nil {
+		return
+	}
+
+	if s.location == nil {
+		compiled, err := s.Compile()
+		if err != nil {
+			return
+		}
+		s = compiled
+	}
+
+	at = at.In(s.location)
+	for i := range s.Windows {
+		if s.Windows[i].contains(at) {
+			return s.Windows[i], true
+		}
+	}
+	return
+}
+
+// String returns a string representation of the schedule.
+func (s *Schedule) String() string {
+	parts := make([]string, len(s.Windows))
+	for i := range s.Windows {
+		parts[i] = s.Windows[i].String()
+	}
+	str := strings.Join(parts, ", ")
+	if s.Zone != "" {
+		str += " " + s.Zone
+	}
+	return str
+}
+
+// MarshalJSON will encode the schedule as JSON.
+func (s *Schedule) MarshalJSON() ([]byte, error) {
+	type schedule Schedule
+	return json.Marshal(&struct {
+		*schedule
+		Grace string `json:"grace,omitempty"`
+	}{
+		schedule: (*schedule)(s),
+		Grace:    durationString(s.Grace),
+	})
+}
+
+// UnmarshalJSON will decode JSON schedule data.
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	type schedule Schedule
+	aux := &struct {
+		*schedule
+		Grace string `json:"grace,omitempty"`
+	}{
+		schedule: (*schedule)(s),
+	}
+	var err error
+	if err = json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if aux.Grace != "" {
+		if s.Grace, err = time.ParseDuration(aux.Grace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply returns a copy of pol with the overrides of the window applied.
+func (w *Window) Apply(pol Policy) Policy {
+	if w.Limit != 0 {
+		pol.Limit = w.Limit
+	}
+	if w.Duration != 0 {
+		pol.Duration = w.Duration
+	}
+	if w.Disabled {
+		pol.Limit = 0
+	}
+	return pol
+}
+
+// String returns a string representation of the window.
+func (w *Window) String() string {
+	var parts []string
+	if len(w.Days) > 0 {
+		parts = append(parts, strings.Join(w.Days, ","))
+	}
+	if w.Start != "" || w.End != "" {
+		parts = append(parts, fmt.Sprintf("%s-%s", w.Start, w.End))
+	}
+	if w.Limit != 0 {
+		parts = append(parts, fmt.Sprintf("limit %d", w.Limit))
+	}
+	if w.Duration != 0 {
+		parts = append(parts, fmt.Sprintf("duration %s", w.Duration))
+	}
+	if w.Disabled {
+		parts = append(parts, "disabled")
+	}
+	return strings.Join(parts, " ")
+}
+
+// MarshalJSON will encode the window as JSON.
+func (w *Window) MarshalJSON() ([]byte, error) {
+	type window Window
+	return json.Marshal(&struct {
+		*window
+		Duration string `json:"duration,omitempty"`
+	}{
+		window:   (*window)(w),
+		Duration: durationString(w.Duration),
+	})
+}
+
+// UnmarshalJSON will decode JSON window data.
+func (w *Window) UnmarshalJSON(data []byte) error {
+	type window Window
+	aux := &struct {
+		*window
+		Duration string `json:"duration,omitempty"`
+	}{
+		window: (*window)(w),
+	}
+	var err error
+	if err = json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+	if aux.Duration != "" {
+		if w.Duration, err = time.ParseDuration(aux.Duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w Window) compile() (Window, error) {
+	if len(w.Days) == 0 {
+		for d := range w.days {
+			w.days[d] = true
+		}
+	}
+	for _, day := range w.Days {
+		matched, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return w, fmt.Errorf("invalid day of the week \"%s\"", day)
+		}
+		for _, d := range matched {
+			w.days[d] = true
+		}
+	}
+
+	var err error
+	if w.start, err = parseTimeOfDay(w.Start, 0); err != nil {
+		return w, fmt.Errorf("invalid start time: %v", err)
+	}
+	if w.end, err = parseTimeOfDay(w.End, 24*time.Hour); err != nil {
+		return w, fmt.Errorf("invalid end time: %v", err)
+	}
+	return w, nil
+}
+
+// contains returns true if the window contains the given time, which must
+// already be in the schedule's time zone.
+func (w *Window) contains(at time.Time) bool {
+	// Use the wall clock time, which differs from the time elapsed since
+	// midnight on days when daylight saving time begins or ends
+	offset := time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	day := at.Weekday()
+
+	if w.start <= w.end {
+		return w.days[day] && offset >= w.start && offset < w.end
+	}
+
+	// The window spans midnight
+	yesterday := (day + 6) % 7
+	return (w.days[day] && offset >= w.start) || (w.days[yesterday] && offset < w.end)
+}
+
+// parseTimeOfDay parses a time of day in 24-hour "15:04" form and returns its
+// offset from midnight. If value is empty the fallback is returned.
+func parseTimeOfDay(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
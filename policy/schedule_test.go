@@ -0,0 +1,95 @@
+package policy
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // Time zones for the daylight saving time tests
+
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+func TestScheduleWindow(t *testing.T) {
+	schedule := &Schedule{
+		Zone: "America/New_York",
+		Windows: []Window{
+			{Days: []string{"weekdays"}, Start: "09:00", End: "17:00", Limit: 10},
+			{Days: []string{"fri"}, Start: "22:00", End: "06:00", Limit: 2},
+			{Days: []string{"sun"}, Start: "09:00", End: "17:00", Limit: 5},
+			{Days: []string{"sun"}, Start: "01:00", End: "03:30", Disabled: true},
+		},
+	}
+	ny, err := time.LoadLocation(schedule.Zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		at    time.Time
+		limit uint // Limit of the matching window, or zero if none match
+	}{
+		{"weekday morning", time.Date(2024, 1, 10, 9, 0, 0, 0, ny), 10},
+		{"weekday evening", time.Date(2024, 1, 10, 17, 0, 0, 0, ny), 0},
+		{"weekday before start", time.Date(2024, 1, 10, 8, 59, 0, 0, ny), 0},
+		{"other time zone", time.Date(2024, 1, 10, 14, 30, 0, 0, time.UTC), 10},
+		{"friday night", time.Date(2024, 1, 12, 23, 0, 0, 0, ny), 2},
+		{"saturday after midnight", time.Date(2024, 1, 13, 5, 59, 0, 0, ny), 2},
+		{"saturday morning", time.Date(2024, 1, 13, 6, 0, 0, 0, ny), 0},
+		{"saturday night", time.Date(2024, 1, 13, 23, 0, 0, 0, ny), 0},
+		{"friday after midnight", time.Date(2024, 1, 12, 5, 0, 0, 0, ny), 0},
+		// Daylight saving time begins at 02:00 on 2024-03-10, so only 8
+		// hours have passed since midnight at 09:30
+		{"spring forward morning", time.Date(2024, 3, 10, 9, 30, 0, 0, ny), 5},
+		{"spring forward before start", time.Date(2024, 3, 10, 8, 30, 0, 0, ny), 0},
+		{"spring forward evening", time.Date(2024, 3, 10, 17, 0, 0, 0, ny), 0},
+		// Daylight saving time ends at 02:00 on 2024-11-03, so 10 hours have
+		// passed since midnight at 09:00
+		{"fall back before start", time.Date(2024, 11, 3, 8, 30, 0, 0, ny), 0},
+		{"fall back morning", time.Date(2024, 11, 3, 9, 0, 0, 0, ny), 5},
+		{"fall back late afternoon", time.Date(2024, 11, 3, 16, 30, 0, 0, ny), 5},
+	}
+
+	for _, tt := range tests {
+		w, ok := schedule.Window(tt.at)
+		switch {
+		case tt.limit == 0 && ok:
+			t.Errorf("%s: %s matched window %q, want none", tt.name, tt.at, w.String())
+		case tt.limit != 0 && !ok:
+			t.Errorf("%s: %s matched no window, want limit %d", tt.name, tt.at, tt.limit)
+		case ok && w.Limit != tt.limit:
+			t.Errorf("%s: %s matched window %q, want limit %d", tt.name, tt.at, w.String(), tt.limit)
+		}
+	}
+}
+
+func TestScheduleDisabled(t *testing.T) {
+	pol := New("cad", strategy.Instance, 10, time.Minute, nil)
+	pol.Schedule = &Schedule{
+		Zone: "America/New_York",
+		Windows: []Window{
+			{Days: []string{"sun"}, Start: "01:00", End: "03:30", Disabled: true},
+		},
+	}
+	ny, err := time.LoadLocation(pol.Schedule.Zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The window covers the hour that is skipped when daylight saving time
+	// begins on 2024-03-10
+	tests := []struct {
+		at    time.Time
+		limit uint
+	}{
+		{time.Date(2024, 3, 10, 0, 59, 0, 0, ny), 10},
+		{time.Date(2024, 3, 10, 1, 30, 0, 0, ny), 0},
+		{time.Date(2024, 3, 10, 3, 0, 0, 0, ny), 0},
+		{time.Date(2024, 3, 10, 3, 30, 0, 0, ny), 10},
+	}
+
+	for _, tt := range tests {
+		if got := pol.Scheduled(tt.at).Limit; got != tt.limit {
+			t.Errorf("%s: got limit %d, want %d", tt.at, got, tt.limit)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/scjalliance/resourceful/lease"
 	"github.com/scjalliance/resourceful/strategy"
+	"golang.org/x/crypto/sha3"
 )
 
 // Set is a set of policies.
@@ -70,6 +71,43 @@ func (s Set) Match(props lease.Properties) (matches Set) {
 	return
 }
 
+// Scheduled returns a copy of the set with the schedule of each policy at the
+// given time applied.
+func (s Set) Scheduled(at time.Time) (scheduled Set) {
+	if s == nil {
+		return nil
+	}
+	scheduled = make(Set, len(s))
+	for i := range s {
+		scheduled[i] = s[i].Scheduled(at)
+	}
+	return
+}
+
+// HasSchedule returns true if any of the policies in the set have a schedule.
+func (s Set) HasSchedule() bool {
+	for i := range s {
+		if s[i].Schedule != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Grace returns the time given to leases above a reduced limit before they
+// are returned to the queue, which is the maximum grace period of the
+// schedules within the set.
+//
+// If none of the policies have a schedule, a zero value is returned.
+func (s Set) Grace() (grace time.Duration) {
+	for i := range s {
+		if s[i].Schedule != nil && s[i].Schedule.Grace > grace {
+			grace = s[i].Schedule.Grace
+		}
+	}
+	return
+}
+
 // Strategy returns the resource counting strategy for the policy set. The
 // first non-empty strategy in the set will be returned. If the set does not
 // define a non-empty strategy, DefaultStrategy will be returned.
@@ -84,6 +122,65 @@ func (s Set) Strategy() strategy.Strategy {
 	return DefaultStrategy
 }
 
+// Consumer returns the consumer template for the policy set. The first
+// non-empty template in the set will be returned. If the set does not define
+// a consumer template an empty string is returned, in which case consumers
+// are identified by host and user.
+func (s Set) Consumer() string {
+	for p := range s {
+		if s[p].Consumer != "" {
+			return s[p].Consumer
+		}
+	}
+	return ""
+}
+
+// Units returns the number of units consumed by each lease for the policy
+// set, which is the maximum value within the set.
+//
+// If the set does not specify a number of units, DefaultUnits is returned.
+func (s Set) Units() (units uint) {
+	for i := range s {
+		if s[i].Units > units {
+			units = s[i].Units
+		}
+	}
+	if units == 0 {
+		return DefaultUnits
+	}
+	return units
+}
+
+// Priority returns the queue priority for the policy set, which is the
+// maximum value within the set.
+//
+// If the set is empty, a zero value is returned.
+func (s Set) Priority() (priority int) {
+	if len(s) == 0 {
+		return 0
+	}
+
+	priority = s[0].Priority
+	for i := 1; i < len(s); i++ {
+		if s[i].Priority > priority {
+			priority = s[i].Priority
+		}
+	}
+	return
+}
+
+// Preemption returns the preemption warning period for the policy set, which
+// is the maximum value within the set. A zero value indicates that leases for
+// the set do not preempt lower priority leases.
+func (s Set) Preemption() (preemption time.Duration) {
+	for i := range s {
+		if s[i].Preemption > preemption {
+			preemption = s[i].Preemption
+		}
+	}
+	return
+}
+
 // Limit returns the lease limit for the policy set, which is the
 // minimum value within the set.
 //
@@ -102,6 +199,71 @@ func (s Set) Limit() (limit uint) {
 	return
 }
 
+// MaxPerUser returns the maximum number of units each user may hold for the
+// policy set, which is the minimum non-zero value within the set.
+//
+// If the set does not specify a per-user limit, zero is returned.
+func (s Set) MaxPerUser() (max uint) {
+	for i := range s {
+		if v := s[i].MaxPerUser; v != 0 && (max == 0 || v < max) {
+			max = v
+		}
+	}
+	return
+}
+
+// MaxPerHost returns the maximum number of units each host may hold for the
+// policy set, which is the minimum non-zero value within the set.
+//
+// If the set does not specify a per-host limit, zero is returned.
+func (s Set) MaxPerHost() (max uint) {
+	for i := range s {
+		if v := s[i].MaxPerHost; v != 0 && (max == 0 || v < max) {
+			max = v
+		}
+	}
+	return
+}
+
+// MaxSession returns the maximum session length for the policy set, which is
+// the minimum non-zero value within the set.
+//
+// If the set does not specify a maximum session length, zero is returned.
+func (s Set) MaxSession() (max time.Duration) {
+	for i := range s {
+		if v := s[i].MaxSession; v != 0 && (max == 0 || v < max) {
+			max = v
+		}
+	}
+	return
+}
+
+// DailyQuota returns the daily quota of each user for the policy set, which
+// is the minimum non-zero value within the set.
+//
+// If the set does not specify a daily quota, zero is returned.
+func (s Set) DailyQuota() (quota time.Duration) {
+	for i := range s {
+		if v := s[i].DailyQuota; v != 0 && (quota == 0 || v < quota) {
+			quota = v
+		}
+	}
+	return
+}
+
+// MaxBorrow returns the longest time for which a lease may be borrowed under
+// the policy set, which is the minimum non-zero value within the set.
+//
+// If the set does not permit borrowing, zero is returned.
+func (s Set) MaxBorrow() (max time.Duration) {
+	for i := range s {
+		if v := s[i].MaxBorrow; v != 0 && (max == 0 || v < max) {
+			max = v
+		}
+	}
+	return
+}
+
 // Duration returns the lease duration for the policy set, which is the
 // minimum value within the set.
 //
@@ -161,6 +323,23 @@ func (s Set) Refresh() (refresh lease.Refresh) {
 	return
 }
 
+// Reservations returns the reservations declared by policies within the set.
+// When more than one policy declares a reservation with the same name, the
+// first one is returned.
+func (s Set) Reservations() (reservations Reservations) {
+	seen := make(map[string]bool)
+	for i := range s {
+		for _, r := range s[i].Reservations {
+			if seen[r.Name] {
+				continue
+			}
+			seen[r.Name] = true
+			reservations = append(reservations, r)
+		}
+	}
+	return
+}
+
 // Resource returns the first resource defined in the policy set.
 //
 // If the set is empty, the returned value will be blank.
@@ -181,6 +360,7 @@ func (s Set) Resources() (resources []string) {
 	for i := 0; i < len(s); i++ {
 		resource := s[i].Resource
 		if resource != "" && !seen[resource] {
+			seen[resource] = true
 			resources = append(resources, resource)
 		}
 	}
@@ -195,3 +375,17 @@ func (s Set) Properties() (props lease.Properties) {
 	}
 	return lease.MergeProperties(list...)
 }
+
+// Hash returns a 224-bit hash of the policy set. It is derived from the
+// hashes of the policies in the set, in order.
+func (s Set) Hash() Hash {
+	hash := sha3.New224()
+	for i := 0; i < len(s); i++ {
+		h := s[i].Hash()
+		hash.Write(h[:])
+	}
+
+	var h Hash
+	hash.Sum(h[:0])
+	return h
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two version strings, such as "1.10.2" and
+// "1.9". It returns -1 if a is less than b, 1 if a is greater than b and 0
+// if they are equal.
+//
+// Versions are split into segments separated by dots, dashes, underscores
+// and plus signs. Segments are compared numerically when both are numbers
+// and lexically otherwise. Missing segments are treated as zero.
+func CompareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var av, bv string
+		if i < len(as) {
+			av = as[i]
+		}
+		if i < len(bs) {
+			bv = bs[i]
+		}
+		if c := compareSegments(av, bv); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func versionSegments(v string) []string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == '.' || r == '-' || r == '_' || r == '+'
+	})
+}
+
+func compareSegments(a, b string) int {
+	if a == "" {
+		a = "0"
+	}
+	if b == "" {
+		b = "0"
+	}
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		default:
+			return 0
+		}
+	case aErr == nil:
+		// Numeric segments sort after textual ones, so that 1.0 > 1.0-beta
+		return 1
+	case bErr == nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
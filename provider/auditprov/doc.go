@@ -0,0 +1,2 @@
+// Package auditprov provides lease history auditing.
+package auditprov
@@ -0,0 +1,175 @@
+package auditprov
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/scjalliance/resourceful/audit"
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// Provider records the lifecycle of the leases committed through it in an
+// audit store.
+//
+// Renewals are not recorded individually. The number of times a lease was
+// renewed is summarized when it is released, expires or is deleted.
+type Provider struct {
+	source lease.Provider
+	store  audit.Store
+	log    *log.Logger // Receives errors that occur while recording, may be nil
+
+	mutex    sync.Mutex
+	renewals map[lease.Subject]uint // Renewals of each lease that have not been summarized
+}
+
+// New returns a new auditing provider that records the history of leases
+// in store.
+func New(source lease.Provider, store audit.Store, logger *log.Logger) *Provider {
+	return &Provider{
+		source:   source,
+		store:    store,
+		log:      logger,
+		renewals: make(map[lease.Subject]uint),
+	}
+}
+
+// Close releases any resources consumed by the provider, its source and its
+// store.
+func (p *Provider) Close() error {
+	err := p.source.Close()
+	if storeErr := p.store.Close(); err == nil {
+		err = storeErr
+	}
+	return err
+}
+
+// ProviderName returns the name of the provider.
+func (p *Provider) ProviderName() string {
+	return fmt.Sprintf("%s (with audited history)", p.source.ProviderName())
+}
+
+// LeaseResources returns all of the resources with lease data.
+func (p *Provider) LeaseResources() (resources []string, err error) {
+	return p.source.LeaseResources()
+}
+
+// LeaseView returns the current revision and lease set for the resource.
+func (p *Provider) LeaseView(resource string) (revision uint64, leases lease.Set, err error) {
+	return p.source.LeaseView(resource)
+}
+
+// LeaseCommit will attempt to apply the operations described in the lease
+// transaction.
+func (p *Provider) LeaseCommit(tx *lease.Tx) error {
+	err := p.source.LeaseCommit(tx)
+	if err == nil {
+		p.record(time.Now(), tx)
+	}
+	return err
+}
+
+// LeaseCommitAll will attempt to apply the operations described in each of
+// the lease transactions atomically.
+func (p *Provider) LeaseCommitAll(txs ...*lease.Tx) error {
+	err := p.source.LeaseCommitAll(txs...)
+	if err == nil {
+		p.record(time.Now(), txs...)
+	}
+	return err
+}
+
+// record adds records describing the effects of the committed transactions
+// to the store.
+func (p *Provider) record(at time.Time, txs ...*lease.Tx) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var records []audit.Record
+	for _, tx := range txs {
+		records = append(records, p.records(tx, at)...)
+	}
+
+	if err := p.store.Add(records...); err != nil && p.log != nil {
+		p.log.Printf("Failed to record lease history: %v", err)
+	}
+}
+
+// records returns the records that describe the effects of tx.
+//
+// records assumes that a lock is held for the duration of the call.
+func (p *Provider) records(tx *lease.Tx, at time.Time) (records []audit.Record) {
+	var operator string
+
+	add := func(event audit.Event, ls lease.Lease) {
+		r := audit.NewRecord(at, event, ls)
+		r.Operator = operator
+		records = append(records, r)
+	}
+
+	// Summarize the renewals of a lease that is ending
+	end := func(ls lease.Lease) {
+		if n := p.renewals[ls.Subject]; n > 0 {
+			r := audit.NewRecord(at, audit.Renew, ls)
+			r.Renewals = n
+			records = append(records, r)
+		}
+		delete(p.renewals, ls.Subject)
+	}
+
+	for _, op := range tx.Ops() {
+		prev, next := op.Previous, op.Lease
+		operator = op.Operator
+
+		switch op.Type {
+		case lease.Create:
+			add(audit.Create, next)
+		case lease.Delete:
+			end(prev)
+			add(audit.Delete, prev)
+		case lease.Update:
+			if prev.Subject != next.Subject {
+				// The lease took the place of another one
+				end(prev)
+				add(audit.Delete, prev)
+				add(audit.Create, next)
+				continue
+			}
+
+			switch {
+			case prev.Status == next.Status:
+				if next.Renewed.After(prev.Renewed) {
+					p.renewals[next.Subject]++
+				}
+			case next.Status == lease.Released:
+				end(prev)
+				if expired(prev, next) {
+					add(audit.Expire, next)
+				} else {
+					add(audit.Release, next)
+				}
+			case next.Status == lease.Borrowed:
+				add(audit.Borrow, next)
+			case prev.Status == lease.Released:
+				// Renewal of a released lease begins a new session
+				add(audit.Create, next)
+			case prev.Status == lease.Queued && next.Status == lease.Active:
+				add(audit.Promote, next)
+			case prev.Status == lease.Active && next.Status == lease.Queued:
+				add(audit.Demote, next)
+			}
+		}
+	}
+
+	return
+}
+
+// expired returns true if prev was released as next because it expired or
+// reached the maximum session length.
+func expired(prev, next lease.Lease) bool {
+	if next.Released.Equal(prev.ExpirationTime()) {
+		return true
+	}
+	return prev.MaxSession > 0 && next.Released.Equal(prev.Started.Add(prev.MaxSession))
+}
@@ -1,8 +1,10 @@
 package boltprov
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/boltdb/bolt"
 	"github.com/scjalliance/resourceful/lease"
@@ -14,9 +16,19 @@ const (
 	ResourcefulBucket = "resourceful"
 	// LeaseBucket is the name of the resourceful lease bucket.
 	LeaseBucket = "lease"
+	// RevisionBucket is the name of the resourceful revision bucket, which
+	// holds the current revision of each resource.
+	RevisionBucket = "revision"
 )
 
 // Provider provides boltdb-backed lease management.
+//
+// Each resource has its own revision, so that transactions for different
+// resources don't conflict with one another. Earlier versions of the
+// provider used the sequence of the lease bucket as a revision shared by
+// every resource. That sequence is no longer advanced, and serves as the
+// initial revision of resources that haven't been committed since. Existing
+// databases are migrated the first time a transaction is committed.
 type Provider struct {
 	db   *bolt.DB
 	root []byte
@@ -78,7 +90,7 @@ func (p *Provider) LeaseView(resource string) (revision uint64, leases lease.Set
 			return nil
 		}
 
-		revision = container.Sequence()
+		revision = leaseRevision(root, container, []byte(resource))
 
 		data := container.Get([]byte(resource))
 		if data == nil {
@@ -93,14 +105,29 @@ func (p *Provider) LeaseView(resource string) (revision uint64, leases lease.Set
 // LeaseCommit will attempt to apply the operations described in the lease
 // transaction.
 func (p *Provider) LeaseCommit(tx *lease.Tx) error {
-	ops := tx.Ops()
-	if len(ops) == 0 {
-		// Nothing to commit
+	return p.LeaseCommitAll(tx)
+}
+
+// LeaseCommitAll will attempt to apply the operations described in each of
+// the lease transactions atomically.
+func (p *Provider) LeaseCommitAll(txs ...*lease.Tx) error {
+	var pending []*lease.Tx
+	seen := make(map[string]bool, len(txs))
+	for _, tx := range txs {
+		if len(tx.Ops()) == 0 {
+			// Nothing to commit
+			continue
+		}
+		if seen[tx.Resource()] {
+			return fmt.Errorf("Unable to commit more than one lease transaction for resource \"%s\"", tx.Resource())
+		}
+		seen[tx.Resource()] = true
+		pending = append(pending, tx)
+	}
+	if len(pending) == 0 {
 		return nil
 	}
 
-	leases := tx.Leases()
-
 	return p.db.Update(func(btx *bolt.Tx) error {
 		root, err := btx.CreateBucketIfNotExists(p.root)
 		if err != nil {
@@ -112,24 +139,57 @@ func (p *Provider) LeaseCommit(tx *lease.Tx) error {
 			return err
 		}
 
-		if container.Sequence() != tx.Revision() {
-			return errors.New("Unable to commit lease transaction due to opportunistic lock conflict")
-		}
-
-		_, err = container.NextSequence()
+		revisions, err := root.CreateBucketIfNotExists([]byte(RevisionBucket))
 		if err != nil {
 			return err
 		}
 
-		key := []byte(tx.Resource())
-		if len(leases) == 0 {
-			return container.Delete(key)
+		for _, tx := range pending {
+			if leaseRevision(root, container, []byte(tx.Resource())) != tx.Revision() {
+				return errors.New("Unable to commit lease transaction due to opportunistic lock conflict")
+			}
 		}
 
-		value, err := json.Marshal(leases)
-		if err != nil {
-			return err
+		for _, tx := range pending {
+			leases := tx.Leases()
+			key := []byte(tx.Resource())
+
+			// The revision is kept when the leases are deleted, so that it
+			// never returns to a value that a stale transaction was based on
+			revision := make([]byte, 8)
+			binary.BigEndian.PutUint64(revision, tx.Revision()+1)
+			if err := revisions.Put(key, revision); err != nil {
+				return err
+			}
+
+			if len(leases) == 0 {
+				if err := container.Delete(key); err != nil {
+					return err
+				}
+				continue
+			}
+
+			value, err := json.Marshal(leases)
+			if err != nil {
+				return err
+			}
+			if err := container.Put(key, value); err != nil {
+				return err
+			}
 		}
-		return container.Put(key, value)
+
+		return nil
 	})
 }
+
+// leaseRevision returns the current revision of the resource with the given
+// key. Resources that haven't been committed since the database was migrated
+// take their revision from the sequence of the lease bucket.
+func leaseRevision(root, container *bolt.Bucket, key []byte) uint64 {
+	if revisions := root.Bucket([]byte(RevisionBucket)); revisions != nil {
+		if value := revisions.Get(key); len(value) == 8 {
+			return binary.BigEndian.Uint64(value)
+		}
+	}
+	return container.Sequence()
+}
@@ -0,0 +1,211 @@
+package boltprov
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/scjalliance/resourceful/lease"
+)
+
+func newProvider(t *testing.T) *Provider {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "leases.boltdb"), 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(db)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func testLease(resource, id string) lease.Lease {
+	return lease.Lease{
+		Subject: lease.Subject{
+			Resource: resource,
+			Instance: lease.Instance{Host: "host", User: "user", ID: id},
+		},
+		Status:  lease.Active,
+		Started: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Limit:   1,
+	}
+}
+
+// view returns the current revision and leases of resource, failing the
+// test if they can't be retrieved.
+func view(t *testing.T, p *Provider, resource string) (uint64, lease.Set) {
+	t.Helper()
+	revision, leases, err := p.LeaseView(resource)
+	if err != nil {
+		t.Fatalf("LeaseView(%q): %v", resource, err)
+	}
+	return revision, leases
+}
+
+func TestLeaseCommitDistinctResources(t *testing.T) {
+	p := newProvider(t)
+
+	// Transactions for a and b are prepared before either is committed
+	revA, leasesA := view(t, p, "a")
+	revB, leasesB := view(t, p, "b")
+	a := lease.NewTx("a", revA, leasesA)
+	a.Create(testLease("a", "1"))
+	staleA := lease.NewTx("a", revA, leasesA)
+	staleA.Create(testLease("a", "2"))
+	b := lease.NewTx("b", revB, leasesB)
+	b.Create(testLease("b", "1"))
+
+	if err := p.LeaseCommit(a); err != nil {
+		t.Fatalf("commit of a: %v", err)
+	}
+	if err := p.LeaseCommit(b); err != nil {
+		t.Fatalf("commit of b after a: %v", err)
+	}
+	if err := p.LeaseCommit(staleA); err == nil {
+		t.Fatal("stale commit of a succeeded")
+	}
+
+	if revision, leases := view(t, p, "a"); revision != 1 || len(leases) != 1 || leases[0].Instance.ID != "1" {
+		t.Fatalf("a: got revision %d with leases %v, want revision 1 with instance 1", revision, leases)
+	}
+	if revision, leases := view(t, p, "b"); revision != 1 || len(leases) != 1 {
+		t.Fatalf("b: got revision %d with %d leases, want revision 1 with 1", revision, len(leases))
+	}
+}
+
+func TestLeaseCommitLegacyRevision(t *testing.T) {
+	p := newProvider(t)
+
+	// Build a database in the layout used before resources had revisions of
+	// their own, in which the lease bucket's sequence was shared by all of
+	// them
+	const sequence = 7
+	err := p.db.Update(func(btx *bolt.Tx) error {
+		root, err := btx.CreateBucket(p.root)
+		if err != nil {
+			return err
+		}
+		container, err := root.CreateBucket([]byte(LeaseBucket))
+		if err != nil {
+			return err
+		}
+		for _, resource := range []string{"a", "b"} {
+			value, err := json.Marshal(lease.Set{testLease(resource, "1")})
+			if err != nil {
+				return err
+			}
+			if err := container.Put([]byte(resource), value); err != nil {
+				return err
+			}
+		}
+		return container.SetSequence(sequence)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	revA, leasesA := view(t, p, "a")
+	revB, leasesB := view(t, p, "b")
+	if revA != sequence || len(leasesA) != 1 || revB != sequence || len(leasesB) != 1 {
+		t.Fatalf("got a at revision %d with %d leases and b at revision %d with %d leases, want both at revision %d with 1", revA, len(leasesA), revB, len(leasesB), sequence)
+	}
+
+	a := lease.NewTx("a", revA, leasesA)
+	a.Delete(lease.Instance{Host: "host", User: "user", ID: "1"})
+	if err := p.LeaseCommit(a); err != nil {
+		t.Fatalf("commit of a: %v", err)
+	}
+
+	// The revision of a advances from the sequence and is kept after its
+	// last lease is deleted, while b still falls back to the sequence
+	if revision, leases := view(t, p, "a"); revision != sequence+1 || len(leases) != 0 {
+		t.Fatalf("a: got revision %d with %d leases, want revision %d with none", revision, len(leases), sequence+1)
+	}
+	if revision, _ := view(t, p, "b"); revision != sequence {
+		t.Fatalf("b: got revision %d, want %d", revision, sequence)
+	}
+
+	stale := lease.NewTx("a", revA, leasesA)
+	stale.Create(testLease("a", "2"))
+	if err := p.LeaseCommit(stale); err == nil {
+		t.Fatal("stale commit of a succeeded")
+	}
+
+	b := lease.NewTx("b", revB, leasesB)
+	b.Create(testLease("b", "2"))
+	if err := p.LeaseCommit(b); err != nil {
+		t.Fatalf("commit of b: %v", err)
+	}
+	if revision, leases := view(t, p, "b"); revision != sequence+1 || len(leases) != 2 {
+		t.Fatalf("b: got revision %d with %d leases, want revision %d with 2", revision, len(leases), sequence+1)
+	}
+}
+
+// BenchmarkLeaseCommit measures the rate of opportunistic lock conflicts
+// when transactions are committed concurrently.
+//
+// When each goroutine works on a resource of its own, commits for one
+// resource don't invalidate transactions for the others.
+func BenchmarkLeaseCommit(b *testing.B) {
+	b.Run("SharedResource", func(b *testing.B) {
+		benchmarkLeaseCommit(b, func(int64) string { return "shared" })
+	})
+	b.Run("DistinctResources", func(b *testing.B) {
+		benchmarkLeaseCommit(b, func(n int64) string { return fmt.Sprintf("resource-%d", n) })
+	})
+}
+
+func benchmarkLeaseCommit(b *testing.B, resource func(n int64) string) {
+	db, err := bolt.Open(filepath.Join(b.TempDir(), "leases.boltdb"), 0666, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	p := New(db)
+	defer p.Close()
+
+	var goroutines, commits, conflicts int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		n := atomic.AddInt64(&goroutines, 1)
+		res := resource(n)
+		ls := lease.Lease{
+			Subject: lease.Subject{
+				Resource: res,
+				Instance: lease.Instance{Host: fmt.Sprintf("host-%d", n), User: "user", ID: "id"},
+			},
+			Status:  lease.Active,
+			Started: time.Now(),
+			Limit:   1,
+		}
+
+		for pb.Next() {
+			revision, leases, err := p.LeaseView(res)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+
+			tx := lease.NewTx(res, revision, leases)
+			if _, found := tx.Instance(ls.Instance); found {
+				tx.Delete(ls.Instance)
+			} else {
+				tx.Create(ls)
+			}
+
+			atomic.AddInt64(&commits, 1)
+			if err := p.LeaseCommit(tx); err != nil {
+				atomic.AddInt64(&conflicts, 1)
+			}
+		}
+	})
+	b.StopTimer()
+
+	if commits > 0 {
+		b.ReportMetric(float64(conflicts)/float64(commits), "conflicts/op")
+	}
+}
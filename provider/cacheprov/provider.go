@@ -8,10 +8,14 @@ import (
 )
 
 // Provider is a cached source of policy data.
+//
+// Policies are retrieved from the source once and then cached until the
+// provider is asked to reload them.
 type Provider struct {
 	Source   policy.Provider
 	mutex    sync.RWMutex
 	cached   bool
+	version  string
 	policies []policy.Policy
 }
 
@@ -36,14 +40,64 @@ func (p *Provider) Policies() (policies policy.Set, err error) {
 	return
 }
 
+// Changed returns true if the policies of the source might have changed since
+// they were last cached. Sources that are unable to detect changes are
+// always considered changed.
+//
+// If the source is unable to determine its policy version, Changed returns
+// false along with the error.
+func (p *Provider) Changed() (bool, error) {
+	versioner, ok := p.Source.(policy.Versioner)
+	if !ok {
+		return true, nil
+	}
+
+	version, err := versioner.PolicyVersion()
+	if err != nil {
+		return false, err
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return !p.cached || version != p.version, nil
+}
+
+// Reload retrieves a fresh set of policies from the source and replaces the
+// cached policies with them. It returns the new set of policies along with
+// the additions and deletions when compared with the previous set.
+//
+// If the source returns an error the previously cached policies are
+// retained and returned along with the error. The source will not be
+// considered changed again until it is modified further.
+func (p *Provider) Reload() (policies, additions, deletions policy.Set, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	version := p.sourceVersion()
+	updated, err := p.Source.Policies()
+	if err != nil {
+		p.version = version
+		return p.policies, nil, nil, err
+	}
+
+	additions, deletions = policy.Set(p.policies).Diff(updated)
+	p.policies = updated
+	p.version = version
+	p.cached = true
+
+	return updated, additions, deletions, nil
+}
+
 func (p *Provider) pull() (policies policy.Set, err error) {
 	p.mutex.RLock()
 	if !p.cached {
 		p.mutex.RUnlock()
 		p.mutex.Lock()
 		if !p.cached {
+			version := p.sourceVersion()
 			p.policies, err = p.Source.Policies()
 			if err == nil {
+				p.version = version
 				p.cached = true
 			}
 		}
@@ -54,3 +108,18 @@ func (p *Provider) pull() (policies policy.Set, err error) {
 	p.mutex.RUnlock()
 	return
 }
+
+// sourceVersion returns the current policy version of the source, if it
+// is able to provide one. The version must be retrieved before the policies
+// are so that changes made while they're being loaded aren't missed.
+func (p *Provider) sourceVersion() string {
+	versioner, ok := p.Source.(policy.Versioner)
+	if !ok {
+		return ""
+	}
+	version, err := versioner.PolicyVersion()
+	if err != nil {
+		return ""
+	}
+	return version
+}
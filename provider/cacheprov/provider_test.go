@@ -0,0 +1,76 @@
+package cacheprov
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/policy"
+	"github.com/scjalliance/resourceful/strategy"
+)
+
+// versionedSource is a policy source that reports a version, or an error
+// when err is set.
+type versionedSource struct {
+	version string
+	err     error
+}
+
+func (s *versionedSource) ProviderName() string { return "test" }
+
+func (s *versionedSource) Close() error { return nil }
+
+func (s *versionedSource) Policies() (policy.Set, error) {
+	return policy.Set{policy.New("cad", strategy.Instance, 1, time.Minute, nil)}, nil
+}
+
+func (s *versionedSource) PolicyVersion() (string, error) {
+	return s.version, s.err
+}
+
+func TestChanged(t *testing.T) {
+	source := &versionedSource{version: "1"}
+	p := New(source)
+
+	if changed, err := p.Changed(); err != nil || !changed {
+		t.Errorf("before caching: got changed %t and error %v, want true and no error", changed, err)
+	}
+
+	if _, err := p.Policies(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := p.Changed(); err != nil || changed {
+		t.Errorf("after caching: got changed %t and error %v, want false and no error", changed, err)
+	}
+
+	source.version = "2"
+	if changed, err := p.Changed(); err != nil || !changed {
+		t.Errorf("after modification: got changed %t and error %v, want true and no error", changed, err)
+	}
+
+	if _, _, _, err := p.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := p.Changed(); err != nil || changed {
+		t.Errorf("after reload: got changed %t and error %v, want false and no error", changed, err)
+	}
+}
+
+func TestChangedVersionError(t *testing.T) {
+	source := &versionedSource{version: "1"}
+	p := New(source)
+	if _, err := p.Policies(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A source that can't report its version must not cause a reload on
+	// every poll
+	source.err = errors.New("policy directory is unavailable")
+	changed, err := p.Changed()
+	if err == nil {
+		t.Error("expected an error")
+	}
+	if changed {
+		t.Error("source was considered changed")
+	}
+}
@@ -1,14 +1,19 @@
 package fsprov
 
 import (
+	"bufio"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 
+	"github.com/scjalliance/resourceful/lease"
 	"github.com/scjalliance/resourceful/policy"
 	"github.com/scjalliance/resourceful/strategy"
+	"golang.org/x/crypto/sha3"
 )
 
 // Provider is a filesystem-based source of policy data. The policies are read
@@ -67,6 +72,18 @@ func (p *Provider) Policies() (policies policy.Set, err error) {
 			return
 		}
 
+		compiled, compileErr := pol.Compile()
+		if compileErr != nil {
+			err = fmt.Errorf("invalid policy in \"%s\": %v", path, compileErr)
+			return
+		}
+		pol = compiled
+
+		if templateErr := lease.ValidateConsumerTemplate(pol.Consumer); templateErr != nil {
+			err = fmt.Errorf("invalid policy consumer in \"%s\": %v", path, templateErr)
+			return
+		}
+
 		if pol.Duration == 0 {
 			pol.Duration = policy.DefaultDuration
 		}
@@ -77,6 +94,39 @@ func (p *Provider) Policies() (policies policy.Set, err error) {
 	return
 }
 
+// PolicyVersion returns a hash of the name, size and modification time of
+// each *.pol file in the policy directory. The value changes whenever a
+// policy file is added, removed or modified.
+func (p *Provider) PolicyVersion() (string, error) {
+	files, dirErr := ioutil.ReadDir(p.path)
+	if dirErr != nil {
+		return "", fmt.Errorf("unable to access policy directory \"%s\": %v", p.path, dirErr)
+	}
+
+	var (
+		hash = sha3.New224()
+		w    = bufio.NewWriterSize(hash, hash.BlockSize())
+	)
+
+	for _, file := range files {
+		if !isPolicyFile(file) {
+			continue
+		}
+		w.WriteString(file.Name())
+		w.WriteByte(0)
+		w.WriteString(strconv.FormatInt(file.Size(), 10))
+		w.WriteByte(0)
+		w.WriteString(strconv.FormatInt(file.ModTime().UnixNano(), 10))
+		w.WriteByte(0)
+	}
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
 // SetPolicies will return update the set of policies within the policy
 // directory to match the given set. The policy files created will have
 // names matching the content hash of each policy. This function will
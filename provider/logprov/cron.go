@@ -0,0 +1,191 @@
+package logprov
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronDescriptors map the predefined cron descriptors to the expressions
+// they stand for.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonths = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	cronDays   = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// cronField is the set of values matched by a field of a cron expression.
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronExpr is a parsed five-field cron expression.
+type cronExpr struct {
+	minute, hour, dom, month, dow cronField
+	domAny, dowAny                bool // Whether the day fields were unrestricted
+}
+
+// parseCron parses a standard five-field cron expression or one of the
+// predefined descriptors, such as @daily.
+func parseCron(s string) (*cronExpr, error) {
+	if strings.HasPrefix(s, "@") {
+		expr, ok := cronDescriptors[strings.ToLower(s)]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized descriptor \"%s\"", s)
+		}
+		s = expr
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields but found %d", len(fields))
+	}
+
+	var (
+		c   cronExpr
+		err error
+	)
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, cronDays); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	if c.dow.has(7) {
+		// Sunday may be written as 0 or 7
+		c.dow |= 1
+	}
+	c.domAny = strings.HasPrefix(fields[2], "*") || fields[2] == "?"
+	c.dowAny = strings.HasPrefix(fields[4], "*") || fields[4] == "?"
+
+	return &c, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+// that fall between min and max.
+func parseCronField(s string, min, max int, names map[string]int) (field cronField, err error) {
+	for _, item := range strings.Split(s, ",") {
+		lo, hi, step := min, max, 1
+
+		rng := item
+		if i := strings.Index(item, "/"); i >= 0 {
+			rng = item[:i]
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in \"%s\"", item)
+			}
+		}
+
+		switch {
+		case rng == "*" || rng == "?":
+		case strings.Contains(rng, "-"):
+			parts := strings.SplitN(rng, "-", 2)
+			if lo, err = parseCronValue(parts[0], names); err != nil {
+				return 0, err
+			}
+			if hi, err = parseCronValue(parts[1], names); err != nil {
+				return 0, err
+			}
+		default:
+			if lo, err = parseCronValue(rng, names); err != nil {
+				return 0, err
+			}
+			if strings.Contains(item, "/") {
+				// A value with a step, such as 5/15, runs to the maximum
+				hi = max
+			} else {
+				hi = lo
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("\"%s\" is outside of the range %d-%d", item, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			field |= 1 << uint(v)
+		}
+	}
+	return field, nil
+}
+
+func parseCronValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value \"%s\"", s)
+	}
+	return v, nil
+}
+
+// dayMatches returns true if the day of t matches the expression. When both
+// day fields are restricted a day matching either of them is accepted.
+func (c *cronExpr) dayMatches(t time.Time) bool {
+	dom := c.dom.has(t.Day())
+	dow := c.dow.has(int(t.Weekday()))
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Next returns the first time after t that matches the expression, in the
+// location of t. It returns the zero time if there is no such time within
+// the next five years.
+func (c *cronExpr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.hour.has(t.Hour()) {
+			// Advance by elapsed time rather than by wall clock so that
+			// daylight saving transitions can't hold us back
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,145 @@
+package logprov
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/txlog"
+)
+
+// encoder writes transaction log entries in a particular format.
+type encoder interface {
+	Effect(at time.Time, tx *lease.Tx, effect lease.Effect, operator string)
+	CheckpointStart(at time.Time)
+	CheckpointLease(at time.Time, revision uint64, ls lease.Lease)
+	CheckpointError(at time.Time, resource string, err error)
+	CheckpointEnd(at time.Time)
+}
+
+// textEncoder writes entries as lines of text through a logger.
+//
+// Every effect and lease is written on a detail line, marked by a plus sign,
+// that records the number of units consumed and, for leases recorded by a
+// checkpoint, their start times. Effects and leases that consume
+// units of a resource are also written on a plain line, in the form that has
+// always been used, so that existing parsers of the log continue to work.
+// Parsers that only look for plain lines skip detail lines, and with them
+// the queue.
+type textEncoder struct {
+	log *log.Logger
+}
+
+func (e textEncoder) Effect(at time.Time, tx *lease.Tx, effect lease.Effect, operator string) {
+	// Administrative actions are attributed to the operator that took them
+	var by string
+	if operator != "" {
+		by = " BY " + formatOperator(operator)
+	}
+
+	e.log.Printf("TX+ %s UNITS %d%s", effect.String(), effect.Weight(), by)
+	if effect.Consumptive() {
+		e.log.Printf("TX %s%s", effect.String(), by)
+	}
+}
+
+// formatOperator returns operator as it is written in text entries. Operators
+// that contain spaces, quotes or unprintable characters are quoted, so that
+// they can be told apart from the rest of the entry.
+func formatOperator(operator string) string {
+	plain := strings.IndexFunc(operator, func(r rune) bool {
+		return r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) < 0
+	if plain {
+		return operator
+	}
+	return strconv.Quote(operator)
+}
+
+func (e textEncoder) CheckpointStart(at time.Time) {
+	e.log.Printf("CP %v START", at.UnixNano())
+}
+
+func (e textEncoder) CheckpointLease(at time.Time, revision uint64, ls lease.Lease) {
+	status := strings.ToUpper(string(ls.Status))
+	detail := fmt.Sprintf("CP+ %v LEASE %s %s UNITS %d", at.UnixNano(), ls.Subject, status, ls.Weight())
+	if !ls.Started.IsZero() {
+		detail += fmt.Sprintf(" STARTED %d", ls.Started.UnixNano())
+	}
+	e.log.Print(detail)
+	if ls.Consumptive() {
+		e.log.Printf("CP %v LEASE %s %s", at.UnixNano(), ls.Subject, status)
+	}
+}
+
+func (e textEncoder) CheckpointError(at time.Time, resource string, err error) {
+	e.log.Printf("CP %v RESOURCE %s ERR %v", at.UnixNano(), resource, err)
+}
+
+func (e textEncoder) CheckpointEnd(at time.Time) {
+	e.log.Printf("CP %v END", at.UnixNano())
+}
+
+// jsonEncoder writes entries as structured records, one line of JSON each.
+type jsonEncoder struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (e *jsonEncoder) Effect(at time.Time, tx *lease.Tx, effect lease.Effect, operator string) {
+	e.write(txlog.Record{
+		Type:     txlog.TransactionRecord,
+		Time:     at,
+		Resource: tx.Resource(),
+		Revision: tx.Revision(),
+		Effect:   &effect,
+		Operator: operator,
+	})
+}
+
+func (e *jsonEncoder) CheckpointStart(at time.Time) {
+	e.write(txlog.Record{Type: txlog.CheckpointStartRecord, Time: at})
+}
+
+func (e *jsonEncoder) CheckpointLease(at time.Time, revision uint64, ls lease.Lease) {
+	e.write(txlog.Record{
+		Type:     txlog.CheckpointLeaseRecord,
+		Time:     at,
+		Resource: ls.Resource,
+		Revision: revision,
+		Lease:    &ls,
+	})
+}
+
+func (e *jsonEncoder) CheckpointError(at time.Time, resource string, err error) {
+	e.write(txlog.Record{
+		Type:     txlog.CheckpointErrorRecord,
+		Time:     at,
+		Resource: resource,
+		Error:    err.Error(),
+	})
+}
+
+func (e *jsonEncoder) CheckpointEnd(at time.Time) {
+	e.write(txlog.Record{Type: txlog.CheckpointEndRecord, Time: at})
+}
+
+// write writes the record as a single line.
+func (e *jsonEncoder) write(record txlog.Record) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.w.Write(data)
+}
@@ -0,0 +1,385 @@
+package logprov
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/txlog"
+)
+
+// TimeLayout is the layout of the timestamp that begins each line of a
+// transaction log written by a logger with standard flags.
+const TimeLayout = "2006/01/02 15:04:05"
+
+// EntryType identifies the type of a transaction log entry.
+type EntryType string
+
+// Transaction log entry types
+const (
+	TransactionEntry     EntryType = "TX"       // An effect of a committed transaction
+	CheckpointStartEntry EntryType = "CP START" // The start of a checkpoint block
+	CheckpointLeaseEntry EntryType = "CP LEASE" // A lease recorded within a checkpoint block
+	CheckpointErrorEntry EntryType = "CP ERR"   // A resource that could not be recorded within a checkpoint block
+	CheckpointEndEntry   EntryType = "CP END"   // The end of a checkpoint block
+)
+
+// Entry is a parsed line of a transaction log.
+//
+// The lease of an entry parsed from a line of text only describes the
+// subject and status of the lease that was affected, and for detail lines
+// its units and, for checkpoint entries, its start time. Entries parsed from structured records describe the entire
+// lease.
+type Entry struct {
+	Type     EntryType
+	Time     time.Time
+	Action   lease.Action // Create or Delete, for transaction entries
+	Lease    lease.Lease
+	Operator string // Administrator that took the action, if any
+	Detail   bool   // Parsed from a detail line of text
+}
+
+// ParseEntry parses a line of a transaction log, which may be a line of
+// text or a structured record. Timestamps of lines of text are interpreted in
+// the given location.
+//
+// It returns ok as false if the line is not a transaction or checkpoint
+// entry.
+func ParseEntry(line string, loc *time.Location) (entry Entry, ok bool, err error) {
+	if txlog.IsRecord([]byte(line)) {
+		var record txlog.Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return entry, false, err
+		}
+		return EntryFromRecord(record)
+	}
+
+	// Each line is expected to begin with a timestamp
+	if len(line) > len(TimeLayout) && line[len(TimeLayout)] == ' ' {
+		if at, err := time.ParseInLocation(TimeLayout, line[:len(TimeLayout)], loc); err == nil {
+			entry.Time = at
+			line = line[len(TimeLayout)+1:]
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(line, "TX "):
+		err = parseTransaction(&entry, line[3:])
+	case strings.HasPrefix(line, "TX+ "):
+		entry.Detail = true
+		err = parseTransaction(&entry, line[4:])
+	case strings.HasPrefix(line, "CP "):
+		err = parseCheckpoint(&entry, line[3:])
+	case strings.HasPrefix(line, "CP+ "):
+		entry.Detail = true
+		err = parseCheckpoint(&entry, line[4:])
+	default:
+		return entry, false, nil
+	}
+
+	if err != nil {
+		return entry, false, err
+	}
+
+	if entry.Time.IsZero() {
+		return entry, false, fmt.Errorf("entry has no timestamp")
+	}
+
+	return entry, true, nil
+}
+
+// EntryFromRecord returns the entry described by a structured record.
+//
+// It returns ok as false if the record is of an unrecognized type.
+func EntryFromRecord(record txlog.Record) (entry Entry, ok bool, err error) {
+	entry.Time = record.Time
+	entry.Operator = record.Operator
+
+	switch record.Type {
+	case txlog.TransactionRecord:
+		if record.Effect == nil {
+			return entry, false, fmt.Errorf("transaction record has no effect")
+		}
+		entry.Type = TransactionEntry
+		entry.Action = record.Effect.Action
+		entry.Lease = record.Effect.Lease
+	case txlog.CheckpointStartRecord:
+		entry.Type = CheckpointStartEntry
+	case txlog.CheckpointLeaseRecord:
+		if record.Lease == nil {
+			return entry, false, fmt.Errorf("checkpoint record has no lease")
+		}
+		entry.Type = CheckpointLeaseEntry
+		entry.Lease = *record.Lease
+	case txlog.CheckpointErrorRecord:
+		entry.Type = CheckpointErrorEntry
+		entry.Lease.Resource = record.Resource
+	case txlog.CheckpointEndRecord:
+		entry.Type = CheckpointEndEntry
+	default:
+		return entry, false, nil
+	}
+
+	return entry, true, nil
+}
+
+// parseTransaction parses the effect described by a transaction entry. The
+// effect is formatted as "<host> <user> <id> <action> <status> <resource>",
+// followed by "UNITS <n>" on detail lines and optionally by "BY <operator>".
+// The operator is quoted if it contains spaces or quotes.
+func parseTransaction(entry *Entry, s string) error {
+	entry.Type = TransactionEntry
+
+	s, operator, err := cutOperator(s)
+	if err != nil {
+		return fmt.Errorf("invalid transaction entry \"%s\": %v", s, err)
+	}
+	entry.Operator = operator
+
+	fields := strings.Split(s, " ")
+
+	// Find the action, which follows the instance
+	a := -1
+	for i := 3; i+2 < len(fields); i++ {
+		if parseAction(fields[i]) != lease.None && parseStatus(fields[i+1]) != "" {
+			a = i
+			break
+		}
+	}
+	if a < 0 {
+		return fmt.Errorf("invalid transaction entry \"%s\"", s)
+	}
+
+	// Host names and instance identifiers don't contain spaces, but user
+	// names might
+	entry.Lease.Instance = lease.Instance{
+		Host: fields[0],
+		User: strings.Join(fields[1:a-1], " "),
+		ID:   fields[a-1],
+	}
+	entry.Action = parseAction(fields[a])
+	entry.Lease.Status = parseStatus(fields[a+1])
+
+	rest := fields[a+2:]
+	if entry.Detail {
+		if rest, err = parseSuffixes(entry, rest); err != nil {
+			return err
+		}
+	}
+	if len(rest) == 0 {
+		return fmt.Errorf("invalid transaction entry \"%s\": resource not specified", s)
+	}
+	entry.Lease.Resource = strings.Join(rest, " ")
+
+	return nil
+}
+
+// parseCheckpoint parses a checkpoint entry. Checkpoint entries are formatted
+// as "<nanoseconds> <marker> ...".
+func parseCheckpoint(entry *Entry, s string) error {
+	fields := strings.Split(s, " ")
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid checkpoint entry \"%s\"", s)
+	}
+
+	nano, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint entry \"%s\": %v", s, err)
+	}
+	entry.Time = time.Unix(0, nano)
+
+	switch fields[1] {
+	case "START":
+		entry.Type = CheckpointStartEntry
+	case "END":
+		entry.Type = CheckpointEndEntry
+	case "RESOURCE":
+		// "RESOURCE <resource> ERR <error>"
+		entry.Type = CheckpointErrorEntry
+		rest := strings.Join(fields[2:], " ")
+		if i := strings.Index(rest, " ERR"); i >= 0 {
+			rest = rest[:i]
+		}
+		entry.Lease.Resource = rest
+	case "LEASE":
+		// "LEASE <resource>: <host> <user> <id> <status>", followed by
+		// "UNITS <n>" and "STARTED <nanoseconds>" on detail lines
+		entry.Type = CheckpointLeaseEntry
+		rest := strings.Join(fields[2:], " ")
+		i := strings.Index(rest, ": ")
+		if i < 0 {
+			return fmt.Errorf("invalid checkpoint entry \"%s\"", s)
+		}
+		entry.Lease.Resource = rest[:i]
+
+		fields = strings.Split(rest[i+2:], " ")
+		if entry.Detail {
+			if fields, err = parseSuffixes(entry, fields); err != nil {
+				return err
+			}
+		}
+		if len(fields) < 4 {
+			return fmt.Errorf("invalid checkpoint entry \"%s\"", s)
+		}
+		last := len(fields) - 1
+		entry.Lease.Status = parseStatus(fields[last])
+		if entry.Lease.Status == "" {
+			return fmt.Errorf("invalid checkpoint entry \"%s\": unrecognized status \"%s\"", s, fields[last])
+		}
+		entry.Lease.Instance = lease.Instance{
+			Host: fields[0],
+			User: strings.Join(fields[1:last-1], " "),
+			ID:   fields[last-1],
+		}
+	default:
+		return fmt.Errorf("invalid checkpoint entry \"%s\"", s)
+	}
+
+	return nil
+}
+
+// cutOperator removes the optional "BY <operator>" suffix from the end of a
+// transaction entry and returns the entry that precedes it along with the
+// operator.
+func cutOperator(s string) (rest, operator string, err error) {
+	// Quoted operators may contain spaces, but never an unescaped quote, so
+	// the last quote that follows BY starts the operator
+	if strings.HasSuffix(s, `"`) {
+		if i := strings.LastIndex(s, ` BY "`); i >= 0 {
+			operator, err := strconv.Unquote(s[i+4:])
+			if err != nil {
+				return s, "", fmt.Errorf("invalid operator %s", s[i+4:])
+			}
+			return s[:i], operator, nil
+		}
+	}
+	if i := strings.LastIndex(s, " BY "); i >= 0 && !strings.Contains(s[i+4:], " ") {
+		return s[:i], s[i+4:], nil
+	}
+	return s, "", nil
+}
+
+// parseSuffixes parses the "UNITS <n>" and optional "STARTED <nanoseconds>"
+// suffixes at the end of the fields of a detail line and returns the fields
+// that precede them.
+func parseSuffixes(entry *Entry, fields []string) ([]string, error) {
+	if n := len(fields); n >= 2 && fields[n-2] == "STARTED" {
+		nano, err := strconv.ParseInt(fields[n-1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start time \"%s\"", fields[n-1])
+		}
+		entry.Lease.Started = time.Unix(0, nano)
+		fields = fields[:n-2]
+	}
+	if n := len(fields); n >= 2 && fields[n-2] == "UNITS" {
+		units, err := strconv.ParseUint(fields[n-1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid units \"%s\"", fields[n-1])
+		}
+		entry.Lease.Units = uint(units)
+		fields = fields[:n-2]
+	}
+	return fields, nil
+}
+
+func parseAction(s string) lease.Action {
+	switch s {
+	case "CREATE":
+		return lease.Create
+	case "DELETE":
+		return lease.Delete
+	default:
+		return lease.None
+	}
+}
+
+func parseStatus(s string) lease.Status {
+	status := lease.Status(strings.ToLower(s))
+	if status.Order() > lease.Booked.Order() {
+		return ""
+	}
+	return status
+}
+
+// Scanner reads the entries of a transaction log.
+type Scanner struct {
+	scanner *bufio.Scanner
+	loc     *time.Location
+	line    int
+	entry   Entry
+	err     error
+
+	detailed bool // Whether a detail line has been read
+	plain    bool // Whether a plain line has been read without detail lines
+}
+
+// NewScanner returns a scanner that reads transaction log entries from r.
+// Timestamps are interpreted in the given location.
+func NewScanner(r io.Reader, loc *time.Location) *Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, txlog.MaxLineSize)
+	return &Scanner{
+		scanner: scanner,
+		loc:     loc,
+	}
+}
+
+// Scan advances the scanner to the next entry, which will then be available
+// through the Entry method. Lines that are not transaction or checkpoint
+// entries are skipped. It returns false when the scan stops, either by
+// reaching the end of the input or an error.
+//
+// Once a detail line has been read, the plain transaction and checkpoint
+// lease lines that repeat the detail lines for older parsers are skipped.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	for s.scanner.Scan() {
+		s.line++
+		line := s.scanner.Text()
+		entry, ok, err := ParseEntry(line, s.loc)
+		if err != nil {
+			s.err = fmt.Errorf("line %d: %v", s.line, err)
+			return false
+		}
+		if !ok {
+			continue
+		}
+		if entry.Detail {
+			s.detailed = true
+		} else if !txlog.IsRecord([]byte(line)) && (entry.Type == TransactionEntry || entry.Type == CheckpointLeaseEntry) {
+			if s.detailed {
+				continue
+			}
+			s.plain = true
+		}
+		s.entry = entry
+		return true
+	}
+	s.err = s.scanner.Err()
+	return false
+}
+
+// Entry returns the most recent entry read by a call to Scan.
+func (s *Scanner) Entry() Entry {
+	return s.entry
+}
+
+// Plain returns true if any of the transaction or checkpoint lease entries
+// that have been read came from a log written without detail lines. Such
+// entries don't record queued leases or the number of units consumed by
+// each lease.
+func (s *Scanner) Plain() bool {
+	return s.plain
+}
+
+// Err returns the first error that was encountered by the scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,134 @@
+package logprov
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+func TestParseEntryOperator(t *testing.T) {
+	effect := lease.Effect{
+		Action: lease.Delete,
+		Lease: lease.Lease{
+			Subject: lease.Subject{
+				Resource: "cad suite",
+				Instance: lease.Instance{Host: "host", User: "John Smith", ID: "1"},
+			},
+			Status: lease.Active,
+			Units:  2,
+		},
+	}
+
+	for _, operator := range []string{"", "alice", "Jane Doe", `Jane "JD" Doe`, "Jane BY Doe", "BY"} {
+		var buf bytes.Buffer
+		enc := textEncoder{log: log.New(&buf, "", log.LstdFlags)}
+		enc.Effect(time.Now(), lease.NewTx(effect.Resource, 0, nil), effect, operator)
+
+		// Both the detail line and the plain line must be understood
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			entry, ok, err := ParseEntry(line, time.Local)
+			if err != nil || !ok {
+				t.Fatalf("%q: unable to parse entry %q: %v", operator, line, err)
+			}
+			if entry.Operator != operator {
+				t.Errorf("%q: got operator %q from %q", operator, entry.Operator, line)
+			}
+			if entry.Lease.Resource != effect.Resource || entry.Lease.Instance != effect.Instance || entry.Lease.Status != effect.Status {
+				t.Errorf("%q: got %s lease %s from %q, want %s lease %s", operator, entry.Lease.Status, entry.Lease.Subject, line, effect.Status, effect.Subject)
+			}
+			if entry.Detail && entry.Lease.Units != effect.Units {
+				t.Errorf("%q: got %d units from %q, want %d", operator, entry.Lease.Units, line, effect.Units)
+			}
+		}
+	}
+
+	// Operators were written without quotes before they could contain spaces
+	entry, ok, err := ParseEntry("2024/01/02 03:04:05 TX host user 1 DELETE ACTIVE cad suite BY alice", time.Local)
+	if err != nil || !ok {
+		t.Fatalf("unable to parse unquoted operator: %v", err)
+	}
+	if entry.Operator != "alice" || entry.Lease.Resource != "cad suite" {
+		t.Errorf("unquoted operator: got operator %q and resource %q, want \"alice\" and \"cad suite\"", entry.Operator, entry.Lease.Resource)
+	}
+}
+
+func TestTextEncoderFormat(t *testing.T) {
+	at := time.Unix(0, 1700000000000000000)
+	ls := lease.Lease{
+		Subject: lease.Subject{
+			Resource: "cad",
+			Instance: lease.Instance{Host: "host", User: "jdoe", ID: "1"},
+		},
+		Status:  lease.Active,
+		Units:   2,
+		Started: at,
+	}
+	queued := ls
+	queued.Instance.ID = "2"
+	queued.Status = lease.Queued
+
+	var buf bytes.Buffer
+	enc := textEncoder{log: log.New(&buf, "", 0)}
+	tx := lease.NewTx("cad", 0, nil)
+	enc.Effect(at, tx, lease.Effect{Action: lease.Create, Lease: ls}, "")
+	enc.Effect(at, tx, lease.Effect{Action: lease.Create, Lease: queued}, "")
+	enc.CheckpointLease(at, 0, ls)
+	enc.CheckpointLease(at, 0, queued)
+
+	// The plain lines are relied upon by existing parsers, so they don't
+	// change. Units, start times and the queue are only recorded by the
+	// detail lines.
+	want := "TX+ host jdoe 1 CREATE ACTIVE cad UNITS 2\n" +
+		"TX host jdoe 1 CREATE ACTIVE cad\n" +
+		"TX+ host jdoe 2 CREATE QUEUED cad UNITS 2\n" +
+		"CP+ 1700000000000000000 LEASE cad: host jdoe 1 ACTIVE UNITS 2 STARTED 1700000000000000000\n" +
+		"CP 1700000000000000000 LEASE cad: host jdoe 1 ACTIVE\n" +
+		"CP+ 1700000000000000000 LEASE cad: host jdoe 2 QUEUED UNITS 2 STARTED 1700000000000000000\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestScannerSkipsPlainLinesAfterDetail(t *testing.T) {
+	const log = "2024/01/02 03:04:05 TX host jdoe 1 CREATE ACTIVE cad\n" +
+		"2024/01/02 03:04:06 TX+ host asmith 2 CREATE ACTIVE cad UNITS 3\n" +
+		"2024/01/02 03:04:06 TX host asmith 2 CREATE ACTIVE cad\n" +
+		"2024/01/02 03:04:07 CP 1704164647000000000 START\n" +
+		"2024/01/02 03:04:07 CP+ 1704164647000000000 LEASE cad: host asmith 2 ACTIVE UNITS 3\n" +
+		"2024/01/02 03:04:07 CP 1704164647000000000 LEASE cad: host asmith 2 ACTIVE\n" +
+		"2024/01/02 03:04:07 CP 1704164647000000000 END\n"
+
+	want := []struct {
+		typ   EntryType
+		id    string
+		units uint
+	}{
+		{TransactionEntry, "1", 0},
+		{TransactionEntry, "2", 3},
+		{CheckpointStartEntry, "", 0},
+		{CheckpointLeaseEntry, "2", 3},
+		{CheckpointEndEntry, "", 0},
+	}
+
+	scanner := NewScanner(strings.NewReader(log), time.UTC)
+	var i int
+	for ; scanner.Scan(); i++ {
+		entry := scanner.Entry()
+		if i >= len(want) {
+			t.Fatalf("unexpected %s entry for instance %q", entry.Type, entry.Lease.Instance.ID)
+		}
+		if entry.Type != want[i].typ || entry.Lease.Instance.ID != want[i].id || entry.Lease.Units != want[i].units {
+			t.Errorf("entry %d: got %s entry for instance %q with %d units, want %s entry for instance %q with %d units", i, entry.Type, entry.Lease.Instance.ID, entry.Lease.Units, want[i].typ, want[i].id, want[i].units)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if i != len(want) {
+		t.Errorf("got %d entries, want %d", i, len(want))
+	}
+}
@@ -11,17 +11,26 @@ import (
 const OpsSuffix = "ops"
 
 // ParseSchedule will parse the schedule in the provided string.
+//
+// The schedule is a comma-separated list of items, each of which is a number
+// of ops such as "200ops", a duration such as "6h", or a cron expression
+// such as "0 */6 * * *" or "@daily". Commas within a cron expression are
+// treated as part of the expression.
 func ParseSchedule(s string) (schedule []Schedule, err error) {
 	if s == "" {
 		return
 	}
 
-	items := strings.Split(s, ",")
+	items := splitSchedule(s)
 
 	for _, item := range items {
-		// TODO: Add support for cron-style scheduling?
-
 		switch {
+		case isCron(item):
+			cron, err := CronSchedule(item)
+			if err != nil {
+				return nil, err
+			}
+			schedule = append(schedule, cron)
 		case strings.HasSuffix(item, OpsSuffix):
 			value := strings.TrimSuffix(item, OpsSuffix)
 			ops, err := strconv.ParseUint(value, 10, 64)
@@ -46,3 +55,36 @@ func ParseSchedule(s string) (schedule []Schedule, err error) {
 
 	return
 }
+
+// splitSchedule splits a schedule into its items.
+//
+// Cron expressions may contain commas of their own, so a piece that follows
+// an incomplete cron expression, or that couldn't be an item by itself, is
+// joined to the expression that precedes it.
+func splitSchedule(s string) (items []string) {
+	for _, piece := range strings.Split(s, ",") {
+		piece = strings.TrimSpace(piece)
+		if n := len(items); n > 0 && isCron(items[n-1]) && !strings.HasPrefix(items[n-1], "@") {
+			if len(strings.Fields(items[n-1])) < 5 || !isItem(piece) {
+				items[n-1] += "," + piece
+				continue
+			}
+		}
+		items = append(items, piece)
+	}
+	return
+}
+
+// isCron returns true if item appears to be a cron expression.
+func isCron(item string) bool {
+	return strings.HasPrefix(item, "@") || strings.ContainsAny(item, " \t")
+}
+
+// isItem returns true if piece could be a schedule item by itself.
+func isItem(piece string) bool {
+	if isCron(piece) || strings.HasSuffix(piece, OpsSuffix) {
+		return true
+	}
+	d, err := time.ParseDuration(piece)
+	return err == nil && d > 0
+}
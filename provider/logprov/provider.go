@@ -2,8 +2,8 @@ package logprov
 
 import (
 	"fmt"
+	"io"
 	"log"
-	"strings"
 	"sync"
 	"time"
 
@@ -19,7 +19,7 @@ type timestamp struct {
 // Provider provides boltdb-backed lease management.
 type Provider struct {
 	source   lease.Provider
-	log      *log.Logger
+	enc      encoder
 	schedule []Schedule
 	ops      counter.Counter
 
@@ -28,11 +28,23 @@ type Provider struct {
 	closed chan struct{} // Closed when the provider closes
 }
 
-// New returns a new transaction logging provider.
+// New returns a new transaction logging provider that writes lines of text
+// to logger.
 func New(source lease.Provider, logger *log.Logger, schedule ...Schedule) *Provider {
+	return newProvider(source, textEncoder{log: logger}, schedule)
+}
+
+// NewJSON returns a new transaction logging provider that writes structured
+// records to w, one line of JSON each. The records are described by
+// txlog.Record.
+func NewJSON(source lease.Provider, w io.Writer, schedule ...Schedule) *Provider {
+	return newProvider(source, &jsonEncoder{w: w}, schedule)
+}
+
+func newProvider(source lease.Provider, enc encoder, schedule []Schedule) *Provider {
 	p := &Provider{
 		source:   source,
-		log:      logger,
+		enc:      enc,
 		schedule: schedule,
 		closed:   make(chan struct{}),
 	}
@@ -40,6 +52,10 @@ func New(source lease.Provider, logger *log.Logger, schedule ...Schedule) *Provi
 		// Start a goroutine to handle duration scheduling
 		go p.durationCheckpoint(d, p.closed)
 	}
+	if crons := p.cronSchedule(); len(crons) > 0 {
+		// Start a goroutine to handle cron scheduling
+		go p.cronCheckpoint(crons, p.closed)
+	}
 	return p
 }
 
@@ -84,6 +100,21 @@ func (p *Provider) LeaseCommit(tx *lease.Tx) error {
 	return err
 }
 
+// LeaseCommitAll will attempt to apply the operations described in each of
+// the lease transactions atomically.
+func (p *Provider) LeaseCommitAll(txs ...*lease.Tx) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	err := p.source.LeaseCommitAll(txs...)
+	if err == nil {
+		for _, tx := range txs {
+			p.record(tx)
+		}
+	}
+	return err
+}
+
 // Checkpoint will write all of the lease data to the transaction log in a
 // checkpoint block.
 //
@@ -102,7 +133,9 @@ func (p *Provider) Checkpoint() (err error) {
 //
 // record assumes that a read lock is held for the duration of the call.
 func (p *Provider) record(tx *lease.Tx) {
-	var ops uint64 // Total number of consumptive ops (really op effects)
+	var ops uint64 // Total number of recorded ops (really op effects)
+
+	at := time.Now()
 
 	for _, op := range tx.Ops() {
 		if op.Type == lease.Update && op.UpdateType() == lease.Renew {
@@ -110,11 +143,11 @@ func (p *Provider) record(tx *lease.Tx) {
 			continue
 		}
 		for _, effect := range op.Effects() {
-			if !effect.Consumptive() {
-				// Only record effects that affect consumption
+			if !effect.Consumptive() && effect.Status != lease.Queued {
+				// Only record effects that affect consumption or the queue
 				continue
 			}
-			p.log.Printf("TX %s", effect.String())
+			p.enc.Effect(at, tx, effect, op.Operator)
 			ops++
 		}
 	}
@@ -193,35 +226,85 @@ func (p *Provider) durationCheckpoint(interval time.Duration, done chan struct{}
 	}
 }
 
+// cronCheckpoint will start a checkpoint at each of the wall clock times
+// matched by the given cron expressions.
+//
+// cronCheckpoint blocks until the given channel is closed.
+func (p *Provider) cronCheckpoint(crons []*cronExpr, done chan struct{}) {
+	var last time.Time
+	for {
+		// Never schedule a time that has already been handled, even if the
+		// wall clock has been set back
+		now := time.Now()
+		if now.Before(last) {
+			now = last
+		}
+
+		var next time.Time
+		for _, c := range crons {
+			if t := c.Next(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		if next.IsZero() {
+			return // The expressions never match
+		}
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-t.C:
+		case <-done:
+			if !t.Stop() {
+				<-t.C
+			}
+			return
+		}
+
+		p.mutex.Lock()
+
+		select {
+		case <-done:
+			p.mutex.Unlock()
+			return // Closed
+		default:
+		}
+
+		p.checkpoint()
+		p.mutex.Unlock()
+
+		last = next
+	}
+}
+
 // checkpoint writes checkpoint data to the transaction log.
 //
 // checkpoint assumes that a write lock is held for the duration of the call.
 func (p *Provider) checkpoint() (err error) {
 	at := time.Now()
-	nano := at.UnixNano()
 
 	resources, err := p.source.LeaseResources()
 	if err != nil {
 		return
 	}
 
-	p.log.Printf("CP %v START", nano)
+	p.enc.CheckpointStart(at)
 
 	for _, resource := range resources {
-		_, leases, viewErr := p.source.LeaseView(resource)
+		revision, leases, viewErr := p.source.LeaseView(resource)
 		if viewErr != nil {
-			p.log.Printf("CP %v RESOURCE %s ERR %v", nano, resource, err)
-		} else {
-			//p.log.Printf("CP %v RESOURCE %s REV %d", at, resource, revision)
-			for _, ls := range leases {
-				if ls.Consumptive() {
-					p.log.Printf("CP %v LEASE %s %s", nano, ls.Subject, strings.ToUpper(string(ls.Status)))
-				}
+			p.enc.CheckpointError(at, resource, viewErr)
+			continue
+		}
+		for _, ls := range leases {
+			// Queued leases are recorded along with their start times so
+			// that the order of the queue can be recovered
+			if ls.Consumptive() || ls.Status == lease.Queued {
+				p.enc.CheckpointLease(at, revision, ls)
 			}
 		}
 	}
 
-	p.log.Printf("CP %v END", nano)
+	p.enc.CheckpointEnd(at)
 
 	p.last.ops = p.ops.Value()
 	p.last.when = at
@@ -269,6 +352,15 @@ func (p *Provider) durationSchedule() (d time.Duration, ok bool) {
 	return
 }
 
+func (p *Provider) cronSchedule() (crons []*cronExpr) {
+	for _, s := range p.schedule {
+		if s.t == cronSchedule && s.cron != nil {
+			crons = append(crons, s.cron)
+		}
+	}
+	return
+}
+
 // nextCheckpoint returns the duration of time to wait for the next
 // scheduled checkpoint.
 func nextCheckpoint(now time.Time, last time.Time, interval time.Duration) (next time.Duration) {
@@ -0,0 +1,154 @@
+package logprov
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// Replay reconstructs the leases recorded in a transaction log as they stood
+// at the given time. If at is zero the entire log is replayed. Timestamps
+// are interpreted in the given location.
+//
+// Each complete checkpoint block replaces the reconstructed state, which is
+// then advanced by the transactions that follow it. Resources that could not
+// be recorded in a checkpoint keep the state derived from earlier
+// transactions.
+//
+// Structured records describe each lease in full. Lines of text only record
+// the subject, status and units of each lease, and the start times of leases
+// recorded by checkpoints, so the leases replayed from them describe little
+// more. Otherwise start times are taken from the time at which a lease was
+// first created. A lease that is moved to another resource keeps its
+// start time. Renewal and release times are taken from the last entry that
+// affected each lease.
+//
+// Logs written before the text format gained detail lines only record leases
+// that consume units of a resource, and not the number of units consumed.
+// Replay returns plain as true if it read any part of such a log.
+func Replay(r io.Reader, loc *time.Location, at time.Time) (leases lease.Set, plain bool, err error) {
+	var rp replayer
+	rp.leases = make(map[lease.Subject]lease.Lease)
+
+	scanner := NewScanner(r, loc)
+	for scanner.Scan() {
+		entry := scanner.Entry()
+		if !at.IsZero() && entry.Time.After(at) {
+			break
+		}
+		rp.add(entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, false, err
+	}
+
+	for _, ls := range rp.leases {
+		leases = append(leases, ls)
+	}
+	sort.Sort(leases)
+	return leases, scanner.Plain(), nil
+}
+
+// replayer accumulates the state of leases recorded in a transaction log.
+type replayer struct {
+	leases  map[lease.Subject]lease.Lease
+	deleted *lease.Lease // Lease deleted by the previous entry, if any
+	cp      map[lease.Subject]lease.Lease
+	cpErrs  map[string]bool
+}
+
+func (rp *replayer) add(entry Entry) {
+	// An update is recorded as the deletion of the previous lease followed
+	// immediately by the creation of the next one
+	deleted := rp.deleted
+	rp.deleted = nil
+
+	switch entry.Type {
+	case TransactionEntry:
+		switch entry.Action {
+		case lease.Create:
+			ls := rp.build(entry.Lease, entry.Time, entry.Operator)
+			if entry.Lease.Started.IsZero() {
+				if prev, ok := rp.leases[ls.Subject]; ok {
+					ls.Started = prev.Started
+				} else if deleted != nil && deleted.Instance == ls.Instance {
+					ls.Started = deleted.Started
+				}
+			}
+			rp.leases[ls.Subject] = ls
+		case lease.Delete:
+			if prev, ok := rp.leases[entry.Lease.Subject]; ok {
+				delete(rp.leases, entry.Lease.Subject)
+				rp.deleted = &prev
+			}
+		}
+	case CheckpointStartEntry:
+		rp.cp = make(map[lease.Subject]lease.Lease)
+		rp.cpErrs = make(map[string]bool)
+	case CheckpointLeaseEntry:
+		if rp.cp == nil {
+			return
+		}
+		ls := rp.build(entry.Lease, entry.Time, "")
+		if prev, ok := rp.leases[ls.Subject]; ok {
+			if entry.Lease.Started.IsZero() {
+				ls.Started = prev.Started
+			}
+			if prev.Status == ls.Status && entry.Lease.Released.IsZero() {
+				ls.Released = prev.Released
+			}
+			if entry.Lease.Hold == nil && prev.Hold != nil {
+				ls.Hold = prev.Hold.Clone()
+			}
+		}
+		rp.cp[ls.Subject] = ls
+	case CheckpointErrorEntry:
+		if rp.cpErrs != nil {
+			rp.cpErrs[entry.Lease.Resource] = true
+		}
+	case CheckpointEndEntry:
+		if rp.cp == nil {
+			return
+		}
+		for subject, ls := range rp.leases {
+			if rp.cpErrs[subject.Resource] {
+				rp.cp[subject] = ls
+			}
+		}
+		rp.leases = rp.cp
+		rp.cp = nil
+		rp.cpErrs = nil
+	}
+}
+
+// build returns a lease described by an entry that was recorded at the
+// given time. Details that the entry doesn't describe are derived from the
+// time at which it was recorded.
+func (rp *replayer) build(described lease.Lease, at time.Time, operator string) lease.Lease {
+	ls := lease.Clone(described)
+	if ls.Properties == nil {
+		ls.Properties = lease.Properties{}
+	}
+	if ls.Started.IsZero() {
+		ls.Started = at
+	}
+	if ls.Renewed.IsZero() {
+		ls.Renewed = at
+	}
+	if ls.Status == lease.Released && ls.Released.IsZero() {
+		ls.Released = at
+	}
+	if ls.Status == lease.Held && ls.Hold == nil {
+		hold := lease.NewHold(ls.Resource, ls.Units, 0, lease.Hold{
+			ID:       strings.TrimPrefix(ls.Instance.ID, lease.HoldPrefix),
+			Operator: operator,
+			Created:  ls.Started,
+		})
+		hold.Instance = ls.Instance
+		return hold
+	}
+	return ls
+}
@@ -0,0 +1,55 @@
+package logprov
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+func TestReplayTextLog(t *testing.T) {
+	const log = "2024/01/02 03:00:00 CP 1704164400000000000 START\n" +
+		"2024/01/02 03:00:00 CP+ 1704164400000000000 LEASE cad: host1 jdoe 1 ACTIVE UNITS 2 STARTED 1704160800000000000\n" +
+		"2024/01/02 03:00:00 CP 1704164400000000000 LEASE cad: host1 jdoe 1 ACTIVE\n" +
+		"2024/01/02 03:00:00 CP+ 1704164400000000000 LEASE cad: host2 asmith 2 QUEUED UNITS 2 STARTED 1704162600000000000\n" +
+		"2024/01/02 03:00:00 CP 1704164400000000000 END\n" +
+		"2024/01/02 03:10:00 TX+ host3 bjones 3 CREATE QUEUED cad UNITS 3\n"
+
+	leases, plain, err := Replay(strings.NewReader(log), time.UTC, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain {
+		t.Error("log with detail lines was reported as plain")
+	}
+
+	want := []struct {
+		id      string
+		status  lease.Status
+		units   uint
+		started time.Time
+	}{
+		{"1", lease.Active, 2, time.Unix(0, 1704160800000000000)},
+		{"2", lease.Queued, 2, time.Unix(0, 1704162600000000000)},
+		{"3", lease.Queued, 3, time.Date(2024, 1, 2, 3, 10, 0, 0, time.UTC)},
+	}
+	if len(leases) != len(want) {
+		t.Fatalf("got %d leases, want %d", len(leases), len(want))
+	}
+	for _, w := range want {
+		var ls *lease.Lease
+		for i := range leases {
+			if leases[i].Instance.ID == w.id {
+				ls = &leases[i]
+			}
+		}
+		if ls == nil {
+			t.Errorf("lease %s was not replayed", w.id)
+			continue
+		}
+		if ls.Status != w.status || ls.Units != w.units || !ls.Started.Equal(w.started) {
+			t.Errorf("lease %s: got %s with %d units started %s, want %s with %d units started %s", w.id, ls.Status, ls.Units, ls.Started, w.status, w.units, w.started)
+		}
+	}
+}
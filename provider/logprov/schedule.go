@@ -1,6 +1,9 @@
 package logprov
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 const (
 	// MinimumDuration is the shortest valid duration for a duration schedule.
@@ -12,6 +15,7 @@ type scheduleType uint
 const (
 	opsSchedule scheduleType = iota
 	durationSchedule
+	cronSchedule
 )
 
 // Schedule is a transaction log checkpointing schedule.
@@ -19,6 +23,7 @@ type Schedule struct {
 	t        scheduleType  // The type of schedule
 	ops      uint64        // The number of operations between each checkpoint
 	duration time.Duration // The time duration between each checkpoint
+	cron     *cronExpr     // The wall clock times at which checkpoints occur
 }
 
 // OpsSchedule creates a checkpointing schedule that will cause a checkpoint to
@@ -40,3 +45,19 @@ func DurationSchedule(d time.Duration) Schedule {
 		duration: d,
 	}
 }
+
+// CronSchedule creates a checkpointing schedule that will cause a checkpoint
+// to occur at the local wall clock times matched by a cron expression. The
+// expression may have the standard five fields, such as "0 */6 * * *", or be
+// one of the descriptors @yearly, @annually, @monthly, @weekly, @daily,
+// @midnight or @hourly.
+func CronSchedule(expr string) (Schedule, error) {
+	c, err := parseCron(expr)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("invalid cron expression \"%s\": %v", expr, err)
+	}
+	return Schedule{
+		t:    cronSchedule,
+		cron: c,
+	}, nil
+}
@@ -2,6 +2,7 @@ package memprov
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"sync"
 
@@ -75,6 +76,56 @@ func (p *Provider) LeaseCommit(tx *lease.Tx) error {
 	if page.revision != tx.Revision() {
 		return errors.New("Unable to commit lease transaction due to opportunistic lock conflict")
 	}
+	page.apply(ops)
+
+	return nil
+}
+
+// LeaseCommitAll will attempt to apply the operations described in each of
+// the lease transactions atomically.
+func (p *Provider) LeaseCommitAll(txs ...*lease.Tx) error {
+	pending := make(map[string]*lease.Tx, len(txs))
+	resources := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		if len(tx.Ops()) == 0 {
+			// Nothing to commit
+			continue
+		}
+		if _, dup := pending[tx.Resource()]; dup {
+			return fmt.Errorf("Unable to commit more than one lease transaction for resource \"%s\"", tx.Resource())
+		}
+		pending[tx.Resource()] = tx
+		resources = append(resources, tx.Resource())
+	}
+
+	// Lock the pages in a consistent order to avoid deadlocks
+	sort.Strings(resources)
+
+	pages := make([]*leasePage, len(resources))
+	for i, resource := range resources {
+		pages[i] = p.leasePage(resource)
+		pages[i].mutex.Lock()
+		defer pages[i].mutex.Unlock()
+	}
+
+	for i, resource := range resources {
+		if pages[i].revision != pending[resource].Revision() {
+			return errors.New("Unable to commit lease transaction due to opportunistic lock conflict")
+		}
+	}
+
+	for i, resource := range resources {
+		pages[i].apply(pending[resource].Ops())
+	}
+
+	return nil
+}
+
+// apply applies the given operations to the page and increments its
+// revision.
+//
+// apply assumes that a write lock is held for the duration of the call.
+func (page *leasePage) apply(ops []lease.Op) {
 	page.revision++
 	for _, op := range ops {
 		switch op.Type {
@@ -94,8 +145,6 @@ func (p *Provider) LeaseCommit(tx *lease.Tx) error {
 	}
 
 	sort.Sort(page.leases)
-
-	return nil
 }
 
 func (p *Provider) leasePage(resource string) *leasePage {
@@ -0,0 +1,76 @@
+package sqlprov
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect describes the variant of SQL spoken by a database.
+type Dialect struct {
+	Name       string     // Name of the database
+	migrations [][]string // Statements that migrate the schema to each version in turn
+	lock       string     // Statement that serializes migrations, if any
+	numbered   bool       // Whether parameters are numbered ($1) rather than question marks
+	timeFormat string     // Layout in which times are stored, or empty to store them natively
+}
+
+// SQLite is the dialect of SQLite databases.
+var SQLite = Dialect{
+	Name:       "SQLite",
+	migrations: sqliteMigrations,
+	timeFormat: "2006-01-02 15:04:05.999999999",
+}
+
+// Postgres is the dialect of PostgreSQL databases.
+var Postgres = Dialect{
+	Name:       "PostgreSQL",
+	migrations: postgresMigrations,
+	lock:       "SELECT pg_advisory_xact_lock(5877)",
+	numbered:   true,
+}
+
+// DialectByName returns the dialect with the given name, such as "sqlite" or
+// "postgres".
+func DialectByName(name string) (dialect Dialect, ok bool) {
+	switch strings.ToLower(name) {
+	case "sqlite", "sqlite3":
+		return SQLite, true
+	case "postgres", "postgresql", "pg":
+		return Postgres, true
+	default:
+		return Dialect{}, false
+	}
+}
+
+// query rewrites a query written with question mark parameters for the
+// dialect.
+func (d Dialect) query(q string) string {
+	if !d.numbered {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// time returns the value in which t is stored. Zero times are stored as
+// null.
+func (d Dialect) time(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	if d.timeFormat != "" {
+		return t.Format(d.timeFormat)
+	}
+	return t
+}
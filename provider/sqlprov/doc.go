@@ -0,0 +1,6 @@
+// Package sqlprov provides lease storage using a SQL database.
+//
+// The provider is built on database/sql and supports SQLite and PostgreSQL
+// through dialects. Programs that use it must import a driver for their
+// database.
+package sqlprov
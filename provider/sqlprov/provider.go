@@ -0,0 +1,233 @@
+package sqlprov
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// errConflict is returned when a transaction is based on a revision that is
+// no longer current.
+var errConflict = errors.New("Unable to commit lease transaction due to opportunistic lock conflict")
+
+// Provider provides SQL-backed lease management.
+//
+// Each lease is stored in a row of the resourceful_leases table, keyed by
+// its resource and instance. Details that are useful to query are stored in
+// columns of their own, and the lease is recorded in full in the data
+// column, from which it is read. The current revision of each resource is
+// stored in the resourceful_revisions table.
+//
+// Several guardians may share a database. Transactions are committed only if
+// the revision of their resource hasn't changed since they were prepared.
+type Provider struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+// New returns a new SQL provider that stores leases in db, which speaks the
+// given dialect. The database schema is created or migrated to the current
+// version if necessary.
+func New(db *sql.DB, dialect Dialect) (*Provider, error) {
+	p := &Provider{
+		db:      db,
+		dialect: dialect,
+	}
+	if err := p.migrate(); err != nil {
+		return nil, fmt.Errorf("unable to migrate database schema: %v", err)
+	}
+	return p, nil
+}
+
+// Close releases any resources consumed by the provider.
+func (p *Provider) Close() error {
+	return p.db.Close()
+}
+
+// ProviderName returns the name of the provider.
+func (p *Provider) ProviderName() string {
+	return p.dialect.Name
+}
+
+// LeaseResources returns all of the resources with lease data.
+func (p *Provider) LeaseResources() (resources []string, err error) {
+	rows, err := p.db.Query(`SELECT DISTINCT resource FROM resourceful_leases ORDER BY resource`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resource string
+		if err := rows.Scan(&resource); err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, rows.Err()
+}
+
+// LeaseView returns the current revision and lease set for the resource.
+func (p *Provider) LeaseView(resource string) (revision uint64, leases lease.Set, err error) {
+	// The revision is read first, so that leases committed in the meantime
+	// cause the transaction to be rejected rather than overwritten
+	var rev int64
+	err = p.db.QueryRow(p.dialect.query(`SELECT revision FROM resourceful_revisions WHERE resource = ?`), resource).Scan(&rev)
+	switch err {
+	case nil:
+		revision = uint64(rev)
+	case sql.ErrNoRows:
+	default:
+		return 0, nil, err
+	}
+
+	rows, err := p.db.Query(p.dialect.query(`SELECT data FROM resourceful_leases WHERE resource = ?`), resource)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return 0, nil, err
+		}
+		var ls lease.Lease
+		if err := json.Unmarshal(data, &ls); err != nil {
+			return 0, nil, err
+		}
+		leases = append(leases, ls)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	sort.Sort(leases)
+	return revision, leases, nil
+}
+
+// LeaseCommit will attempt to apply the operations described in the lease
+// transaction.
+func (p *Provider) LeaseCommit(tx *lease.Tx) error {
+	return p.LeaseCommitAll(tx)
+}
+
+// LeaseCommitAll will attempt to apply the operations described in each of
+// the lease transactions atomically.
+func (p *Provider) LeaseCommitAll(txs ...*lease.Tx) error {
+	var pending []*lease.Tx
+	seen := make(map[string]bool, len(txs))
+	for _, tx := range txs {
+		if len(tx.Ops()) == 0 {
+			// Nothing to commit
+			continue
+		}
+		if seen[tx.Resource()] {
+			return fmt.Errorf("Unable to commit more than one lease transaction for resource \"%s\"", tx.Resource())
+		}
+		seen[tx.Resource()] = true
+		pending = append(pending, tx)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	stx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer stx.Rollback()
+
+	for _, tx := range pending {
+		if err := p.advance(stx, tx.Resource(), tx.Revision()); err != nil {
+			return err
+		}
+	}
+
+	for _, tx := range pending {
+		for _, op := range tx.Ops() {
+			if err := p.apply(stx, tx.Resource(), op); err != nil {
+				return err
+			}
+		}
+	}
+
+	return stx.Commit()
+}
+
+// advance increments the revision of a resource if it matches the revision
+// on which a transaction was based.
+func (p *Provider) advance(stx *sql.Tx, resource string, revision uint64) error {
+	var (
+		result sql.Result
+		err    error
+	)
+	if revision == 0 {
+		result, err = stx.Exec(p.dialect.query(`INSERT INTO resourceful_revisions (resource, revision) VALUES (?, 1) ON CONFLICT (resource) DO NOTHING`), resource)
+	} else {
+		result, err = stx.Exec(p.dialect.query(`UPDATE resourceful_revisions SET revision = revision + 1 WHERE resource = ? AND revision = ?`), resource, int64(revision))
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errConflict
+	}
+	return nil
+}
+
+// apply applies an operation to the leases of a resource.
+func (p *Provider) apply(stx *sql.Tx, resource string, op lease.Op) error {
+	switch op.Type {
+	case lease.Create:
+		return p.insert(stx, resource, op.Lease)
+	case lease.Update:
+		if err := p.delete(stx, resource, op.Previous.Instance); err != nil {
+			return err
+		}
+		return p.insert(stx, resource, op.Lease)
+	case lease.Delete:
+		return p.delete(stx, resource, op.Previous.Instance)
+	default:
+		return nil
+	}
+}
+
+func (p *Provider) insert(stx *sql.Tx, resource string, ls lease.Lease) error {
+	data, err := json.Marshal(ls)
+	if err != nil {
+		return err
+	}
+	_, err = stx.Exec(p.dialect.query(`INSERT INTO resourceful_leases
+		(resource, host, user_name, instance, status, units, consumer, started, renewed, released, policy_hash, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		resource,
+		ls.Instance.Host,
+		ls.Instance.User,
+		ls.Instance.ID,
+		string(ls.Status),
+		int64(ls.Units),
+		ls.ConsumerKey(),
+		p.dialect.time(ls.Started),
+		p.dialect.time(ls.Renewed),
+		p.dialect.time(ls.Released),
+		ls.PolicyHash,
+		string(data),
+	)
+	return err
+}
+
+func (p *Provider) delete(stx *sql.Tx, resource string, instance lease.Instance) error {
+	_, err := stx.Exec(p.dialect.query(`DELETE FROM resourceful_leases WHERE resource = ? AND host = ? AND user_name = ? AND instance = ?`),
+		resource, instance.Host, instance.User, instance.ID)
+	return err
+}
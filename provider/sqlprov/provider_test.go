@@ -0,0 +1,275 @@
+package sqlprov
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	_ "modernc.org/sqlite"
+)
+
+func openSQLite(t *testing.T, path string) *Provider {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	p, err := New(db, SQLite)
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	return p
+}
+
+func newProvider(t *testing.T) *Provider {
+	t.Helper()
+	p := openSQLite(t, filepath.Join(t.TempDir(), "leases.sqlite"))
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func testLease(resource, id string) lease.Lease {
+	return lease.Lease{
+		Subject: lease.Subject{
+			Resource: resource,
+			Instance: lease.Instance{Host: "host", User: "user", ID: id},
+		},
+		Properties: lease.Properties{"program.name": "app.exe"},
+		Status:     lease.Active,
+		Started:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Units:      2,
+	}
+}
+
+// view returns the current revision and leases of resource, failing the
+// test if they can't be retrieved.
+func view(t *testing.T, p *Provider, resource string) (uint64, lease.Set) {
+	t.Helper()
+	revision, leases, err := p.LeaseView(resource)
+	if err != nil {
+		t.Fatalf("LeaseView(%q): %v", resource, err)
+	}
+	return revision, leases
+}
+
+func TestLeaseCommit(t *testing.T) {
+	p := newProvider(t)
+
+	revision, leases := view(t, p, "a")
+	if revision != 0 || len(leases) != 0 {
+		t.Fatalf("empty resource: got revision %d with %d leases, want revision 0 with none", revision, len(leases))
+	}
+
+	tx := lease.NewTx("a", revision, leases)
+	tx.Create(testLease("a", "1"))
+	tx.Create(testLease("a", "2"))
+	if err := p.LeaseCommit(tx); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	revision, leases = view(t, p, "a")
+	if revision != 1 || len(leases) != 2 {
+		t.Fatalf("after create: got revision %d with %d leases, want revision 1 with 2", revision, len(leases))
+	}
+	if got := leases[0]; got.Properties["program.name"] != "app.exe" || got.Units != 2 || !got.Started.Equal(testLease("a", "1").Started) {
+		t.Fatalf("after create: lease was not stored in full: %+v", got)
+	}
+
+	tx = lease.NewTx("a", revision, leases)
+	updated := testLease("a", "3")
+	tx.Update(lease.Instance{Host: "host", User: "user", ID: "1"}, updated)
+	tx.Delete(lease.Instance{Host: "host", User: "user", ID: "2"})
+	if err := p.LeaseCommit(tx); err != nil {
+		t.Fatalf("update and delete: %v", err)
+	}
+
+	revision, leases = view(t, p, "a")
+	if revision != 2 || len(leases) != 1 || leases[0].Instance.ID != "3" {
+		t.Fatalf("after update and delete: got revision %d with leases %v, want revision 2 with instance 3", revision, leases)
+	}
+
+	tx = lease.NewTx("a", revision, leases)
+	tx.Delete(leases[0].Instance)
+	if err := p.LeaseCommit(tx); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// The revision is retained when the last lease is deleted
+	revision, leases = view(t, p, "a")
+	if revision != 3 || len(leases) != 0 {
+		t.Fatalf("after delete: got revision %d with %d leases, want revision 3 with none", revision, len(leases))
+	}
+
+	resources, err := p.LeaseResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 0 {
+		t.Fatalf("LeaseResources: got %v, want none", resources)
+	}
+}
+
+func TestLeaseCommitStaleRevision(t *testing.T) {
+	p := newProvider(t)
+
+	// Both transactions are based on the same view of an empty resource
+	first := lease.NewTx("a", 0, nil)
+	first.Create(testLease("a", "1"))
+	second := lease.NewTx("a", 0, nil)
+	second.Create(testLease("a", "2"))
+
+	if err := p.LeaseCommit(first); err != nil {
+		t.Fatalf("first commit: %v", err)
+	}
+	if err := p.LeaseCommit(second); err != errConflict {
+		t.Fatalf("stale commit of a new resource: got %v, want %v", err, errConflict)
+	}
+
+	revision, leases := view(t, p, "a")
+	third := lease.NewTx("a", revision, leases)
+	third.Create(testLease("a", "3"))
+	fourth := lease.NewTx("a", revision, leases)
+	fourth.Create(testLease("a", "4"))
+
+	if err := p.LeaseCommit(third); err != nil {
+		t.Fatalf("third commit: %v", err)
+	}
+	if err := p.LeaseCommit(fourth); err != errConflict {
+		t.Fatalf("stale commit of an existing resource: got %v, want %v", err, errConflict)
+	}
+
+	revision, leases = view(t, p, "a")
+	if revision != 2 || len(leases) != 2 || leases.Index("a", lease.Instance{Host: "host", User: "user", ID: "1"}) < 0 || leases.Index("a", lease.Instance{Host: "host", User: "user", ID: "3"}) < 0 {
+		t.Fatalf("got revision %d with leases %v, want revision 2 with instances 1 and 3", revision, leases)
+	}
+}
+
+func TestLeaseCommitAll(t *testing.T) {
+	p := newProvider(t)
+
+	a := lease.NewTx("a", 0, nil)
+	a.Create(testLease("a", "1"))
+	b := lease.NewTx("b", 0, nil)
+	b.Create(testLease("b", "1"))
+	if err := p.LeaseCommitAll(a, b); err != nil {
+		t.Fatalf("commit all: %v", err)
+	}
+
+	revA, leasesA := view(t, p, "a")
+	revB, leasesB := view(t, p, "b")
+	if revA != 1 || len(leasesA) != 1 || revB != 1 || len(leasesB) != 1 {
+		t.Fatalf("got a at revision %d with %d leases and b at revision %d with %d leases, want both at revision 1 with 1", revA, len(leasesA), revB, len(leasesB))
+	}
+
+	resources, err := p.LeaseResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resources) != 2 || resources[0] != "a" || resources[1] != "b" {
+		t.Fatalf("LeaseResources: got %v, want [a b]", resources)
+	}
+
+	dup1 := lease.NewTx("a", revA, leasesA)
+	dup1.Create(testLease("a", "2"))
+	dup2 := lease.NewTx("a", revA, leasesA)
+	dup2.Create(testLease("a", "3"))
+	if err := p.LeaseCommitAll(dup1, dup2); err == nil {
+		t.Fatal("commit of two transactions for the same resource succeeded")
+	}
+}
+
+func TestLeaseCommitAllRollback(t *testing.T) {
+	p := newProvider(t)
+
+	b := lease.NewTx("b", 0, nil)
+	b.Create(testLease("b", "1"))
+	if err := p.LeaseCommit(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// The transaction for a is valid, but the one for b is based on a stale
+	// revision, so neither may be committed
+	a := lease.NewTx("a", 0, nil)
+	a.Create(testLease("a", "1"))
+	stale := lease.NewTx("b", 0, nil)
+	stale.Create(testLease("b", "2"))
+	if err := p.LeaseCommitAll(a, stale); err != errConflict {
+		t.Fatalf("got %v, want %v", err, errConflict)
+	}
+
+	if revision, leases := view(t, p, "a"); revision != 0 || len(leases) != 0 {
+		t.Fatalf("a was committed: got revision %d with %d leases, want revision 0 with none", revision, len(leases))
+	}
+	if revision, leases := view(t, p, "b"); revision != 1 || len(leases) != 1 {
+		t.Fatalf("b was changed: got revision %d with %d leases, want revision 1 with 1", revision, len(leases))
+	}
+
+	// A failure while applying operations is also rolled back, after the
+	// revisions of both resources have been advanced
+	a = lease.NewTx("a", 0, nil)
+	a.Create(testLease("a", "1"))
+	revision, leases := view(t, p, "b")
+	duplicate := lease.NewTx("b", revision, leases)
+	duplicate.Create(testLease("b", "1"))
+	if err := p.LeaseCommitAll(a, duplicate); err == nil {
+		t.Fatal("commit of a duplicate lease succeeded")
+	}
+
+	if revision, leases := view(t, p, "a"); revision != 0 || len(leases) != 0 {
+		t.Fatalf("a was committed: got revision %d with %d leases, want revision 0 with none", revision, len(leases))
+	}
+	if revision, leases := view(t, p, "b"); revision != 1 || len(leases) != 1 {
+		t.Fatalf("b was changed: got revision %d with %d leases, want revision 1 with 1", revision, len(leases))
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.sqlite")
+
+	p := openSQLite(t, path)
+	tx := lease.NewTx("a", 0, nil)
+	tx.Create(testLease("a", "1"))
+	if err := p.LeaseCommit(tx); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	// Opening the database again leaves the schema and its data intact
+	p = openSQLite(t, path)
+	defer p.Close()
+
+	var version int
+	if err := p.db.QueryRow(`SELECT version FROM resourceful_schema`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != len(SQLite.migrations) {
+		t.Fatalf("schema version: got %d, want %d", version, len(SQLite.migrations))
+	}
+
+	if revision, leases := view(t, p, "a"); revision != 1 || len(leases) != 1 {
+		t.Fatalf("got revision %d with %d leases, want revision 1 with 1", revision, len(leases))
+	}
+
+	// A schema newer than the provider understands is refused
+	if _, err := p.db.Exec(`UPDATE resourceful_schema SET version = ?`, version+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.migrate(); err == nil {
+		t.Fatal("migration of a newer schema succeeded")
+	}
+}
+
+func TestDialectQuery(t *testing.T) {
+	const q = `SELECT data FROM resourceful_leases WHERE resource = ? AND host = ?`
+	if got := SQLite.query(q); got != q {
+		t.Errorf("SQLite: got %q, want %q", got, q)
+	}
+	want := `SELECT data FROM resourceful_leases WHERE resource = $1 AND host = $2`
+	if got := Postgres.query(q); got != want {
+		t.Errorf("PostgreSQL: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package sqlprov
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Each migration is a list of statements that brings the schema from the
+// previous version to the next. The version of the schema is the number of
+// migrations that have been applied. Migrations must never be changed once
+// released; changes to the schema are made by appending new ones.
+
+var sqliteMigrations = [][]string{
+	{
+		`CREATE TABLE resourceful_revisions (
+			resource TEXT NOT NULL PRIMARY KEY,
+			revision INTEGER NOT NULL
+		)`,
+		`CREATE TABLE resourceful_leases (
+			resource TEXT NOT NULL,
+			host TEXT NOT NULL,
+			user_name TEXT NOT NULL,
+			instance TEXT NOT NULL,
+			status TEXT NOT NULL,
+			units INTEGER NOT NULL,
+			consumer TEXT NOT NULL,
+			started TIMESTAMP NULL,
+			renewed TIMESTAMP NULL,
+			released TIMESTAMP NULL,
+			policy_hash TEXT NOT NULL,
+			data TEXT NOT NULL,
+			PRIMARY KEY (resource, host, user_name, instance)
+		)`,
+		`CREATE INDEX resourceful_leases_host_user ON resourceful_leases (host, user_name)`,
+	},
+}
+
+var postgresMigrations = [][]string{
+	{
+		`CREATE TABLE resourceful_revisions (
+			resource TEXT NOT NULL PRIMARY KEY,
+			revision BIGINT NOT NULL
+		)`,
+		`CREATE TABLE resourceful_leases (
+			resource TEXT NOT NULL,
+			host TEXT NOT NULL,
+			user_name TEXT NOT NULL,
+			instance TEXT NOT NULL,
+			status TEXT NOT NULL,
+			units BIGINT NOT NULL,
+			consumer TEXT NOT NULL,
+			started TIMESTAMPTZ NULL,
+			renewed TIMESTAMPTZ NULL,
+			released TIMESTAMPTZ NULL,
+			policy_hash TEXT NOT NULL,
+			data JSONB NOT NULL,
+			PRIMARY KEY (resource, host, user_name, instance)
+		)`,
+		`CREATE INDEX resourceful_leases_host_user ON resourceful_leases (host, user_name)`,
+	},
+}
+
+// migrate brings the schema of the database up to date.
+func (p *Provider) migrate() error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if p.dialect.lock != "" {
+		// Guardians that share a database may start at the same time
+		if _, err := tx.Exec(p.dialect.lock); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS resourceful_schema (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+
+	var version int
+	err = tx.QueryRow(`SELECT version FROM resourceful_schema`).Scan(&version)
+	switch err {
+	case nil:
+	case sql.ErrNoRows:
+		if _, err := tx.Exec(`INSERT INTO resourceful_schema (version) VALUES (0)`); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	migrations := p.dialect.migrations
+	if version > len(migrations) {
+		return fmt.Errorf("the database schema version %d is newer than the latest supported version %d", version, len(migrations))
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	for _, migration := range migrations[version:] {
+		for _, statement := range migration {
+			if _, err := tx.Exec(statement); err != nil {
+				return fmt.Errorf("schema migration %d failed: %v", version+1, err)
+			}
+		}
+		version++
+	}
+
+	if _, err := tx.Exec(p.dialect.query(`UPDATE resourceful_schema SET version = ?`), version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
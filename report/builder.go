@@ -0,0 +1,330 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/provider/logprov"
+)
+
+// LimitFunc returns the number of units of a resource that can be consumed
+// at once. It returns zero if the limit is unknown.
+type LimitFunc func(resource string) uint
+
+// Builder reconstructs the sessions recorded in a transaction log and
+// summarizes the use of each resource over a period of time.
+//
+// Entries must be added in the order they were written. Checkpoint blocks
+// are treated as authoritative, which corrects the state of the builder when
+// the log begins part of the way through a session or is missing entries.
+type Builder struct {
+	from   time.Time
+	to     time.Time
+	limits LimitFunc
+
+	first     time.Time                     // Time of the earliest entry
+	last      time.Time                     // Time of the most recent entry
+	leases    map[lease.Subject]state       // Current lease state
+	pending   *logprov.Entry                // Deletion that might be part of an update
+	cp        map[lease.Subject]lease.Lease // Leases of the checkpoint in progress
+	cpErrs    map[string]bool               // Resources missing from the checkpoint in progress
+	resources map[string]*usage
+}
+
+// state is the state of a lease reconstructed from the log.
+type state struct {
+	lease.Lease
+	since time.Time // When the lease entered its current status
+}
+
+// usage accumulates the use of a resource.
+type usage struct {
+	Resource
+	seats  time.Duration // Unit-weighted time consumed
+	deltas []delta
+	users  map[string]*User
+	hours  map[string]time.Duration
+}
+
+// delta is a change in the number of units of a resource being consumed.
+type delta struct {
+	at    time.Time
+	units int
+}
+
+// NewBuilder returns a report builder for the period between from and to.
+// A zero from or to leaves the period unbounded at that end. The limit of
+// each resource is determined by limits, which may be nil.
+func NewBuilder(from, to time.Time, limits LimitFunc) *Builder {
+	return &Builder{
+		from:      from,
+		to:        to,
+		limits:    limits,
+		leases:    make(map[lease.Subject]state),
+		resources: make(map[string]*usage),
+	}
+}
+
+// Add adds a transaction log entry to the builder.
+func (b *Builder) Add(entry logprov.Entry) {
+	if b.first.IsZero() || entry.Time.Before(b.first) {
+		b.first = entry.Time
+	}
+	if entry.Time.After(b.last) {
+		b.last = entry.Time
+	}
+
+	// An update is recorded as the deletion of the previous lease followed
+	// immediately by the creation of the next one
+	if p := b.pending; p != nil {
+		b.pending = nil
+		if entry.Type == logprov.TransactionEntry && entry.Action == lease.Create && entry.Lease.Instance == p.Lease.Instance && entry.Time.Equal(p.Time) {
+			prev := b.take(p.Lease.Subject)
+			b.transition(prev, &entry.Lease, entry.Time, true)
+			return
+		}
+		b.transition(b.take(p.Lease.Subject), nil, p.Time, false)
+	}
+
+	switch entry.Type {
+	case logprov.TransactionEntry:
+		switch entry.Action {
+		case lease.Create:
+			b.transition(b.take(entry.Lease.Subject), &entry.Lease, entry.Time, false)
+		case lease.Delete:
+			b.pending = &entry
+		}
+	case logprov.CheckpointStartEntry:
+		b.cp = make(map[lease.Subject]lease.Lease)
+		b.cpErrs = make(map[string]bool)
+	case logprov.CheckpointLeaseEntry:
+		if b.cp != nil {
+			b.cp[entry.Lease.Subject] = entry.Lease
+		}
+	case logprov.CheckpointErrorEntry:
+		if b.cpErrs != nil {
+			b.cpErrs[entry.Lease.Resource] = true
+		}
+	case logprov.CheckpointEndEntry:
+		if b.cp != nil {
+			b.reconcile(entry.Time)
+		}
+		b.cp = nil
+		b.cpErrs = nil
+	}
+}
+
+// Report closes any sessions that are still open and returns the report.
+// If the period of the report is unbounded it is limited to the entries that
+// were added.
+func (b *Builder) Report() Report {
+	if p := b.pending; p != nil {
+		b.pending = nil
+		b.transition(b.take(p.Lease.Subject), nil, p.Time, false)
+	}
+
+	start, end := b.from, b.to
+	if start.IsZero() {
+		start = b.first
+	}
+	if end.IsZero() {
+		end = b.last
+	}
+
+	for subject, current := range b.leases {
+		if current.Consumptive() {
+			b.consume(current, end)
+		}
+		delete(b.leases, subject)
+	}
+
+	report := Report{From: start, To: end}
+	for _, u := range b.resources {
+		report.Resources = append(report.Resources, u.summarize(b.limit(u.Resource.Resource)))
+	}
+	sort.Slice(report.Resources, func(i, j int) bool {
+		return report.Resources[i].Resource < report.Resources[j].Resource
+	})
+	return report
+}
+
+// reconcile brings the state of the builder into agreement with the
+// checkpoint that has just ended.
+func (b *Builder) reconcile(at time.Time) {
+	for subject, current := range b.leases {
+		if !current.Consumptive() || b.cpErrs[subject.Resource] {
+			continue
+		}
+		if _, ok := b.cp[subject]; !ok {
+			// We missed the end of the lease
+			b.transition(b.take(subject), nil, at, true)
+		}
+	}
+	for subject, ls := range b.cp {
+		current, ok := b.leases[subject]
+		if ok && current.Status == ls.Status && current.Weight() == ls.Weight() {
+			continue
+		}
+		ls := ls
+		b.transition(b.take(subject), &ls, at, true)
+	}
+}
+
+// take removes the state of subject from the builder and returns it.
+func (b *Builder) take(subject lease.Subject) *state {
+	current, ok := b.leases[subject]
+	if !ok {
+		return nil
+	}
+	delete(b.leases, subject)
+	return &current
+}
+
+// transition records a change in the state of a lease at the given time.
+// A nil prev indicates a new lease, and a nil next indicates the end of one.
+// A continuation does not end a queued lease or start a session or queue
+// event.
+func (b *Builder) transition(prev *state, next *lease.Lease, at time.Time, continuation bool) {
+	if prev != nil && prev.Consumptive() {
+		b.consume(*prev, at)
+	}
+
+	inRange := b.contains(at)
+
+	if next == nil {
+		if prev != nil && prev.Status == lease.Queued && !continuation && inRange {
+			// The lease was never granted
+			b.usage(prev.Resource).Denials++
+		}
+		return
+	}
+
+	u := b.usage(next.Resource)
+	if inRange {
+		if next.Status == lease.Queued && (prev == nil || prev.Status != lease.Queued) && !continuation {
+			u.Queued++
+		}
+		if user(next) && (prev == nil || !prev.Consumptive()) {
+			u.Sessions++
+			u.user(next.Instance.User).Sessions++
+		}
+	}
+
+	b.leases[next.Subject] = state{Lease: *next, since: at}
+}
+
+// consume records the units consumed by a lease between the time it entered
+// its current status and end.
+func (b *Builder) consume(s state, end time.Time) {
+	start := s.since
+	if !b.from.IsZero() && start.Before(b.from) {
+		start = b.from
+	}
+	if !b.to.IsZero() && end.After(b.to) {
+		end = b.to
+	}
+	if !end.After(start) {
+		return
+	}
+
+	u := b.usage(s.Resource)
+	units := int(s.Weight())
+	d := end.Sub(start)
+	u.seats += time.Duration(units) * d
+	u.deltas = append(u.deltas, delta{at: start, units: units}, delta{at: end, units: -units})
+	if user(&s.Lease) {
+		u.user(s.Instance.User)
+		u.hours[s.Instance.User] += d
+	}
+}
+
+// contains returns true if t is within the period of the report.
+func (b *Builder) contains(t time.Time) bool {
+	if !b.from.IsZero() && t.Before(b.from) {
+		return false
+	}
+	if !b.to.IsZero() && !t.Before(b.to) {
+		return false
+	}
+	return true
+}
+
+func (b *Builder) usage(resource string) *usage {
+	u, ok := b.resources[resource]
+	if !ok {
+		u = &usage{
+			Resource: Resource{Resource: resource},
+			users:    make(map[string]*User),
+			hours:    make(map[string]time.Duration),
+		}
+		b.resources[resource] = u
+	}
+	return u
+}
+
+func (b *Builder) limit(resource string) uint {
+	if b.limits == nil {
+		return 0
+	}
+	return b.limits(resource)
+}
+
+func (u *usage) user(name string) *User {
+	usr, ok := u.users[name]
+	if !ok {
+		usr = &User{User: name}
+		u.users[name] = usr
+	}
+	return usr
+}
+
+// summarize returns the summary of the resource's usage.
+func (u *usage) summarize(limit uint) Resource {
+	r := u.Resource
+	r.Limit = limit
+	r.SeatHours = u.seats.Hours()
+
+	// Sweep through the changes in consumption, applying all of the changes
+	// that happen at the same time before evaluating the level
+	sort.SliceStable(u.deltas, func(i, j int) bool {
+		return u.deltas[i].at.Before(u.deltas[j].at)
+	})
+	var (
+		level   int
+		atLimit time.Duration
+	)
+	for i := 0; i < len(u.deltas); {
+		at := u.deltas[i].at
+		for ; i < len(u.deltas) && u.deltas[i].at.Equal(at); i++ {
+			level += u.deltas[i].units
+		}
+		if level > 0 && uint(level) > r.Peak {
+			r.Peak = uint(level)
+		}
+		if limit > 0 && level >= int(limit) && i < len(u.deltas) {
+			atLimit += u.deltas[i].at.Sub(at)
+		}
+	}
+
+	r.AtLimit = atLimit.Hours()
+
+	for name, usr := range u.users {
+		usr.Hours = u.hours[name].Hours()
+		r.Users = append(r.Users, *usr)
+	}
+	sort.Slice(r.Users, func(i, j int) bool {
+		if r.Users[i].Hours != r.Users[j].Hours {
+			return r.Users[i].Hours > r.Users[j].Hours
+		}
+		return r.Users[i].User < r.Users[j].User
+	})
+
+	return r
+}
+
+// user returns true if ls is held by a user for the purpose of running a
+// program, as opposed to being a hold or a lease in its decay period.
+func user(ls *lease.Lease) bool {
+	return (ls.Status == lease.Active || ls.Status == lease.Borrowed) && ls.Instance.User != ""
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scjalliance/resourceful/provider/logprov"
+)
+
+// textLog is a transaction log in the text format, in which each lease
+// consumes two units of a resource with a limit of two. The first lease is
+// released after an hour, the second gives up waiting in the queue and the
+// third is promoted when the first is released.
+const textLog = `2024/01/02 10:00:00 TX+ host1 jdoe 1 CREATE ACTIVE cad UNITS 2
+2024/01/02 10:00:00 TX host1 jdoe 1 CREATE ACTIVE cad
+2024/01/02 10:10:00 TX+ host2 asmith 2 CREATE QUEUED cad UNITS 2
+2024/01/02 10:20:00 TX+ host2 asmith 2 DELETE QUEUED cad UNITS 2
+2024/01/02 10:30:00 TX+ host3 bjones 3 CREATE QUEUED cad UNITS 2
+2024/01/02 11:00:00 TX+ host1 jdoe 1 DELETE ACTIVE cad UNITS 2
+2024/01/02 11:00:00 TX host1 jdoe 1 DELETE ACTIVE cad
+2024/01/02 11:00:00 TX+ host3 bjones 3 DELETE QUEUED cad UNITS 2
+2024/01/02 11:00:00 TX+ host3 bjones 3 CREATE ACTIVE cad UNITS 2
+2024/01/02 11:00:00 TX host3 bjones 3 CREATE ACTIVE cad
+2024/01/02 12:00:00 TX+ host3 bjones 3 DELETE ACTIVE cad UNITS 2
+2024/01/02 12:00:00 TX host3 bjones 3 DELETE ACTIVE cad
+`
+
+func TestReportFromTextLog(t *testing.T) {
+	builder := NewBuilder(time.Time{}, time.Time{}, func(string) uint { return 2 })
+	scanner := logprov.NewScanner(strings.NewReader(textLog), time.UTC)
+	for scanner.Scan() {
+		builder.Add(scanner.Entry())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if scanner.Plain() {
+		t.Error("log with detail lines was reported as plain")
+	}
+
+	r := builder.Report()
+	if len(r.Resources) != 1 {
+		t.Fatalf("got %d resources, want 1", len(r.Resources))
+	}
+	res := r.Resources[0]
+
+	if res.Peak != 2 {
+		t.Errorf("got peak %d, want 2", res.Peak)
+	}
+	if res.SeatHours != 4 {
+		t.Errorf("got %.2f seat hours, want 4", res.SeatHours)
+	}
+	if res.AtLimit != 2 {
+		t.Errorf("got %.2f hours at limit, want 2", res.AtLimit)
+	}
+	if res.Sessions != 2 {
+		t.Errorf("got %d sessions, want 2", res.Sessions)
+	}
+	if res.Queued != 2 {
+		t.Errorf("got %d queued, want 2", res.Queued)
+	}
+	if res.Denials != 1 {
+		t.Errorf("got %d denials, want 1", res.Denials)
+	}
+}
+
+func TestReportFromPlainTextLog(t *testing.T) {
+	// Logs written before detail lines were added only have the plain lines
+	var lines []string
+	for _, line := range strings.SplitAfter(textLog, "\n") {
+		if !strings.Contains(line, " TX+ ") {
+			lines = append(lines, line)
+		}
+	}
+
+	builder := NewBuilder(time.Time{}, time.Time{}, nil)
+	scanner := logprov.NewScanner(strings.NewReader(strings.Join(lines, "")), time.UTC)
+	for scanner.Scan() {
+		builder.Add(scanner.Entry())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !scanner.Plain() {
+		t.Error("log without detail lines was not reported as plain")
+	}
+
+	r := builder.Report()
+	if len(r.Resources) != 1 {
+		t.Fatalf("got %d resources, want 1", len(r.Resources))
+	}
+	if res := r.Resources[0]; res.SeatHours != 2 || res.Sessions != 2 {
+		t.Errorf("got %.2f seat hours and %d sessions, want 2 and 2", res.SeatHours, res.Sessions)
+	}
+}
@@ -0,0 +1,3 @@
+// Package report summarizes the use of resources as recorded in a lease
+// transaction log.
+package report
@@ -0,0 +1,30 @@
+package report
+
+import "time"
+
+// Report summarizes the use of resources over a period of time.
+type Report struct {
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Resources []Resource `json:"resources"`
+}
+
+// Resource summarizes the use of a resource.
+type Resource struct {
+	Resource  string  `json:"resource"`
+	Limit     uint    `json:"limit,omitempty"` // Zero if the limit is unknown
+	Peak      uint    `json:"peak"`            // Maximum number of units consumed at once
+	AtLimit   float64 `json:"at_limit_hours"`  // Hours during which every unit was consumed
+	SeatHours float64 `json:"seat_hours"`      // Units consumed multiplied by hours consumed
+	Sessions  int     `json:"sessions"`        // Number of sessions that started
+	Queued    int     `json:"queued"`          // Number of times a lease was queued
+	Denials   int     `json:"denials"`         // Number of queued leases that ended without being granted
+	Users     []User  `json:"users,omitempty"`
+}
+
+// User summarizes the use of a resource by a user.
+type User struct {
+	User     string  `json:"user"`
+	Hours    float64 `json:"hours"`    // Hours during which the user held an active or borrowed lease
+	Sessions int     `json:"sessions"` // Number of sessions that started
+}
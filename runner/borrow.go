@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/scjalliance/resourceful/guardian"
+	"github.com/scjalliance/resourceful/lease"
+	"github.com/scjalliance/resourceful/lease/leasetoken"
+)
+
+var (
+	// ErrNotBorrowed is returned when a borrowed lease cannot be found for a
+	// program.
+	ErrNotBorrowed = errors.New("runner: a lease has not been borrowed for the program")
+
+	// ErrNoBorrowKey is returned when a borrowed lease cannot be verified
+	// because the public key of the guardian has not been configured.
+	ErrNoBorrowKey = errors.New("runner: the public key of the guardian that signs borrowed leases has not been configured")
+)
+
+// Borrowed is a signed lease token for a borrowed lease, as it is stored on
+// the local machine.
+type Borrowed struct {
+	Token string `json:"token"`
+}
+
+// Lease verifies the token with the public key of the guardian and returns
+// the borrowed lease it holds.
+func (b Borrowed) Lease(key ed25519.PublicKey) (lease.Lease, error) {
+	if len(key) == 0 {
+		return lease.Lease{}, ErrNoBorrowKey
+	}
+	return leasetoken.Verify(b.Token, key)
+}
+
+// Borrow will attempt to borrow a lease for the program specified by config
+// for the given duration. If duration is zero the maximum duration permitted
+// by the guardian's policies is requested.
+//
+// The signed lease token issued by the guardian is verified with
+// config.BorrowKey and stored on the local machine, which allows the program
+// to be run while the guardian cannot be reached.
+func Borrow(ctx context.Context, client *guardian.Client, config Config, duration time.Duration) (ls lease.Lease, err error) {
+	if len(config.BorrowKey) == 0 {
+		return ls, ErrNoBorrowKey
+	}
+
+	instance, props, err := DetectEnvironment(config)
+	if err != nil {
+		return ls, fmt.Errorf("runner: unable to detect environment: %v", err)
+	}
+
+	response, err := client.Borrow(ctx, lease.Subject{Instance: instance}, props, duration)
+	if err != nil {
+		return ls, err
+	}
+
+	b := Borrowed{Token: response.Token}
+	if ls, err = b.Lease(config.BorrowKey); err != nil {
+		// Don't leave a lease that can't be used counting against the limit
+		client.Return(ctx, response.Lease.Subject)
+		return ls, fmt.Errorf("runner: the guardian issued an invalid lease token: %v", err)
+	}
+
+	if err = saveBorrowed(config.Program, b); err != nil {
+		return ls, fmt.Errorf("runner: unable to store lease token: %v", err)
+	}
+
+	return ls, nil
+}
+
+// Return will attempt to return the lease borrowed for the program specified
+// by config. The stored lease token is removed once the guardian has
+// accepted the return, or if the lease has already expired.
+func Return(ctx context.Context, client *guardian.Client, config Config) error {
+	b, err := loadBorrowed(config.Program)
+	if err != nil {
+		return err
+	}
+
+	ls, err := b.Lease(config.BorrowKey)
+	switch {
+	case err == ErrNoBorrowKey:
+		return err
+	case err == nil && !ls.Expired(time.Now()):
+		if _, err := client.Return(ctx, ls.Subject); err != nil {
+			return err
+		}
+	}
+
+	return removeBorrowed(config.Program)
+}
+
+// LoadBorrowed returns the borrowed lease stored for the program specified
+// by config, after verifying its token with config.BorrowKey. It returns
+// ErrNotBorrowed if a lease has not been borrowed for the program.
+//
+// The lease is only returned if it was borrowed for the same program by the
+// current user on the local host, so that a token can't be copied to
+// another program or machine.
+func LoadBorrowed(config Config) (lease.Lease, error) {
+	b, err := loadBorrowed(config.Program)
+	if err != nil {
+		return lease.Lease{}, err
+	}
+
+	ls, err := b.Lease(config.BorrowKey)
+	if err != nil {
+		return lease.Lease{}, err
+	}
+
+	instance, props, err := DetectEnvironment(config)
+	if err != nil {
+		return lease.Lease{}, fmt.Errorf("runner: unable to detect environment: %v", err)
+	}
+	if err := checkBorrowed(ls, instance, props); err != nil {
+		return lease.Lease{}, err
+	}
+
+	return ls, nil
+}
+
+// checkBorrowed returns an error if ls is not a borrowed lease for the
+// program described by props, held by the host and user of instance.
+//
+// The resource of the lease was chosen by the guardian's policies when it
+// was borrowed, and the properties that the policies matched are signed
+// along with it. The program's properties tie the lease to the program.
+func checkBorrowed(ls lease.Lease, instance lease.Instance, props lease.Properties) error {
+	switch {
+	case ls.Status != lease.Borrowed || ls.Resource == "":
+		return errors.New("runner: the lease token does not hold a borrowed lease")
+	case !strings.EqualFold(ls.Instance.Host, instance.Host):
+		return fmt.Errorf("runner: the lease was borrowed by another host (%s)", ls.Instance.Host)
+	case !strings.EqualFold(ls.Instance.User, instance.User):
+		return fmt.Errorf("runner: the lease was borrowed by another user (%s)", ls.Instance.User)
+	case !strings.EqualFold(ls.Properties["program.name"], props["program.name"]):
+		return fmt.Errorf("runner: the lease was borrowed for another program (%s)", ls.Properties["program.name"])
+	}
+	return nil
+}
+
+// borrowedPath returns the path of the file that holds the lease token
+// borrowed for program.
+func borrowedPath(program string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.ToLower(filepath.Base(program))
+	return filepath.Join(dir, "resourceful", "borrowed", name+".json"), nil
+}
+
+func loadBorrowed(program string) (b Borrowed, err error) {
+	path, err := borrowedPath(program)
+	if err != nil {
+		return b, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, ErrNotBorrowed
+		}
+		return b, err
+	}
+	if err = json.Unmarshal(data, &b); err != nil {
+		return b, fmt.Errorf("runner: invalid lease token file \"%s\": %v", path, err)
+	}
+	return b, nil
+}
+
+func saveBorrowed(program string, b Borrowed) error {
+	path, err := borrowedPath(program)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func removeBorrowed(program string) error {
+	path, err := borrowedPath(program)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
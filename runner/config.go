@@ -1,10 +1,20 @@
 package runner
 
-import "github.com/scjalliance/resourceful/lease/leaseui"
+import (
+	"crypto/ed25519"
+
+	"github.com/scjalliance/resourceful/lease/leaseui"
+)
 
 // Config holds configuration for a runner.
 type Config struct {
 	Icon    *leaseui.Icon
 	Program string
 	Args    []string
+
+	// BorrowKey is the public key of the guardian that signs borrowed
+	// leases. It must be distributed to each machine out of band, because
+	// anything stored alongside a borrowed lease can be forged by the user.
+	// Leases cannot be borrowed or used offline without it.
+	BorrowKey ed25519.PublicKey
 }
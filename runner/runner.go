@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +31,7 @@ type Runner struct {
 	restored   bool // Are we waiting for the user to acknowledge that the connection was restored?
 	ui         *leaseui.Manager
 	dismissal  time.Time
+	borrowed   *lease.Lease // A borrowed lease that we're running under while offline
 
 	mutex sync.Mutex // Held during processing
 }
@@ -136,9 +138,11 @@ func (r *Runner) run(ctx context.Context) (err error) {
 		} else {
 			switch state.Lease.Status {
 			case lease.Queued:
-				err = r.handleQueued(ctx, shutdown)
-			case lease.Active:
+				err = r.handleQueued(ctx, state, shutdown)
+			case lease.Active, lease.Borrowed:
 				err = r.handleActive(ctx, shutdown)
+			case lease.Released:
+				err = r.handleReleased(ctx, state, shutdown)
 			default:
 				err = fmt.Errorf("unexpected lease status: \"%s\"", state.Lease.Status)
 			}
@@ -156,27 +160,48 @@ func (r *Runner) run(ctx context.Context) (err error) {
 func (r *Runner) handleError(ctx context.Context, state lease.State, shutdown context.CancelFunc) error {
 	r.failed = true
 
+	now := time.Now()
+
 	if !r.running {
 		log.Printf("Lease acquisition failed: %v", state.Err)
+		ls, err := LoadBorrowed(r.config)
+		switch {
+		case err == nil && !ls.Expired(now):
+			log.Printf("Using lease borrowed until %s", ls.ExpirationTime().Format(time.RFC3339))
+			r.borrowed = &ls
+			r.ui.Change(leaseui.None, nil)
+			return r.execute(ctx, shutdown)
+		case err != nil && err != ErrNotBorrowed:
+			log.Printf("Unable to use borrowed lease: %v", err)
+		}
 		r.ui.Change(leaseui.Startup, r.startupCallback(shutdown))
 		return nil
 	}
 
 	log.Printf("Lease renewal failed: %v", state.Err)
 
-	now := time.Now()
-	if state.Lease.Expired(now) {
+	ls := state.Lease
+	if r.borrowed != nil {
+		ls = *r.borrowed
+	}
+
+	if ls.Expired(now) {
 		log.Printf("Lease has expired. Shutting down %s", r.config.Program)
 		shutdown()
 		return nil
 	}
 
-	expiration := state.Lease.ExpirationTime()
+	expiration := ls.ExpirationTime()
 	remaining := expiration.Sub(now)
 
 	log.Printf("Lease time remaining: %s", remaining.String())
 
-	if shouldWarn(state.Lease, now, r.dismissal) {
+	if ls.Status == lease.Borrowed && remaining >= time.Minute*1 {
+		// Borrowed leases are meant to be used while disconnected
+		return nil
+	}
+
+	if shouldWarn(ls, now, r.dismissal) {
 		log.Printf("Warning the user")
 		r.warned = true
 		r.ui.Change(leaseui.Disconnected, r.disconnectedCallback())
@@ -186,7 +211,7 @@ func (r *Runner) handleError(ctx context.Context, state lease.State, shutdown co
 }
 
 // handleQueued processes queued lease acquisitions.
-func (r *Runner) handleQueued(ctx context.Context, shutdown context.CancelFunc) (err error) {
+func (r *Runner) handleQueued(ctx context.Context, state lease.State, shutdown context.CancelFunc) (err error) {
 	if r.running {
 		// TODO: When a lease is downgraded to released or queued status
 		//       show the lease UI again?
@@ -200,13 +225,35 @@ func (r *Runner) handleQueued(ctx context.Context, shutdown context.CancelFunc)
 		return nil
 	}
 
-	log.Printf("Lease queued")
+	if state.Message != "" {
+		log.Printf("Lease queued: %s", state.Message)
+	} else {
+		log.Printf("Lease queued")
+	}
 
 	r.ui.Change(leaseui.Queued, r.queuedCallback(shutdown))
 
 	return nil
 }
 
+// handleReleased processes lease acquisitions that were refused by the
+// server, such as when a session or quota has been exhausted.
+func (r *Runner) handleReleased(ctx context.Context, state lease.State, shutdown context.CancelFunc) (err error) {
+	reason := state.Message
+	if reason == "" {
+		reason = "The lease was refused by the server."
+	}
+
+	if r.running {
+		log.Printf("%s Shutting down %s", reason, r.config.Program)
+		shutdown()
+		leaseui.Notify(fmt.Sprintf("%s has been stopped", filepath.Base(r.config.Program)), reason)
+		return nil
+	}
+
+	return fmt.Errorf("lease refused: %s", reason)
+}
+
 // handleActive processes active lease acquisitions.
 func (r *Runner) handleActive(ctx context.Context, completion context.CancelFunc) (err error) {
 	switch {
@@ -231,6 +278,7 @@ func (r *Runner) handleActive(ctx context.Context, completion context.CancelFunc
 
 	r.warned = false
 	r.failed = false
+	r.borrowed = nil
 
 	if !r.running {
 		return r.execute(ctx, completion)
@@ -329,6 +377,7 @@ func (r *Runner) execute(ctx context.Context, completion context.CancelFunc) (er
 	log.Printf("Executing %s %s", r.config.Program, strings.Join(r.config.Args, " "))
 
 	cmd := exec.CommandContext(ctx, r.config.Program, r.config.Args...)
+	attachStdio(cmd)
 	err = cmd.Start()
 	if err != nil {
 		return
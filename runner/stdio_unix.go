@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// attachStdio connects the command to the standard streams of the runner so
+// that terminal programs can be used interactively.
+func attachStdio(cmd *exec.Cmd) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+}
@@ -0,0 +1,10 @@
+//go:build windows
+// +build windows
+
+package runner
+
+import "os/exec"
+
+// attachStdio does nothing on windows, where programs are launched without
+// a console.
+func attachStdio(cmd *exec.Cmd) {}
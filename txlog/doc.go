@@ -0,0 +1,4 @@
+// Package txlog provides structured lease transaction log records, rotation
+// of transaction log files and iteration over the segments of a rotated
+// transaction log.
+package txlog
@@ -0,0 +1,234 @@
+package txlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SegmentTimeLayout is the layout of the timestamp appended to the path of a
+// transaction log when a segment is rotated out of it. Segment names sort in
+// the order the segments were rotated.
+const SegmentTimeLayout = "20060102T150405.000000000Z"
+
+// Rotation describes when a transaction log file is rotated and what happens
+// to the segments rotated out of it. The zero value never rotates.
+type Rotation struct {
+	MaxSize  int64         // Size in bytes at which the log is rotated, or zero for no limit
+	MaxAge   time.Duration // Age at which the log is rotated, or zero for no limit
+	Compress bool          // Compress rotated segments with gzip
+	Keep     int           // Number of rotated segments to keep, or zero to keep them all
+}
+
+// File is a transaction log file that is rotated according to a rotation
+// policy. Rotation happens before a write that would exceed the policy, so
+// records are never split across segments.
+//
+// The age of a file is measured from the time it was opened or last
+// rotated.
+type File struct {
+	path     string
+	rotation Rotation
+
+	mutex   sync.Mutex
+	file    *os.File
+	size    int64
+	opened  time.Time
+	closed  bool
+	pending sync.WaitGroup // Compression and pruning of rotated segments
+	cleanup sync.Mutex     // Held while compressing and pruning segments
+}
+
+// OpenFile opens the transaction log file at path for appending, creating it
+// if necessary.
+func OpenFile(path string, rotation Rotation) (*File, error) {
+	f := &File{
+		path:     path,
+		rotation: rotation,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the file, rotating it first if necessary.
+func (f *File) Write(p []byte) (n int, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if f.due(int64(len(p)), time.Now()) {
+		if err := f.rotate(); err != nil {
+			return 0, fmt.Errorf("unable to rotate transaction log: %v", err)
+		}
+	}
+
+	n, err = f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file regardless of its size and age. It does nothing if
+// the file is empty.
+func (f *File) Rotate() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.size == 0 {
+		return nil
+	}
+	return f.rotate()
+}
+
+// Close closes the file. It waits for any rotated segments to be compressed
+// and pruned.
+func (f *File) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	err := f.file.Close()
+	f.pending.Wait()
+	return err
+}
+
+// due returns true if a write of n bytes at the given time must be preceded
+// by rotation.
+//
+// due assumes that a lock is held for the duration of the call.
+func (f *File) due(n int64, now time.Time) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.rotation.MaxSize > 0 && f.size+n > f.rotation.MaxSize {
+		return true
+	}
+	if f.rotation.MaxAge > 0 && now.Sub(f.opened) >= f.rotation.MaxAge {
+		return true
+	}
+	return false
+}
+
+// open opens the file at the path of the log.
+//
+// open assumes that a lock is held for the duration of the call.
+func (f *File) open() error {
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.opened = time.Now()
+	return nil
+}
+
+// rotate renames the current file to a segment and opens a new one in its
+// place. Compression and pruning of segments happen in the background.
+//
+// rotate assumes that a lock is held for the duration of the call.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	segment := f.path + "." + time.Now().UTC().Format(SegmentTimeLayout)
+	if err := os.Rename(f.path, segment); err != nil {
+		// Keep writing to the existing file
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	if f.rotation.Compress || f.rotation.Keep > 0 {
+		f.pending.Add(1)
+		go func() {
+			defer f.pending.Done()
+			f.cleanup.Lock()
+			defer f.cleanup.Unlock()
+			if f.rotation.Compress {
+				compress(segment)
+			}
+			if f.rotation.Keep > 0 {
+				prune(f.path, f.rotation.Keep)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// compress replaces the segment at path with a gzip-compressed copy.
+func compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
+
+// prune removes the oldest segments of the transaction log at path so that
+// no more than keep of them remain.
+func prune(path string, keep int) error {
+	segments, err := Segments(path)
+	if err != nil {
+		return err
+	}
+	for len(segments) > keep {
+		if err := os.Remove(segments[0]); err != nil {
+			return err
+		}
+		segments = segments[1:]
+	}
+	return nil
+}
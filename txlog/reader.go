@@ -0,0 +1,217 @@
+package txlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxLineSize is the maximum size of a line that can be read from a
+// transaction log.
+const MaxLineSize = 4 * 1024 * 1024
+
+// Segments returns the paths of the segments that have been rotated out of
+// the transaction log at path, in the order they were rotated. The path of
+// the log itself is not included.
+func Segments(path string) (segments []string, err error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base+".") {
+			continue
+		}
+		if names[name+".gz"] {
+			// The segment has been compressed but not yet removed
+			continue
+		}
+		suffix := strings.TrimSuffix(strings.TrimPrefix(name, base+"."), ".gz")
+		if _, err := time.Parse(SegmentTimeLayout, suffix); err == nil {
+			segments = append(segments, filepath.Join(filepath.Dir(path), name))
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segmentName(segments[i]) < segmentName(segments[j])
+	})
+	return segments, nil
+}
+
+// OpenSegments returns a reader that reads every segment of the transaction
+// log at path in order, followed by the log itself. Compressed segments are
+// decompressed as they are read.
+func OpenSegments(path string) (io.ReadCloser, error) {
+	segments, err := Segments(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		segments = append(segments, path)
+	} else if len(segments) == 0 {
+		return nil, err
+	}
+	return &segmentReader{paths: segments}, nil
+}
+
+// segmentReader reads a sequence of segments, opening each one in turn.
+type segmentReader struct {
+	paths []string
+	file  *os.File
+	r     io.Reader
+}
+
+func (sr *segmentReader) Read(p []byte) (n int, err error) {
+	for {
+		if sr.r == nil {
+			if len(sr.paths) == 0 {
+				return 0, io.EOF
+			}
+			if err := sr.next(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err = sr.r.Read(p)
+		if err == io.EOF {
+			sr.file.Close()
+			sr.file, sr.r = nil, nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// next opens the next segment.
+func (sr *segmentReader) next() error {
+	path := sr.paths[0]
+	sr.paths = sr.paths[1:]
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	sr.file, sr.r = file, file
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			sr.file, sr.r = nil, nil
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		sr.r = zr
+	}
+	return nil
+}
+
+func (sr *segmentReader) Close() error {
+	sr.paths = nil
+	if sr.file != nil {
+		err := sr.file.Close()
+		sr.file, sr.r = nil, nil
+		return err
+	}
+	return nil
+}
+
+// Reader reads the structured records of a transaction log. Lines that
+// are not structured records are skipped.
+type Reader struct {
+	closer  io.Closer
+	scanner *bufio.Scanner
+	line    int
+	record  Record
+	err     error
+}
+
+// NewReader returns a reader that reads records from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, MaxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// Open returns a reader that reads the records of every segment of the
+// transaction log at path in order. The reader must be closed when it is no
+// longer needed.
+func Open(path string) (*Reader, error) {
+	rc, err := OpenSegments(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReader(rc)
+	r.closer = rc
+	return r, nil
+}
+
+// Scan advances the reader to the next record, which will then be available
+// through the Record method. It returns false when the scan stops, either by
+// reaching the end of the input or an error.
+func (r *Reader) Scan() bool {
+	if r.err != nil {
+		return false
+	}
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if !IsRecord(line) {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			r.err = fmt.Errorf("line %d: %v", r.line, err)
+			return false
+		}
+		r.record = record
+		return true
+	}
+	r.err = r.scanner.Err()
+	return false
+}
+
+// Record returns the most recent record read by a call to Scan.
+func (r *Reader) Record() Record {
+	return r.record
+}
+
+// Err returns the first error that was encountered by the reader.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Close closes the underlying segments if the reader was returned by Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// IsRecord returns true if line appears to hold a structured record.
+func IsRecord(line []byte) bool {
+	return len(line) > 0 && line[0] == '{'
+}
+
+// segmentName returns the name of a segment without its compression suffix,
+// which determines the order of the segments.
+func segmentName(path string) string {
+	return strings.TrimSuffix(path, ".gz")
+}
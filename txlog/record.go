@@ -0,0 +1,35 @@
+package txlog
+
+import (
+	"time"
+
+	"github.com/scjalliance/resourceful/lease"
+)
+
+// RecordType identifies the type of a transaction log record.
+type RecordType string
+
+// Transaction log record types
+const (
+	TransactionRecord     RecordType = "tx"       // An effect of a committed transaction
+	CheckpointStartRecord RecordType = "cp_start" // The start of a checkpoint block
+	CheckpointLeaseRecord RecordType = "cp_lease" // A lease recorded within a checkpoint block
+	CheckpointErrorRecord RecordType = "cp_error" // A resource that could not be recorded within a checkpoint block
+	CheckpointEndRecord   RecordType = "cp_end"   // The end of a checkpoint block
+)
+
+// Record is a structured transaction log record. Each record is written as a
+// single line of JSON.
+//
+// Every record within a checkpoint block carries the time at which the
+// checkpoint was taken.
+type Record struct {
+	Type     RecordType    `json:"type"`
+	Time     time.Time     `json:"time"`
+	Resource string        `json:"resource,omitempty"`
+	Revision uint64        `json:"revision,omitempty"` // Revision of the lease data the transaction was applied to, or that the checkpoint observed
+	Effect   *lease.Effect `json:"effect,omitempty"`   // Effect of a transaction
+	Lease    *lease.Lease  `json:"lease,omitempty"`    // Lease recorded within a checkpoint block
+	Operator string        `json:"operator,omitempty"` // Administrator that took the action, if any
+	Error    string        `json:"error,omitempty"`
+}